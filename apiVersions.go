@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// apiVersions has the api versions the built-in manifests are rendered with, so they're accepted by the cluster they get applied to
+type apiVersions struct {
+	Ingress                 string
+	PodDisruptionBudget     string
+	CronJob                 string
+	HorizontalPodAutoscaler string
+}
+
+var (
+	// api versions for current clusters, also used if the cluster version can't be determined since the older ones have been removed
+	latestAPIVersions = apiVersions{
+		Ingress:                 "networking.k8s.io/v1",
+		PodDisruptionBudget:     "policy/v1",
+		CronJob:                 "batch/v1",
+		HorizontalPodAutoscaler: "autoscaling/v2",
+	}
+
+	clusterVersionRegex = regexp.MustCompile(`^v?(\d+)\.(\d+)`)
+)
+
+// getAPIVersions returns the api versions to use for a cluster with the given server version, like v1.21.5-gke.1302; networking.k8s.io/v1
+// ingress is available from 1.19, policy/v1 pod disruption budget and batch/v1 cronjob from 1.21 and autoscaling/v2 horizontal pod
+// autoscaler from 1.23
+func getAPIVersions(clusterVersion string) apiVersions {
+
+	major, minor, err := parseClusterVersion(clusterVersion)
+	if err != nil {
+		return latestAPIVersions
+	}
+
+	versions := latestAPIVersions
+	if major == 1 && minor < 19 {
+		versions.Ingress = "extensions/v1beta1"
+	}
+	if major == 1 && minor < 21 {
+		versions.PodDisruptionBudget = "policy/v1beta1"
+		versions.CronJob = "batch/v1beta1"
+	}
+	if major == 1 && minor < 23 {
+		versions.HorizontalPodAutoscaler = "autoscaling/v2beta2"
+	}
+
+	return versions
+}
+
+// parseClusterVersion returns the major and minor version of a kubernetes version string
+func parseClusterVersion(clusterVersion string) (major, minor int, err error) {
+
+	matches := clusterVersionRegex.FindStringSubmatch(clusterVersion)
+	if matches == nil {
+		return 0, 0, fmt.Errorf("Version %v is not a valid kubernetes version", clusterVersion)
+	}
+
+	major, err = strconv.Atoi(matches[1])
+	if err != nil {
+		return 0, 0, err
+	}
+	minor, err = strconv.Atoi(matches[2])
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return major, minor, nil
+}
+
+// parseServerVersion returns the server git version from the output of kubectl version -o json
+func parseServerVersion(output []byte) (string, error) {
+
+	var versionOutput struct {
+		ServerVersion *struct {
+			GitVersion string `json:"gitVersion"`
+		} `json:"serverVersion"`
+	}
+	err := json.Unmarshal(output, &versionOutput)
+	if err != nil {
+		return "", err
+	}
+	if versionOutput.ServerVersion == nil || versionOutput.ServerVersion.GitVersion == "" {
+		return "", fmt.Errorf("Output has no server version")
+	}
+
+	return versionOutput.ServerVersion.GitVersion, nil
+}
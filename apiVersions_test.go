@@ -0,0 +1,84 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAPIVersions(t *testing.T) {
+
+	t.Run("ReturnsLatestAPIVersionsIfClusterVersionIsUnknown", func(t *testing.T) {
+
+		// act
+		versions := getAPIVersions("")
+
+		assert.Equal(t, "networking.k8s.io/v1", versions.Ingress)
+		assert.Equal(t, "policy/v1", versions.PodDisruptionBudget)
+		assert.Equal(t, "batch/v1", versions.CronJob)
+		assert.Equal(t, "autoscaling/v2", versions.HorizontalPodAutoscaler)
+	})
+
+	t.Run("ReturnsLatestAPIVersionsForCurrentCluster", func(t *testing.T) {
+
+		// act
+		versions := getAPIVersions("v1.27.3-gke.100")
+
+		assert.Equal(t, latestAPIVersions, versions)
+	})
+
+	t.Run("ReturnsBetaHorizontalPodAutoscalerForClusterOlderThan123", func(t *testing.T) {
+
+		// act
+		versions := getAPIVersions("v1.22.17-gke.4000")
+
+		assert.Equal(t, "networking.k8s.io/v1", versions.Ingress)
+		assert.Equal(t, "policy/v1", versions.PodDisruptionBudget)
+		assert.Equal(t, "batch/v1", versions.CronJob)
+		assert.Equal(t, "autoscaling/v2beta2", versions.HorizontalPodAutoscaler)
+	})
+
+	t.Run("ReturnsBetaPodDisruptionBudgetAndCronJobForClusterOlderThan121", func(t *testing.T) {
+
+		// act
+		versions := getAPIVersions("v1.20.15-gke.1000")
+
+		assert.Equal(t, "networking.k8s.io/v1", versions.Ingress)
+		assert.Equal(t, "policy/v1beta1", versions.PodDisruptionBudget)
+		assert.Equal(t, "batch/v1beta1", versions.CronJob)
+	})
+
+	t.Run("ReturnsExtensionsIngressForClusterOlderThan119", func(t *testing.T) {
+
+		// act
+		versions := getAPIVersions("v1.18.20")
+
+		assert.Equal(t, "extensions/v1beta1", versions.Ingress)
+		assert.Equal(t, "policy/v1beta1", versions.PodDisruptionBudget)
+		assert.Equal(t, "batch/v1beta1", versions.CronJob)
+	})
+}
+
+func TestParseServerVersion(t *testing.T) {
+
+	t.Run("ReturnsGitVersionOfServer", func(t *testing.T) {
+
+		output := []byte(`{"clientVersion":{"major":"1","minor":"27","gitVersion":"v1.27.1"},"serverVersion":{"major":"1","minor":"21+","gitVersion":"v1.21.5-gke.1302"}}`)
+
+		// act
+		version, err := parseServerVersion(output)
+
+		assert.Nil(t, err)
+		assert.Equal(t, "v1.21.5-gke.1302", version)
+	})
+
+	t.Run("ReturnsErrorIfOutputHasNoServerVersion", func(t *testing.T) {
+
+		output := []byte(`{"clientVersion":{"major":"1","minor":"27","gitVersion":"v1.27.1"}}`)
+
+		// act
+		_, err := parseServerVersion(output)
+
+		assert.NotNil(t, err)
+	})
+}
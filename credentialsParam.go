@@ -18,13 +18,13 @@ func (p *CredentialsParam) SetDefaults(releaseName string) {
 }
 
 // ValidateRequiredProperties checks whether all needed properties are set
-func (p *CredentialsParam) ValidateRequiredProperties() (bool, []error) {
+func (p *CredentialsParam) ValidateRequiredProperties() (bool, []ValidationError) {
 
-	errors := []error{}
+	errors := []ValidationError{}
 
 	// validate control params
 	if p.Credentials == "" {
-		errors = append(errors, fmt.Errorf("Credentials property is required; set it via credentials property on this stage"))
+		errors = append(errors, newValidationError("credentials", "required", "Credentials property is required; set it via credentials property on this stage"))
 	}
 
 	return len(errors) == 0, errors
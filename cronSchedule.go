@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type cronField struct {
+	name  string
+	min   int
+	max   int
+	names map[string]int
+}
+
+var (
+	cronFields = []cronField{
+		{name: "minute", min: 0, max: 59},
+		{name: "hour", min: 0, max: 23},
+		{name: "day of month", min: 1, max: 31},
+		{name: "month", min: 1, max: 12, names: map[string]int{"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6, "jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12}},
+		{name: "day of week", min: 0, max: 7, names: map[string]int{"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6}},
+	}
+
+	cronDescriptors = []string{"@yearly", "@annually", "@monthly", "@weekly", "@daily", "@midnight", "@hourly"}
+)
+
+// validateCronSchedule checks whether schedule is valid in the cron format supported by kubernetes cronjobs
+func validateCronSchedule(schedule string) error {
+
+	schedule = strings.TrimSpace(schedule)
+
+	if strings.HasPrefix(schedule, "@") {
+		for _, d := range cronDescriptors {
+			if schedule == d {
+				return nil
+			}
+		}
+		return fmt.Errorf("Descriptor %v is unknown; allowed descriptors are %v", schedule, strings.Join(cronDescriptors, ", "))
+	}
+
+	fields := strings.Fields(schedule)
+	if len(fields) != len(cronFields) {
+		return fmt.Errorf("Schedule should have 5 fields (minute, hour, day of month, month and day of week) but has %v", len(fields))
+	}
+
+	for i, field := range fields {
+		err := cronFields[i].validate(field)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (f cronField) validate(value string) error {
+
+	for _, part := range strings.Split(value, ",") {
+		rangePart := part
+
+		if stepParts := strings.SplitN(part, "/", 2); len(stepParts) == 2 {
+			rangePart = stepParts[0]
+			step, err := strconv.Atoi(stepParts[1])
+			if err != nil || step <= 0 {
+				return fmt.Errorf("Step %v in %v field %v should be a positive number", stepParts[1], f.name, value)
+			}
+		}
+
+		if rangePart == "*" || rangePart == "?" {
+			continue
+		}
+
+		bounds := strings.SplitN(rangePart, "-", 2)
+		start, err := f.parseValue(bounds[0])
+		if err != nil {
+			return fmt.Errorf("%v in %v field %v", err, f.name, value)
+		}
+		if len(bounds) == 2 {
+			end, err := f.parseValue(bounds[1])
+			if err != nil {
+				return fmt.Errorf("%v in %v field %v", err, f.name, value)
+			}
+			if end < start {
+				return fmt.Errorf("Range %v in %v field %v ends before it starts", rangePart, f.name, value)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (f cronField) parseValue(value string) (int, error) {
+	if number, ok := f.names[strings.ToLower(value)]; ok {
+		return number, nil
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("Value %v is not a number", value)
+	}
+	if number < f.min || number > f.max {
+		return 0, fmt.Errorf("Value %v is outside the allowed range %v-%v", value, f.min, f.max)
+	}
+	return number, nil
+}
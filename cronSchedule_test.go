@@ -0,0 +1,76 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateCronSchedule(t *testing.T) {
+
+	t.Run("ReturnsNilForValidSchedules", func(t *testing.T) {
+
+		schedules := []string{
+			"*/5 * * * *",
+			"0 3 * * 1-5",
+			"15,45 8-18/2 1 jan-jun MON",
+			"0 0 ? * sun",
+			"@hourly",
+		}
+
+		for _, schedule := range schedules {
+			// act
+			err := validateCronSchedule(schedule)
+
+			assert.Nil(t, err, schedule)
+		}
+	})
+
+	t.Run("ReturnsErrorIfNumberOfFieldsIsIncorrect", func(t *testing.T) {
+
+		// act
+		err := validateCronSchedule("*/5 * * *")
+
+		assert.NotNil(t, err)
+	})
+
+	t.Run("ReturnsErrorIfValueIsOutOfRange", func(t *testing.T) {
+
+		// act
+		err := validateCronSchedule("0 24 * * *")
+
+		assert.NotNil(t, err)
+	})
+
+	t.Run("ReturnsErrorIfStepIsNotPositive", func(t *testing.T) {
+
+		// act
+		err := validateCronSchedule("*/0 * * * *")
+
+		assert.NotNil(t, err)
+	})
+
+	t.Run("ReturnsErrorIfRangeIsReversed", func(t *testing.T) {
+
+		// act
+		err := validateCronSchedule("0 0 * * 5-1")
+
+		assert.NotNil(t, err)
+	})
+
+	t.Run("ReturnsErrorIfNameIsUnknown", func(t *testing.T) {
+
+		// act
+		err := validateCronSchedule("0 0 * foo *")
+
+		assert.NotNil(t, err)
+	})
+
+	t.Run("ReturnsErrorIfDescriptorIsUnknown", func(t *testing.T) {
+
+		// act
+		err := validateCronSchedule("@every-minute")
+
+		assert.NotNil(t, err)
+	})
+}
@@ -1,5 +1,9 @@
 package main
 
+import (
+	"encoding/json"
+)
+
 // GKECredentials represents the credentials of type kubernetes-engine as defined in the server config and passed to this trusted image
 type GKECredentials struct {
 	Name                 string                            `json:"name,omitempty"`
@@ -28,3 +32,27 @@ func GetCredentialsByName(c []GKECredentials, credentialName string) *GKECredent
 
 	return nil
 }
+
+// GetCredentialDefaultsJSON returns the defaults of a credential as they were passed in, so they can be checked for unknown properties
+func GetCredentialDefaultsJSON(credentialsJSON []byte, credentialName string) (json.RawMessage, error) {
+
+	var credentials []struct {
+		Name                 string `json:"name"`
+		AdditionalProperties struct {
+			Defaults json.RawMessage `json:"defaults"`
+		} `json:"additionalProperties"`
+	}
+
+	err := json.Unmarshal(credentialsJSON, &credentials)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, cred := range credentials {
+		if cred.Name == credentialName {
+			return cred.AdditionalProperties.Defaults, nil
+		}
+	}
+
+	return nil, nil
+}
@@ -37,3 +37,28 @@ func TestGetCredentialsByName(t *testing.T) {
 		assert.Nil(t, credential)
 	})
 }
+
+func TestGetCredentialDefaultsJSON(t *testing.T) {
+
+	t.Run("ReturnsDefaultsOfCredentialWithName", func(t *testing.T) {
+
+		credentialsJSON := `[{"name":"gke-staging","additionalProperties":{"defaults":{"namespace":"staging"}}},{"name":"gke-production","additionalProperties":{"defaults":{"namespace":"production"}}}]`
+
+		// act
+		defaultsJSON, err := GetCredentialDefaultsJSON([]byte(credentialsJSON), "gke-production")
+
+		assert.Nil(t, err)
+		assert.Equal(t, `{"namespace":"production"}`, string(defaultsJSON))
+	})
+
+	t.Run("ReturnsNilIfCredentialHasNoDefaults", func(t *testing.T) {
+
+		credentialsJSON := `[{"name":"gke-production","additionalProperties":{"project":"my-project"}}]`
+
+		// act
+		defaultsJSON, err := GetCredentialDefaultsJSON([]byte(credentialsJSON), "gke-production")
+
+		assert.Nil(t, err)
+		assert.Nil(t, defaultsJSON)
+	})
+}
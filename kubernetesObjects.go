@@ -0,0 +1,893 @@
+package main
+
+import (
+	"sort"
+	"strconv"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// The types below mirror the subset of the Kubernetes api objects that the built-in manifests use; they're serialized with yaml.Marshal, so
+// values never have to be escaped by hand
+
+// TypeMeta has the api version and kind of an object
+type TypeMeta struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+}
+
+// ObjectMeta has the metadata of an object
+type ObjectMeta struct {
+	Name        string            `yaml:"name,omitempty"`
+	Namespace   string            `yaml:"namespace,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+// IntOrString is a value that's serialized as a number if it's numeric and as a string otherwise, like a port name or percentage
+type IntOrString string
+
+// MarshalYAML serializes numeric values as a number
+func (v IntOrString) MarshalYAML() (interface{}, error) {
+	if i, err := strconv.Atoi(string(v)); err == nil {
+		return i, nil
+	}
+	return string(v), nil
+}
+
+// Quantity is a resource quantity like 100m or 2Gi, serialized as a number if it's a plain integer
+type Quantity string
+
+// MarshalYAML serializes plain integers as a number
+func (q Quantity) MarshalYAML() (interface{}, error) {
+	if i, err := strconv.Atoi(string(q)); err == nil {
+		return i, nil
+	}
+	return string(q), nil
+}
+
+// Namespace is a core/v1 Namespace
+type Namespace struct {
+	TypeMeta `yaml:",inline"`
+	Metadata ObjectMeta `yaml:"metadata"`
+}
+
+// ServiceAccount is a core/v1 ServiceAccount
+type ServiceAccount struct {
+	TypeMeta `yaml:",inline"`
+	Metadata ObjectMeta `yaml:"metadata"`
+}
+
+// Secret is a core/v1 Secret
+type Secret struct {
+	TypeMeta `yaml:",inline"`
+	Metadata ObjectMeta        `yaml:"metadata"`
+	Type     string            `yaml:"type,omitempty"`
+	Data     map[string]string `yaml:"data,omitempty"`
+}
+
+// ConfigMap is a core/v1 ConfigMap
+type ConfigMap struct {
+	TypeMeta `yaml:",inline"`
+	Metadata ObjectMeta        `yaml:"metadata"`
+	Data     map[string]string `yaml:"data,omitempty"`
+}
+
+// Service is a core/v1 Service
+type Service struct {
+	TypeMeta `yaml:",inline"`
+	Metadata ObjectMeta  `yaml:"metadata"`
+	Spec     ServiceSpec `yaml:"spec"`
+}
+
+// ServiceSpec is the spec of a Service
+type ServiceSpec struct {
+	Type                     string            `yaml:"type,omitempty"`
+	ClusterIP                string            `yaml:"clusterIP,omitempty"`
+	LoadBalancerSourceRanges []string          `yaml:"loadBalancerSourceRanges,omitempty"`
+	Ports                    []ServicePort     `yaml:"ports,omitempty"`
+	Selector                 map[string]string `yaml:"selector,omitempty"`
+}
+
+// ServicePort is a port exposed by a Service
+type ServicePort struct {
+	Name       string      `yaml:"name"`
+	Port       int         `yaml:"port"`
+	TargetPort IntOrString `yaml:"targetPort,omitempty"`
+	Protocol   string      `yaml:"protocol,omitempty"`
+}
+
+// Deployment is an apps/v1 Deployment
+type Deployment struct {
+	TypeMeta `yaml:",inline"`
+	Metadata ObjectMeta     `yaml:"metadata"`
+	Spec     DeploymentSpec `yaml:"spec"`
+}
+
+// DeploymentSpec is the spec of a Deployment
+type DeploymentSpec struct {
+	Replicas             *int               `yaml:"replicas,omitempty"`
+	Strategy             DeploymentStrategy `yaml:"strategy"`
+	RevisionHistoryLimit int                `yaml:"revisionHistoryLimit,omitempty"`
+	Selector             LabelSelector      `yaml:"selector"`
+	Template             PodTemplateSpec    `yaml:"template"`
+}
+
+// DeploymentStrategy defines how a Deployment replaces its pods
+type DeploymentStrategy struct {
+	Type          string                   `yaml:"type"`
+	RollingUpdate *RollingUpdateDeployment `yaml:"rollingUpdate,omitempty"`
+}
+
+// RollingUpdateDeployment limits the number of pods added and removed during a rolling update
+type RollingUpdateDeployment struct {
+	MaxSurge       IntOrString `yaml:"maxSurge,omitempty"`
+	MaxUnavailable IntOrString `yaml:"maxUnavailable,omitempty"`
+}
+
+// StatefulSet is an apps/v1 StatefulSet
+type StatefulSet struct {
+	TypeMeta `yaml:",inline"`
+	Metadata ObjectMeta      `yaml:"metadata"`
+	Spec     StatefulSetSpec `yaml:"spec"`
+}
+
+// StatefulSetSpec is the spec of a StatefulSet; serviceName is the headless service that gives the pods their stable dns names
+type StatefulSetSpec struct {
+	Replicas             *int                            `yaml:"replicas,omitempty"`
+	ServiceName          string                          `yaml:"serviceName"`
+	PodManagementPolicy  string                          `yaml:"podManagementPolicy,omitempty"`
+	UpdateStrategy       StatefulSetUpdateStrategy       `yaml:"updateStrategy"`
+	RevisionHistoryLimit int                             `yaml:"revisionHistoryLimit,omitempty"`
+	Selector             LabelSelector                   `yaml:"selector"`
+	Template             PodTemplateSpec                 `yaml:"template"`
+	VolumeClaimTemplates []PersistentVolumeClaimTemplate `yaml:"volumeClaimTemplates,omitempty"`
+}
+
+// StatefulSetUpdateStrategy defines how a StatefulSet replaces its pods
+type StatefulSetUpdateStrategy struct {
+	Type          string                            `yaml:"type"`
+	RollingUpdate *RollingUpdateStatefulSetStrategy `yaml:"rollingUpdate,omitempty"`
+}
+
+// RollingUpdateStatefulSetStrategy only updates the pods with an ordinal of at least partition
+type RollingUpdateStatefulSetStrategy struct {
+	Partition *int `yaml:"partition,omitempty"`
+}
+
+// DaemonSet is an apps/v1 DaemonSet
+type DaemonSet struct {
+	TypeMeta `yaml:",inline"`
+	Metadata ObjectMeta    `yaml:"metadata"`
+	Spec     DaemonSetSpec `yaml:"spec"`
+}
+
+// DaemonSetSpec is the spec of a DaemonSet
+type DaemonSetSpec struct {
+	UpdateStrategy       DaemonSetUpdateStrategy `yaml:"updateStrategy"`
+	RevisionHistoryLimit int                     `yaml:"revisionHistoryLimit,omitempty"`
+	Selector             LabelSelector           `yaml:"selector"`
+	Template             PodTemplateSpec         `yaml:"template"`
+}
+
+// DaemonSetUpdateStrategy defines how a DaemonSet replaces its pods
+type DaemonSetUpdateStrategy struct {
+	Type          string                  `yaml:"type"`
+	RollingUpdate *RollingUpdateDaemonSet `yaml:"rollingUpdate,omitempty"`
+}
+
+// RollingUpdateDaemonSet limits the number of nodes without a running pod during a rolling update
+type RollingUpdateDaemonSet struct {
+	MaxUnavailable IntOrString `yaml:"maxUnavailable,omitempty"`
+}
+
+// PersistentVolumeClaimTemplate is a persistent volume claim created for each pod of a StatefulSet
+type PersistentVolumeClaimTemplate struct {
+	Metadata ObjectMeta                `yaml:"metadata"`
+	Spec     PersistentVolumeClaimSpec `yaml:"spec"`
+}
+
+// PersistentVolumeClaimSpec requests storage of a storage class
+type PersistentVolumeClaimSpec struct {
+	AccessModes      []string                       `yaml:"accessModes"`
+	StorageClassName string                         `yaml:"storageClassName,omitempty"`
+	Resources        PersistentVolumeClaimResources `yaml:"resources"`
+}
+
+// PersistentVolumeClaimResources has the requested storage size
+type PersistentVolumeClaimResources struct {
+	Requests map[string]string `yaml:"requests"`
+}
+
+// Job is a batch/v1 Job
+type Job struct {
+	TypeMeta `yaml:",inline"`
+	Metadata ObjectMeta `yaml:"metadata"`
+	Spec     JobSpec    `yaml:"spec"`
+}
+
+// JobSpec is the spec of a Job
+type JobSpec struct {
+	Completions int             `yaml:"completions,omitempty"`
+	Parallelism int             `yaml:"parallelism,omitempty"`
+	Template    PodTemplateSpec `yaml:"template"`
+}
+
+// CronJob is a batch/v1 or batch/v1beta1 CronJob
+type CronJob struct {
+	TypeMeta `yaml:",inline"`
+	Metadata ObjectMeta  `yaml:"metadata"`
+	Spec     CronJobSpec `yaml:"spec"`
+}
+
+// CronJobSpec is the spec of a CronJob
+type CronJobSpec struct {
+	Schedule                   string          `yaml:"schedule"`
+	ConcurrencyPolicy          string          `yaml:"concurrencyPolicy,omitempty"`
+	FailedJobsHistoryLimit     int             `yaml:"failedJobsHistoryLimit"`
+	SuccessfulJobsHistoryLimit int             `yaml:"successfulJobsHistoryLimit"`
+	Suspend                    bool            `yaml:"suspend"`
+	JobTemplate                JobTemplateSpec `yaml:"jobTemplate"`
+}
+
+// JobTemplateSpec is the template for the jobs created by a CronJob
+type JobTemplateSpec struct {
+	Spec JobSpec `yaml:"spec"`
+}
+
+// LabelSelector selects objects by their labels
+type LabelSelector struct {
+	MatchLabels      map[string]string          `yaml:"matchLabels,omitempty"`
+	MatchExpressions []LabelSelectorRequirement `yaml:"matchExpressions,omitempty"`
+}
+
+// LabelSelectorRequirement selects objects by comparing a label to a set of values
+type LabelSelectorRequirement struct {
+	Key      string   `yaml:"key"`
+	Operator string   `yaml:"operator"`
+	Values   []string `yaml:"values,omitempty"`
+}
+
+// PodTemplateSpec is the template for the pods created by a controller
+type PodTemplateSpec struct {
+	Metadata ObjectMeta `yaml:"metadata"`
+	Spec     PodSpec    `yaml:"spec"`
+}
+
+// PodSpec is the spec of a pod
+type PodSpec struct {
+	RestartPolicy                 string                     `yaml:"restartPolicy,omitempty"`
+	ServiceAccount                string                     `yaml:"serviceAccount,omitempty"`
+	HostNetwork                   bool                       `yaml:"hostNetwork,omitempty"`
+	DNSPolicy                     string                     `yaml:"dnsPolicy,omitempty"`
+	PriorityClassName             string                     `yaml:"priorityClassName,omitempty"`
+	NodeSelector                  map[string]string          `yaml:"nodeSelector,omitempty"`
+	Affinity                      *Affinity                  `yaml:"affinity,omitempty"`
+	Tolerations                   []Toleration               `yaml:"tolerations,omitempty"`
+	TopologySpreadConstraints     []TopologySpreadConstraint `yaml:"topologySpreadConstraints,omitempty"`
+	SecurityContext               *PodSecurityContext        `yaml:"securityContext,omitempty"`
+	InitContainers                []Container                `yaml:"initContainers,omitempty"`
+	Containers                    []Container                `yaml:"containers"`
+	TerminationGracePeriodSeconds int                        `yaml:"terminationGracePeriodSeconds,omitempty"`
+	Volumes                       []Volume                   `yaml:"volumes,omitempty"`
+}
+
+// PodSecurityContext has the security settings shared by all containers in a pod
+type PodSecurityContext struct {
+	RunAsNonRoot   *bool           `yaml:"runAsNonRoot,omitempty"`
+	RunAsUser      *int            `yaml:"runAsUser,omitempty"`
+	RunAsGroup     *int            `yaml:"runAsGroup,omitempty"`
+	FSGroup        *int            `yaml:"fsGroup,omitempty"`
+	SeccompProfile *SeccompProfile `yaml:"seccompProfile,omitempty"`
+}
+
+// SeccompProfile sets the seccomp profile applied to the containers
+type SeccompProfile struct {
+	Type string `yaml:"type"`
+}
+
+// SecurityContext has the security settings of a single container, overriding the ones of the pod
+type SecurityContext struct {
+	RunAsNonRoot             *bool         `yaml:"runAsNonRoot,omitempty"`
+	RunAsUser                *int          `yaml:"runAsUser,omitempty"`
+	RunAsGroup               *int          `yaml:"runAsGroup,omitempty"`
+	ReadOnlyRootFilesystem   *bool         `yaml:"readOnlyRootFilesystem,omitempty"`
+	AllowPrivilegeEscalation *bool         `yaml:"allowPrivilegeEscalation,omitempty"`
+	Capabilities             *Capabilities `yaml:"capabilities,omitempty"`
+}
+
+// Capabilities adds or drops linux capabilities of a container
+type Capabilities struct {
+	Add  []string `yaml:"add,omitempty"`
+	Drop []string `yaml:"drop,omitempty"`
+}
+
+// Affinity has the scheduling constraints of a pod
+type Affinity struct {
+	PodAntiAffinity *PodAntiAffinity `yaml:"podAntiAffinity,omitempty"`
+	NodeAffinity    *NodeAffinity    `yaml:"nodeAffinity,omitempty"`
+}
+
+// PodAntiAffinity keeps pods away from other pods
+type PodAntiAffinity struct {
+	RequiredDuringSchedulingIgnoredDuringExecution  []PodAffinityTerm         `yaml:"requiredDuringSchedulingIgnoredDuringExecution,omitempty"`
+	PreferredDuringSchedulingIgnoredDuringExecution []WeightedPodAffinityTerm `yaml:"preferredDuringSchedulingIgnoredDuringExecution,omitempty"`
+}
+
+// WeightedPodAffinityTerm is a PodAffinityTerm with a weight relative to other terms
+type WeightedPodAffinityTerm struct {
+	Weight          int             `yaml:"weight"`
+	PodAffinityTerm PodAffinityTerm `yaml:"podAffinityTerm"`
+}
+
+// PodAffinityTerm selects the pods within a topology domain
+type PodAffinityTerm struct {
+	LabelSelector LabelSelector `yaml:"labelSelector"`
+	TopologyKey   string        `yaml:"topologyKey"`
+}
+
+// Toleration allows a pod to be scheduled on nodes with a matching taint
+type Toleration struct {
+	Key               string `yaml:"key,omitempty"`
+	Operator          string `yaml:"operator,omitempty"`
+	Value             string `yaml:"value,omitempty"`
+	Effect            string `yaml:"effect,omitempty"`
+	TolerationSeconds *int   `yaml:"tolerationSeconds,omitempty"`
+}
+
+// TopologySpreadConstraint spreads matching pods evenly across topology domains
+type TopologySpreadConstraint struct {
+	MaxSkew           int           `yaml:"maxSkew"`
+	TopologyKey       string        `yaml:"topologyKey"`
+	WhenUnsatisfiable string        `yaml:"whenUnsatisfiable"`
+	LabelSelector     LabelSelector `yaml:"labelSelector"`
+}
+
+// NodeAffinity attracts pods to nodes
+type NodeAffinity struct {
+	PreferredDuringSchedulingIgnoredDuringExecution []PreferredSchedulingTerm `yaml:"preferredDuringSchedulingIgnoredDuringExecution,omitempty"`
+}
+
+// PreferredSchedulingTerm is a NodeSelectorTerm with a weight relative to other terms
+type PreferredSchedulingTerm struct {
+	Weight     int              `yaml:"weight"`
+	Preference NodeSelectorTerm `yaml:"preference"`
+}
+
+// NodeSelectorTerm selects nodes by their labels
+type NodeSelectorTerm struct {
+	MatchExpressions []NodeSelectorRequirement `yaml:"matchExpressions,omitempty"`
+}
+
+// NodeSelectorRequirement selects nodes by comparing a label to a set of values
+type NodeSelectorRequirement struct {
+	Key      string   `yaml:"key"`
+	Operator string   `yaml:"operator"`
+	Values   []string `yaml:"values,omitempty"`
+}
+
+// Container is a container in a pod
+type Container struct {
+	Name            string               `yaml:"name"`
+	Image           string               `yaml:"image"`
+	ImagePullPolicy string               `yaml:"imagePullPolicy,omitempty"`
+	Command         []string             `yaml:"command,omitempty"`
+	Args            []string             `yaml:"args,omitempty"`
+	Env             []EnvVar             `yaml:"env,omitempty"`
+	Resources       ResourceRequirements `yaml:"resources,omitempty"`
+	Ports           []ContainerPort      `yaml:"ports,omitempty"`
+	LivenessProbe   *Probe               `yaml:"livenessProbe,omitempty"`
+	ReadinessProbe  *Probe               `yaml:"readinessProbe,omitempty"`
+	StartupProbe    *Probe               `yaml:"startupProbe,omitempty"`
+	VolumeMounts    []VolumeMount        `yaml:"volumeMounts,omitempty"`
+	Lifecycle       *Lifecycle           `yaml:"lifecycle,omitempty"`
+	SecurityContext *SecurityContext     `yaml:"securityContext,omitempty"`
+}
+
+// EnvVar is an environment variable with either a literal value or a value from a source
+type EnvVar struct {
+	Name      string                 `yaml:"name"`
+	Value     string                 `yaml:"value,omitempty"`
+	ValueFrom map[string]interface{} `yaml:"valueFrom,omitempty"`
+}
+
+// ResourceRequirements has the requested and maximum cpu and memory of a container
+type ResourceRequirements struct {
+	Requests ResourceList `yaml:"requests,omitempty"`
+	Limits   ResourceList `yaml:"limits,omitempty"`
+}
+
+// ResourceList has cpu and memory quantities
+type ResourceList struct {
+	CPU    string `yaml:"cpu,omitempty"`
+	Memory string `yaml:"memory,omitempty"`
+}
+
+// ContainerPort is a port exposed by a container
+type ContainerPort struct {
+	Name          string `yaml:"name"`
+	ContainerPort int    `yaml:"containerPort"`
+	Protocol      string `yaml:"protocol,omitempty"`
+}
+
+// Probe checks the health of a container with one of its actions
+type Probe struct {
+	HTTPGet             *HTTPGetAction   `yaml:"httpGet,omitempty"`
+	TCPSocket           *TCPSocketAction `yaml:"tcpSocket,omitempty"`
+	Exec                *ExecAction      `yaml:"exec,omitempty"`
+	GRPC                *GRPCAction      `yaml:"grpc,omitempty"`
+	InitialDelaySeconds int              `yaml:"initialDelaySeconds,omitempty"`
+	TimeoutSeconds      int              `yaml:"timeoutSeconds,omitempty"`
+	PeriodSeconds       int              `yaml:"periodSeconds,omitempty"`
+	SuccessThreshold    int              `yaml:"successThreshold,omitempty"`
+	FailureThreshold    int              `yaml:"failureThreshold,omitempty"`
+}
+
+// HTTPGetAction performs an http get request against a container
+type HTTPGetAction struct {
+	Path   string      `yaml:"path,omitempty"`
+	Port   IntOrString `yaml:"port"`
+	Scheme string      `yaml:"scheme,omitempty"`
+}
+
+// TCPSocketAction opens a tcp connection to a container
+type TCPSocketAction struct {
+	Port IntOrString `yaml:"port"`
+}
+
+// GRPCAction calls the grpc health checking protocol of a container
+type GRPCAction struct {
+	Port    int    `yaml:"port"`
+	Service string `yaml:"service,omitempty"`
+}
+
+// Lifecycle has actions to run on container lifecycle events
+type Lifecycle struct {
+	PreStop *Handler `yaml:"preStop,omitempty"`
+}
+
+// Handler is an action to run on a container lifecycle event
+type Handler struct {
+	Exec *ExecAction `yaml:"exec,omitempty"`
+}
+
+// ExecAction runs a command inside a container
+type ExecAction struct {
+	Command []string `yaml:"command"`
+}
+
+// VolumeMount mounts a volume inside a container
+type VolumeMount struct {
+	Name      string `yaml:"name"`
+	MountPath string `yaml:"mountPath"`
+	ReadOnly  bool   `yaml:"readOnly,omitempty"`
+}
+
+// Volume is a volume of a pod; Source holds the volume source for volumes that are defined in the params as is
+type Volume struct {
+	Name      string                 `yaml:"name"`
+	Secret    *SecretVolumeSource    `yaml:"secret,omitempty"`
+	ConfigMap *ConfigMapVolumeSource `yaml:"configMap,omitempty"`
+	HostPath  *HostPathVolumeSource  `yaml:"hostPath,omitempty"`
+	Source    map[string]interface{} `yaml:"-"`
+}
+
+// MarshalYAML serializes the properties of Source next to the name, since it can't be inlined alongside the typed volume sources
+func (v Volume) MarshalYAML() (interface{}, error) {
+
+	type volume Volume
+	if len(v.Source) == 0 {
+		return volume(v), nil
+	}
+
+	keys := make([]string, 0, len(v.Source))
+	for k := range v.Source {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	mapSlice := yaml.MapSlice{{Key: "name", Value: v.Name}}
+	for _, k := range keys {
+		mapSlice = append(mapSlice, yaml.MapItem{Key: k, Value: v.Source[k]})
+	}
+
+	return mapSlice, nil
+}
+
+// SecretVolumeSource mounts a secret as volume, either all keys or only the listed items
+type SecretVolumeSource struct {
+	SecretName string      `yaml:"secretName"`
+	Items      []KeyToPath `yaml:"items,omitempty"`
+}
+
+// KeyToPath mounts a key of a secret or configmap as file with the given path
+type KeyToPath struct {
+	Key  string `yaml:"key"`
+	Path string `yaml:"path"`
+}
+
+// ConfigMapVolumeSource mounts a configmap as volume
+type ConfigMapVolumeSource struct {
+	Name string `yaml:"name"`
+}
+
+// HostPathVolumeSource mounts a directory of the node as volume
+type HostPathVolumeSource struct {
+	Path string `yaml:"path"`
+	Type string `yaml:"type,omitempty"`
+}
+
+// PodDisruptionBudget is a policy/v1 or policy/v1beta1 PodDisruptionBudget
+type PodDisruptionBudget struct {
+	TypeMeta `yaml:",inline"`
+	Metadata ObjectMeta              `yaml:"metadata"`
+	Spec     PodDisruptionBudgetSpec `yaml:"spec"`
+}
+
+// PodDisruptionBudgetSpec is the spec of a PodDisruptionBudget
+type PodDisruptionBudgetSpec struct {
+	Selector       LabelSelector `yaml:"selector"`
+	MinAvailable   IntOrString   `yaml:"minAvailable,omitempty"`
+	MaxUnavailable IntOrString   `yaml:"maxUnavailable,omitempty"`
+}
+
+// HorizontalPodAutoscaler is an autoscaling/v2 or autoscaling/v2beta2 HorizontalPodAutoscaler, which share the same spec
+type HorizontalPodAutoscaler struct {
+	TypeMeta `yaml:",inline"`
+	Metadata ObjectMeta                  `yaml:"metadata"`
+	Spec     HorizontalPodAutoscalerSpec `yaml:"spec"`
+}
+
+// HorizontalPodAutoscalerSpec is the spec of a HorizontalPodAutoscaler
+type HorizontalPodAutoscalerSpec struct {
+	ScaleTargetRef CrossVersionObjectReference      `yaml:"scaleTargetRef"`
+	MinReplicas    int                              `yaml:"minReplicas"`
+	MaxReplicas    int                              `yaml:"maxReplicas"`
+	Metrics        []MetricSpec                     `yaml:"metrics,omitempty"`
+	Behavior       *HorizontalPodAutoscalerBehavior `yaml:"behavior,omitempty"`
+}
+
+// MetricSpec is a metric to scale on; the source matching the type is set
+type MetricSpec struct {
+	Type     string                `yaml:"type"`
+	Resource *ResourceMetricSource `yaml:"resource,omitempty"`
+	Pods     *PodsMetricSource     `yaml:"pods,omitempty"`
+	Object   *ObjectMetricSource   `yaml:"object,omitempty"`
+	External *ExternalMetricSource `yaml:"external,omitempty"`
+}
+
+// ResourceMetricSource scales on the cpu or memory of the pods
+type ResourceMetricSource struct {
+	Name   string       `yaml:"name"`
+	Target MetricTarget `yaml:"target"`
+}
+
+// PodsMetricSource scales on a custom metric averaged over the pods
+type PodsMetricSource struct {
+	Metric MetricIdentifier `yaml:"metric"`
+	Target MetricTarget     `yaml:"target"`
+}
+
+// ObjectMetricSource scales on a custom metric describing another object
+type ObjectMetricSource struct {
+	DescribedObject CrossVersionObjectReference `yaml:"describedObject"`
+	Metric          MetricIdentifier            `yaml:"metric"`
+	Target          MetricTarget                `yaml:"target"`
+}
+
+// ExternalMetricSource scales on a metric from outside the cluster
+type ExternalMetricSource struct {
+	Metric MetricIdentifier `yaml:"metric"`
+	Target MetricTarget     `yaml:"target"`
+}
+
+// MetricIdentifier selects a metric by name and labels
+type MetricIdentifier struct {
+	Name     string         `yaml:"name"`
+	Selector *LabelSelector `yaml:"selector,omitempty"`
+}
+
+// MetricTarget is the target value, average value or average utilization of a metric
+type MetricTarget struct {
+	Type               string   `yaml:"type"`
+	Value              Quantity `yaml:"value,omitempty"`
+	AverageValue       Quantity `yaml:"averageValue,omitempty"`
+	AverageUtilization int      `yaml:"averageUtilization,omitempty"`
+}
+
+// HorizontalPodAutoscalerBehavior limits how fast the autoscaler scales up and down
+type HorizontalPodAutoscalerBehavior struct {
+	ScaleUp   *HPAScalingRules `yaml:"scaleUp,omitempty"`
+	ScaleDown *HPAScalingRules `yaml:"scaleDown,omitempty"`
+}
+
+// HPAScalingRules are the policies for scaling in one direction
+type HPAScalingRules struct {
+	StabilizationWindowSeconds *int               `yaml:"stabilizationWindowSeconds,omitempty"`
+	SelectPolicy               string             `yaml:"selectPolicy,omitempty"`
+	Policies                   []HPAScalingPolicy `yaml:"policies,omitempty"`
+}
+
+// HPAScalingPolicy allows a change of a number of pods or a percentage of the replicas per period
+type HPAScalingPolicy struct {
+	Type          string `yaml:"type"`
+	Value         int    `yaml:"value"`
+	PeriodSeconds int    `yaml:"periodSeconds"`
+}
+
+// CrossVersionObjectReference refers to an object by api version, kind and name
+type CrossVersionObjectReference struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Name       string `yaml:"name"`
+}
+
+// Ingress is a networking.k8s.io/v1 or extensions/v1beta1 Ingress
+type Ingress struct {
+	TypeMeta `yaml:",inline"`
+	Metadata ObjectMeta  `yaml:"metadata"`
+	Spec     IngressSpec `yaml:"spec"`
+}
+
+// IngressSpec is the spec of an Ingress
+type IngressSpec struct {
+	IngressClassName string        `yaml:"ingressClassName,omitempty"`
+	TLS              []IngressTLS  `yaml:"tls,omitempty"`
+	Rules            []IngressRule `yaml:"rules,omitempty"`
+}
+
+// IngressTLS has the certificate for a set of hosts
+type IngressTLS struct {
+	Hosts      []string `yaml:"hosts"`
+	SecretName string   `yaml:"secretName"`
+}
+
+// IngressRule routes the traffic for a host
+type IngressRule struct {
+	Host string                `yaml:"host"`
+	HTTP *HTTPIngressRuleValue `yaml:"http,omitempty"`
+}
+
+// HTTPIngressRuleValue has the paths of an IngressRule
+type HTTPIngressRuleValue struct {
+	Paths []HTTPIngressPath `yaml:"paths"`
+}
+
+// HTTPIngressPath routes the traffic for a path to a backend
+type HTTPIngressPath struct {
+	Path     string         `yaml:"path,omitempty"`
+	PathType string         `yaml:"pathType,omitempty"`
+	Backend  IngressBackend `yaml:"backend"`
+}
+
+// IngressBackend is the service and port traffic is routed to; extensions/v1beta1 uses service name and port, networking.k8s.io/v1
+// uses service
+type IngressBackend struct {
+	ServiceName string                 `yaml:"serviceName,omitempty"`
+	ServicePort IntOrString            `yaml:"servicePort,omitempty"`
+	Service     *IngressServiceBackend `yaml:"service,omitempty"`
+}
+
+// IngressServiceBackend is the service and port traffic is routed to in a networking.k8s.io/v1 Ingress
+type IngressServiceBackend struct {
+	Name string             `yaml:"name"`
+	Port ServiceBackendPort `yaml:"port"`
+}
+
+// ServiceBackendPort refers to a service port by either name or number
+type ServiceBackendPort struct {
+	Name   string `yaml:"name,omitempty"`
+	Number int    `yaml:"number,omitempty"`
+}
+
+// BackendConfig is a cloud.google.com/v1beta1 BackendConfig
+type BackendConfig struct {
+	TypeMeta `yaml:",inline"`
+	Metadata ObjectMeta        `yaml:"metadata"`
+	Spec     BackendConfigSpec `yaml:"spec"`
+}
+
+// BackendConfigSpec is the spec of a BackendConfig
+type BackendConfigSpec struct {
+	IAP *IAPConfig `yaml:"iap,omitempty"`
+}
+
+// IAPConfig enables identity-aware proxy for a backend
+type IAPConfig struct {
+	Enabled                bool                   `yaml:"enabled"`
+	OAuthClientCredentials OAuthClientCredentials `yaml:"oauthclientCredentials"`
+}
+
+// OAuthClientCredentials refers to the secret with the oauth client credentials used by identity-aware proxy
+type OAuthClientCredentials struct {
+	SecretName string `yaml:"secretName"`
+}
+
+// NetworkPolicy is a networking.k8s.io/v1 NetworkPolicy
+type NetworkPolicy struct {
+	TypeMeta `yaml:",inline"`
+	Metadata ObjectMeta        `yaml:"metadata"`
+	Spec     NetworkPolicySpec `yaml:"spec"`
+}
+
+// NetworkPolicySpec selects the pods the policy applies to and the traffic allowed to and from them
+type NetworkPolicySpec struct {
+	PodSelector LabelSelector              `yaml:"podSelector"`
+	PolicyTypes []string                   `yaml:"policyTypes"`
+	Ingress     []NetworkPolicyIngressRule `yaml:"ingress,omitempty"`
+	Egress      []NetworkPolicyEgressRule  `yaml:"egress,omitempty"`
+}
+
+// NetworkPolicyIngressRule allows traffic from any of the peers to any of the ports; leaving either empty allows all
+type NetworkPolicyIngressRule struct {
+	From  []NetworkPolicyPeer `yaml:"from,omitempty"`
+	Ports []NetworkPolicyPort `yaml:"ports,omitempty"`
+}
+
+// NetworkPolicyEgressRule allows traffic to any of the peers on any of the ports; leaving either empty allows all
+type NetworkPolicyEgressRule struct {
+	To    []NetworkPolicyPeer `yaml:"to,omitempty"`
+	Ports []NetworkPolicyPort `yaml:"ports,omitempty"`
+}
+
+// NetworkPolicyPeer selects pods, namespaces or an ip range; pod and namespace selector combined select pods in the matching namespaces
+type NetworkPolicyPeer struct {
+	PodSelector       *LabelSelector `yaml:"podSelector,omitempty"`
+	NamespaceSelector *LabelSelector `yaml:"namespaceSelector,omitempty"`
+	IPBlock           *IPBlock       `yaml:"ipBlock,omitempty"`
+}
+
+// IPBlock selects an ip range
+type IPBlock struct {
+	CIDR string `yaml:"cidr"`
+}
+
+// NetworkPolicyPort is a port and protocol in a network policy rule
+type NetworkPolicyPort struct {
+	Protocol string      `yaml:"protocol,omitempty"`
+	Port     IntOrString `yaml:"port,omitempty"`
+}
+
+// Certificate is a cert-manager.io/v1 Certificate
+type Certificate struct {
+	TypeMeta `yaml:",inline"`
+	Metadata ObjectMeta      `yaml:"metadata"`
+	Spec     CertificateSpec `yaml:"spec"`
+}
+
+// CertificateSpec requests a certificate for the dns names from an issuer and stores it in a tls secret
+type CertificateSpec struct {
+	SecretName string               `yaml:"secretName"`
+	DNSNames   []string             `yaml:"dnsNames"`
+	IssuerRef  CertificateIssuerRef `yaml:"issuerRef"`
+}
+
+// CertificateIssuerRef refers to a cert-manager Issuer in the same namespace or a ClusterIssuer
+type CertificateIssuerRef struct {
+	Name  string `yaml:"name"`
+	Kind  string `yaml:"kind"`
+	Group string `yaml:"group"`
+}
+
+// ManagedCertificate is a networking.gke.io/v1 ManagedCertificate
+type ManagedCertificate struct {
+	TypeMeta `yaml:",inline"`
+	Metadata ObjectMeta             `yaml:"metadata"`
+	Spec     ManagedCertificateSpec `yaml:"spec"`
+}
+
+// ManagedCertificateSpec lists the domains google issues the certificate for
+type ManagedCertificateSpec struct {
+	Domains []string `yaml:"domains"`
+}
+
+// Gateway is a networking.istio.io/v1beta1 Gateway
+type Gateway struct {
+	TypeMeta `yaml:",inline"`
+	Metadata ObjectMeta  `yaml:"metadata"`
+	Spec     GatewaySpec `yaml:"spec"`
+}
+
+// GatewaySpec configures the istio ingress gateway pods matching the selector to accept traffic for the hosts
+type GatewaySpec struct {
+	Selector map[string]string `yaml:"selector"`
+	Servers  []GatewayServer   `yaml:"servers"`
+}
+
+// GatewayServer accepts traffic for the hosts on a port, terminating tls with the certificate from credentialName
+type GatewayServer struct {
+	Port  GatewayPort       `yaml:"port"`
+	Hosts []string          `yaml:"hosts"`
+	TLS   *GatewayServerTLS `yaml:"tls,omitempty"`
+}
+
+// GatewayPort is a port the gateway listens on
+type GatewayPort struct {
+	Number   int    `yaml:"number"`
+	Name     string `yaml:"name"`
+	Protocol string `yaml:"protocol"`
+}
+
+// GatewayServerTLS either redirects http to https or terminates tls
+type GatewayServerTLS struct {
+	HTTPSRedirect  bool   `yaml:"httpsRedirect,omitempty"`
+	Mode           string `yaml:"mode,omitempty"`
+	CredentialName string `yaml:"credentialName,omitempty"`
+}
+
+// VirtualService is a networking.istio.io/v1beta1 VirtualService
+type VirtualService struct {
+	TypeMeta `yaml:",inline"`
+	Metadata ObjectMeta         `yaml:"metadata"`
+	Spec     VirtualServiceSpec `yaml:"spec"`
+}
+
+// VirtualServiceSpec routes the traffic for the hosts coming in through the gateways
+type VirtualServiceSpec struct {
+	Hosts    []string    `yaml:"hosts"`
+	Gateways []string    `yaml:"gateways"`
+	HTTP     []HTTPRoute `yaml:"http"`
+}
+
+// HTTPRoute sends requests matching any of the matches to the weighted destinations
+type HTTPRoute struct {
+	Match   []HTTPMatchRequest     `yaml:"match,omitempty"`
+	Route   []HTTPRouteDestination `yaml:"route"`
+	Timeout string                 `yaml:"timeout,omitempty"`
+	Retries *HTTPRetry             `yaml:"retries,omitempty"`
+}
+
+// HTTPMatchRequest matches requests by uri and optionally host
+type HTTPMatchRequest struct {
+	URI       *StringMatch `yaml:"uri,omitempty"`
+	Authority *StringMatch `yaml:"authority,omitempty"`
+}
+
+// StringMatch matches a string exactly or by prefix
+type StringMatch struct {
+	Exact  string `yaml:"exact,omitempty"`
+	Prefix string `yaml:"prefix,omitempty"`
+}
+
+// HTTPRouteDestination is a destination with the percentage of the traffic it receives
+type HTTPRouteDestination struct {
+	Destination Destination `yaml:"destination"`
+	Weight      *int        `yaml:"weight,omitempty"`
+}
+
+// Destination is a service port, optionally limited to a subset of its pods
+type Destination struct {
+	Host   string       `yaml:"host"`
+	Subset string       `yaml:"subset,omitempty"`
+	Port   PortSelector `yaml:"port"`
+}
+
+// PortSelector selects a service port by number
+type PortSelector struct {
+	Number int `yaml:"number"`
+}
+
+// HTTPRetry retries failed requests
+type HTTPRetry struct {
+	Attempts int    `yaml:"attempts"`
+	RetryOn  string `yaml:"retryOn,omitempty"`
+}
+
+// DestinationRule is a networking.istio.io/v1beta1 DestinationRule
+type DestinationRule struct {
+	TypeMeta `yaml:",inline"`
+	Metadata ObjectMeta          `yaml:"metadata"`
+	Spec     DestinationRuleSpec `yaml:"spec"`
+}
+
+// DestinationRuleSpec defines the subsets of the pods of a service that virtual services can route to
+type DestinationRuleSpec struct {
+	Host    string   `yaml:"host"`
+	Subsets []Subset `yaml:"subsets,omitempty"`
+}
+
+// Subset selects the pods of a service by labels
+type Subset struct {
+	Name   string            `yaml:"name"`
+	Labels map[string]string `yaml:"labels"`
+}
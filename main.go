@@ -10,10 +10,11 @@ import (
 	"net/http"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
-	"text/template"
 	"time"
 
 	"github.com/alecthomas/kingpin"
@@ -30,9 +31,14 @@ var (
 )
 
 var (
+	// commands
+	deployCommand  = kingpin.Command("deploy", "Deploy the application to the cluster; this is the default command.").Default()
+	schemaCommand  = kingpin.Command("schema", "Print the JSON Schema describing the custom properties of this extension.")
+	explainCommand = kingpin.Command("explain", "Print the effective parameters as yaml with the source of each value, without touching the cluster.")
+
 	// flags
-	paramsJSON      = kingpin.Flag("params", "Extension parameters, created from custom properties.").Envar("ESTAFETTE_EXTENSION_CUSTOM_PROPERTIES").Required().String()
-	credentialsJSON = kingpin.Flag("credentials", "GKE credentials configured at service level, passed in to this trusted extension.").Envar("ESTAFETTE_CREDENTIALS_KUBERNETES_ENGINE").Required().String()
+	paramsJSON      = kingpin.Flag("params", "Extension parameters, created from custom properties; required for the deploy command.").Envar("ESTAFETTE_EXTENSION_CUSTOM_PROPERTIES").String()
+	credentialsJSON = kingpin.Flag("credentials", "GKE credentials configured at service level, passed in to this trusted extension; required for the deploy command.").Envar("ESTAFETTE_CREDENTIALS_KUBERNETES_ENGINE").String()
 
 	// optional flags
 	gitName       = kingpin.Flag("git-name", "Repository name, used as application name if not passed explicitly and app label not being set.").Envar("ESTAFETTE_GIT_NAME").String()
@@ -43,22 +49,38 @@ var (
 	releaseID     = kingpin.Flag("release-id", "ID of the release, to use as a label.").Envar("ESTAFETTE_RELEASE_ID").String()
 	triggeredBy   = kingpin.Flag("triggered-by", "The user id of the person triggering the release.").Envar("ESTAFETTE_TRIGGER_MANUAL_USER_ID").String()
 
+	validationReportFile = kingpin.Flag("validation-report", "Path of a json file to write validation errors and warnings to, so they can be annotated in the manifest.").Envar("ESTAFETTE_EXTENSION_VALIDATION_REPORT").String()
+
 	assistTroubleshootingOnError = false
 	paramsForTroubleshooting     = Params{}
+	explainOnly                  = false
 )
 
 func main() {
 
 	// parse command line parameters
-	kingpin.Parse()
+	command := kingpin.Parse()
 
 	// log to stdout and hide timestamp
 	log.SetOutput(os.Stdout)
 	log.SetFlags(log.Flags() &^ (log.Ldate | log.Ltime))
 
+	if command == schemaCommand.FullCommand() {
+		printParamsSchema()
+		return
+	}
+	explainOnly = command == explainCommand.FullCommand()
+
 	// log startup message
 	logInfo("Starting %v version %v...", app, version)
 
+	if *paramsJSON == "" {
+		log.Fatal("Parameters are required; pass them via --params or ESTAFETTE_EXTENSION_CUSTOM_PROPERTIES")
+	}
+	if *credentialsJSON == "" {
+		log.Fatal("Credentials are required; pass them via --credentials or ESTAFETTE_CREDENTIALS_KUBERNETES_ENGINE")
+	}
+
 	// put all estafette labels in map
 	logInfo("Getting all estafette labels from envvars...")
 	estafetteLabels := map[string]string{}
@@ -88,10 +110,8 @@ func main() {
 	credentialsParam.SetDefaults(*releaseName)
 
 	logInfo("Validating required credential parameter...")
-	valid, errors := credentialsParam.ValidateRequiredProperties()
-	if !valid {
-		log.Fatal("Not all valid fields are set: ", errors)
-	}
+	_, credentialsParamErrors := credentialsParam.ValidateRequiredProperties()
+	reportValidationErrors(credentialsParamErrors, false)
 
 	logInfo("Unmarshalling injected credentials...")
 	var credentials []GKECredentials
@@ -106,34 +126,112 @@ func main() {
 		log.Fatalf("Credential with name %v does not exist.", credentialsParam.Credentials)
 	}
 
-	var params Params
+	var defaultsJSON json.RawMessage
 	if credential.AdditionalProperties.Defaults != nil {
 		logInfo("Using defaults from credential %v...", credentialsParam.Credentials)
-		// todo log just the specified defaults, not the entire parms object
-		// defaultsAsYAML, err := yaml.Marshal(credential.AdditionalProperties.Defaults)
-		// if err == nil {
-		// 	log.Printf(string(defaultsAsYAML))
-		// }
-		params = *credential.AdditionalProperties.Defaults
+		defaultsJSON, err = GetCredentialDefaultsJSON([]byte(*credentialsJSON), credentialsParam.Credentials)
+		if err != nil {
+			log.Fatal("Failed unmarshalling credential defaults: ", err)
+		}
+	}
+
+	logInfo("Validating parameters / custom properties against the schema...")
+	schemaViolations := []ValidationError{}
+	if defaultsJSON != nil {
+		defaultsViolations, err := ValidateParamsJSON(defaultsJSON, "defaults")
+		if err != nil {
+			log.Fatal("Failed unmarshalling credential defaults: ", err)
+		}
+		schemaViolations = append(schemaViolations, defaultsViolations...)
+	}
+	paramsViolations, err := ValidateParamsJSON([]byte(*paramsJSON), "")
+	if err != nil {
+		log.Fatal("Failed unmarshalling parameters: ", err)
+	}
+	schemaViolations = append(schemaViolations, paramsViolations...)
+	reportValidationErrors(schemaViolations, false)
+
+	unknownProperties := []UnknownPropertyError{}
+	if defaultsJSON != nil {
+		unknownDefaults, err := decodeStrict(defaultsJSON, &Params{}, "defaults")
+		if err != nil {
+			log.Fatal("Failed unmarshalling credential defaults: ", err)
+		}
+		unknownProperties = append(unknownProperties, unknownDefaults...)
+	}
+	// the credentials property is handled by CredentialsParam
+	unknownParams, err := decodeStrict([]byte(*paramsJSON), &Params{}, "", "credentials")
+	if err != nil {
+		log.Fatal("Failed unmarshalling parameters: ", err)
+	}
+	unknownProperties = append(unknownProperties, unknownParams...)
+
+	mergedParamsJSON := json.RawMessage(*paramsJSON)
+	var appliedDefaults []string
+	if defaultsJSON != nil {
+		logInfo("Merging parameters / custom properties over the defaults from credential %v...", credentialsParam.Credentials)
+		mergedParamsJSON, appliedDefaults, err = mergeParamsJSON(defaultsJSON, []byte(*paramsJSON))
+		if err != nil {
+			log.Fatal("Failed merging parameters with credential defaults: ", err)
+		}
+		if len(appliedDefaults) > 0 {
+			logInfo("Applied the following defaults from credential %v:\n%v", credentialsParam.Credentials, strings.Join(appliedDefaults, "\n"))
+		} else {
+			logInfo("None of the defaults from credential %v were applied, they're all overridden", credentialsParam.Credentials)
+		}
 	}
 
 	logInfo("Unmarshalling parameters / custom properties...")
-	err = json.Unmarshal([]byte(*paramsJSON), &params)
+	var params Params
+	err = json.Unmarshal(mergedParamsJSON, &params)
 	if err != nil {
 		log.Fatal("Failed unmarshalling parameters: ", err)
 	}
 
+	environmentApplied, err := params.ApplyEnvironment([]byte(*paramsJSON), *releaseName)
+	if err != nil {
+		log.Fatal("Failed applying environment parameters: ", err)
+	}
+	if environmentApplied {
+		logInfo("Applied parameters from environments.%v...", *releaseName)
+	}
+
+	allowlistWarnings := params.RestrictIngressAnnotationAllowlist(credential.AdditionalProperties.Defaults)
+
+	paramsSources, err := NewParamsSources(mergedParamsJSON, appliedDefaults, credentialsParam.Credentials)
+	if err != nil {
+		log.Fatal("Failed determining the source of the parameters: ", err)
+	}
+	err = paramsSources.ApplyEnvironment([]byte(*paramsJSON), *releaseName)
+	if err != nil {
+		log.Fatal("Failed determining the source of the environment parameters: ", err)
+	}
+	paramsBeforeDefaults, err := flattenParams(params)
+	if err != nil {
+		log.Fatal("Failed determining the source of the parameters: ", err)
+	}
+
 	logInfo("Setting defaults for parameters that are not set in the manifest...")
 	params.SetDefaults(*gitName, *appLabel, *buildVersion, *releaseName, *releaseAction, estafetteLabels)
 
-	logInfo("Validating required parameters...")
-	valid, errors, warnings := params.ValidateRequiredProperties()
-	if !valid {
-		log.Fatal("Not all valid fields are set: ", errors)
+	err = paramsSources.ApplyConventions(paramsBeforeDefaults, params, *appLabel, *releaseAction, estafetteLabels)
+	if err != nil {
+		log.Fatal("Failed determining the source of the default parameters: ", err)
 	}
 
-	for _, warning := range warnings {
-		log.Printf("Warning: %s", warning)
+	logInfo("Validating required parameters...")
+	_, errors, warnings := params.ValidateRequiredProperties()
+	unknownPropertyErrors, unknownPropertyWarnings := params.ValidateUnknownProperties(unknownProperties)
+	validationErrors := append(append(errors, unknownPropertyErrors...), append(append(warnings, unknownPropertyWarnings...), allowlistWarnings...)...)
+	reportValidationErrors(validationErrors, true)
+
+	if explainOnly {
+		logInfo("Effective parameters with the source of each value:")
+		err = explainParams(os.Stdout, params, paramsSources)
+		if err != nil {
+			log.Fatal("Failed explaining parameters: ", err)
+		}
+		return
 	}
 
 	// replacing openresty image tag with digest
@@ -182,26 +280,29 @@ func main() {
 	}
 	runCommand("gcloud", clustersGetCredentialsArsgs)
 
+	// render the manifests with the api versions the cluster supports
+	params.ClusterVersion = getClusterVersion()
+
 	if params.Action == "deploy-babysit" {
 		logInfo("Run deployment with babysitter...")
 		params.Action = "deploy-canary"
-		templateDataDeployCanary, tmplDeployCanary := generateKubernetesYaml(params)
-		applyKubernetesYaml(params, templateDataDeployCanary, tmplDeployCanary)
+		templateDataDeployCanary, hasManifestsDeployCanary := generateKubernetesYaml(params)
+		applyKubernetesYaml(params, templateDataDeployCanary, hasManifestsDeployCanary)
 		deployed, err := checkAlerts(params)
 		if !deployed || err != nil {
 			logInfo("Canary deployment is failed, rollback it...")
 			params.Action = "rollback-canary"
-			templateDataRollbackCanary, tmplRollbackCanary := generateKubernetesYaml(params)
-			applyKubernetesYaml(params, templateDataRollbackCanary, tmplRollbackCanary)
+			templateDataRollbackCanary, hasManifestsRollbackCanary := generateKubernetesYaml(params)
+			applyKubernetesYaml(params, templateDataRollbackCanary, hasManifestsRollbackCanary)
 			sendNotifications("failed", "canary", params)
 			return
 		}
 		sendNotifications("succeeded", "canary", params)
 		logInfo("Canary deployment is successfull, rollout stable...")
 		params.Action = "deploy-stable"
-		templateDataDeployStable, tmplDeployStable := generateKubernetesYaml(params)
+		templateDataDeployStable, hasManifestsDeployStable := generateKubernetesYaml(params)
 		previousVersion := getCurrentDeploymentVersion(params, templateDataDeployStable.NameWithTrack, templateDataDeployStable.Namespace)
-		applyKubernetesYaml(params, templateDataDeployStable, tmplDeployStable)
+		applyKubernetesYaml(params, templateDataDeployStable, hasManifestsDeployStable)
 		deployed, err = checkAlerts(params)
 		// rollback stable
 		if !deployed || err != nil {
@@ -212,24 +313,56 @@ func main() {
 			logInfo("Stable deployment is failed, rollback to version " + previousVersion)
 			params.Action = "deploy-stable"
 			params.BuildVersion = previousVersion
-			templateDataDeployStable, tmplDeployStable := generateKubernetesYaml(params)
-			applyKubernetesYaml(params, templateDataDeployStable, tmplDeployStable)
+			templateDataDeployStable, hasManifestsDeployStable := generateKubernetesYaml(params)
+			applyKubernetesYaml(params, templateDataDeployStable, hasManifestsDeployStable)
 			sendNotifications("failed", "stable", params)
 			return
 		}
 		sendNotifications("succeeded", "stable", params)
 	} else {
-		templateData, tmpl := generateKubernetesYaml(params)
-		applyKubernetesYaml(params, templateData, tmpl)
+		templateData, hasManifests := generateKubernetesYaml(params)
+		applyKubernetesYaml(params, templateData, hasManifests)
 	}
 }
 
-func generateKubernetesYaml(params Params) (TemplateData, *template.Template) {
-	// combine templates
-	tmpl, err := buildTemplates(params)
+func printParamsSchema() {
+	schemaJSON, err := json.MarshalIndent(generateParamsSchema(), "", "  ")
 	if err != nil {
-		log.Fatal("Failed building templates: ", err)
+		log.Fatal("Failed marshalling json schema: ", err)
+	}
+	fmt.Println(string(schemaJSON))
+}
+
+// reportValidationErrors prints errors and warnings, stores them in the validation report if requested and exits if there are any errors;
+// the report is written when validation fails or after the last validation step, so it always reflects the final outcome
+func reportValidationErrors(validationErrors []ValidationError, lastValidation bool) {
+
+	if len(validationErrors) > 0 {
+		logInfo("Validation found the following errors and warnings:")
+		printValidationErrors(os.Stdout, validationErrors)
+		log.Println("")
+	}
+
+	if *validationReportFile != "" && (lastValidation || hasValidationErrors(validationErrors)) {
+		err := writeValidationReport(*validationReportFile, validationErrors)
+		if err != nil {
+			logInfo("Failed writing validation report to %v: %v", *validationReportFile, err)
+		}
+	}
+
+	if hasValidationErrors(validationErrors) {
+		if explainOnly {
+			// explaining the params helps to understand the errors, so continue anyway
+			logInfo("Not all valid fields are set; see the errors above")
+			return
+		}
+		log.Fatal("Not all valid fields are set; see the errors above")
 	}
+}
+
+func generateKubernetesYaml(params Params) (TemplateData, bool) {
+	// determine built-in and local manifests
+	templates := getTemplates(params)
 
 	// pre-render config files if they exist
 	params.Configs.RenderedFileContent = renderConfig(params)
@@ -237,30 +370,32 @@ func generateKubernetesYaml(params Params) (TemplateData, *template.Template) {
 	// checking number of replicas for existing deployment to make switching deployment type safe
 	currentReplicas := getExistingNumberOfReplicas(params)
 
-	// generate the data required for rendering the templates
+	// generate the data required for rendering the manifests
 	templateData := generateTemplateData(params, currentReplicas, *releaseID, *triggeredBy)
 
-	// render the template
-	renderedTemplate, err := renderTemplate(tmpl, templateData)
+	if len(templates) == 0 {
+		return templateData, false
+	}
+
+	// render the manifests
+	renderedManifests, err := renderManifests(templates, templateData)
 	if err != nil {
-		log.Fatal("Failed rendering templates: ", err)
+		log.Fatal("Failed rendering manifests: ", err)
 	}
 
-	if tmpl != nil {
-		logInfo("Storing rendered manifest on disk...")
-		err = ioutil.WriteFile("/kubernetes.yaml", renderedTemplate.Bytes(), 0600)
-		if err != nil {
-			log.Fatal("Failed writing manifest: ", err)
-		}
+	logInfo("Storing rendered manifest on disk...")
+	err = ioutil.WriteFile("/kubernetes.yaml", renderedManifests.Bytes(), 0600)
+	if err != nil {
+		log.Fatal("Failed writing manifest: ", err)
 	}
 
-	return templateData, tmpl
+	return templateData, true
 }
 
-func applyKubernetesYaml(params Params, templateData TemplateData, tmpl *template.Template) {
+func applyKubernetesYaml(params Params, templateData TemplateData, hasManifests bool) {
 
 	kubectlApplyArgs := []string{"apply", "-f", "/kubernetes.yaml", "-n", templateData.Namespace}
-	if tmpl != nil {
+	if hasManifests {
 		// always perform a dryrun to ensure we're not ending up in a semi broken state where half of the templates is successfully applied and others not
 		logInfo("Performing a dryrun to test the validity of the manifests...")
 		runCommand("kubectl", append(kubectlApplyArgs, "--dry-run"))
@@ -274,20 +409,31 @@ func applyKubernetesYaml(params Params, templateData TemplateData, tmpl *templat
 	assistTroubleshootingOnError = true
 	paramsForTroubleshooting = params
 
-	if tmpl != nil {
+	if hasManifests {
 		patchServiceIfRequired(params, templateData, templateData.Name, templateData.Namespace)
 		patchDeploymentIfRequired(params, templateData.Name, templateData.Namespace)
 		removePoddisruptionBudgetIfRequired(params, templateData.NameWithTrack, templateData.Namespace)
 		removeIngressIfRequired(params, templateData, templateData.Name, templateData.Namespace)
+		migrateIngressClassIfRequired(params, templateData, templateData.Name, templateData.Namespace)
 		cleanupJobIfRequired(params, templateData, templateData.Name, templateData.Namespace)
 
 		logInfo("Applying the manifests for real...")
 		runCommand("kubectl", kubectlApplyArgs)
 
-		if params.Kind == "deployment" {
+		if params.Kind == "deployment" && params.Action != "rollback-canary" {
 			logInfo("Waiting for the deployment to finish...")
 			runCommand("kubectl", []string{"rollout", "status", "deployment", templateData.NameWithTrack, "-n", templateData.Namespace})
 		}
+		if params.Kind == "statefulset" {
+			logInfo("Waiting for the statefulset to finish...")
+			runCommand("kubectl", []string{"rollout", "status", "statefulset", templateData.Name, "-n", templateData.Namespace})
+		}
+		if params.Kind == "daemonset" && params.DaemonSet.UpdateStrategy == "RollingUpdate" {
+			logInfo("Waiting for the daemonset to finish...")
+			runCommand("kubectl", []string{"rollout", "status", "daemonset", templateData.Name, "-n", templateData.Namespace})
+		}
+
+		shiftTrafficToCanaryIfRequired(params, templateData)
 	}
 
 	// clean up old stuff
@@ -306,9 +452,12 @@ func applyKubernetesYaml(params Params, templateData TemplateData, tmpl *templat
 			deleteSecretsForParamsChange(params, templateData.NameWithTrack, templateData.Namespace)
 			deleteServiceAccountSecretForParamsChange(params, templateData.GoogleCloudCredentialsAppName, templateData.Namespace)
 			deleteIngressForVisibilityChange(templateData, templateData.Name, templateData.Namespace)
-			removeEstafetteCloudflareAnnotations(templateData, templateData.Name, templateData.Namespace)
+			removeUnusedDNSAnnotations(templateData, templateData.Name, templateData.Namespace)
 			removeBackendConfigAnnotation(templateData, templateData.Name, templateData.Namespace)
 			deleteBackendConfigAndIAPOauthSecret(templateData, templateData.Name, templateData.Namespace)
+			deleteCertificatesForProviderChange(templateData, templateData.Name, templateData.Namespace)
+			deleteMeshResourcesIfDisabled(templateData, templateData.Name, templateData.Namespace)
+			deleteNetworkPolicyIfDisabled(params, templateData.Name, templateData.Namespace)
 			break
 		case "rollback-canary":
 			scaleCanaryDeployment(templateData.Name, templateData.Namespace, 0)
@@ -320,13 +469,23 @@ func applyKubernetesYaml(params Params, templateData TemplateData, tmpl *templat
 			deleteSecretsForParamsChange(params, templateData.Name, templateData.Namespace)
 			deleteServiceAccountSecretForParamsChange(params, templateData.GoogleCloudCredentialsAppName, templateData.Namespace)
 			deleteIngressForVisibilityChange(templateData, templateData.Name, templateData.Namespace)
-			removeEstafetteCloudflareAnnotations(templateData, templateData.Name, templateData.Namespace)
+			removeUnusedDNSAnnotations(templateData, templateData.Name, templateData.Namespace)
 			removeBackendConfigAnnotation(templateData, templateData.Name, templateData.Namespace)
 			deleteBackendConfigAndIAPOauthSecret(templateData, templateData.Name, templateData.Namespace)
+			deleteCertificatesForProviderChange(templateData, templateData.Name, templateData.Namespace)
+			deleteMeshResourcesIfDisabled(templateData, templateData.Name, templateData.Namespace)
+			deleteNetworkPolicyIfDisabled(params, templateData.Name, templateData.Namespace)
 			break
 		}
 		break
-	case "job":
+	case "statefulset", "daemonset":
+		deleteConfigsForParamsChange(params, templateData.Name, templateData.Namespace)
+		deleteSecretsForParamsChange(params, templateData.Name, templateData.Namespace)
+		deleteServiceAccountSecretForParamsChange(params, templateData.GoogleCloudCredentialsAppName, templateData.Namespace)
+		deleteNetworkPolicyIfDisabled(params, templateData.Name, templateData.Namespace)
+		break
+	case "job", "cronjob":
+		deleteNetworkPolicyIfDisabled(params, templateData.Name, templateData.Namespace)
 		break
 	}
 
@@ -335,8 +494,8 @@ func applyKubernetesYaml(params Params, templateData TemplateData, tmpl *templat
 
 func assistTroubleshooting() {
 	if assistTroubleshootingOnError {
-		logInfo("Showing current ingresses, services, configmaps, secrets, deployments, jobs, cronjobs, poddisruptionbudgets, horizontalpodautoscalers, pods, endpoints for app=%v...", paramsForTroubleshooting.App)
-		runCommandExtended("kubectl", []string{"get", "ing,svc,cm,secret,deploy,job,cronjob,pdb,hpa,po,ep", "-l", fmt.Sprintf("app=%v", paramsForTroubleshooting.App), "-n", paramsForTroubleshooting.Namespace})
+		logInfo("Showing current ingresses, services, configmaps, secrets, deployments, statefulsets, daemonsets, jobs, cronjobs, poddisruptionbudgets, horizontalpodautoscalers, networkpolicies, pods, endpoints for app=%v...", paramsForTroubleshooting.App)
+		runCommandExtended("kubectl", []string{"get", "ing,svc,cm,secret,deploy,sts,ds,job,cronjob,pdb,hpa,netpol,po,ep", "-l", fmt.Sprintf("app=%v", paramsForTroubleshooting.App), "-n", paramsForTroubleshooting.Namespace})
 
 		if paramsForTroubleshooting.Action == "deploy-canary" {
 			logInfo("Showing logs for canary deployment...")
@@ -405,6 +564,10 @@ func deleteIngressForVisibilityChange(templateData TemplateData, name, namespace
 		logInfo("Deleting ingress if it exists, which is used for visibility private, iap or public-whitelist...")
 		runCommand("kubectl", []string{"delete", "ingress", name, "-n", namespace, "--ignore-not-found=true"})
 	}
+	if templateData.UseIstio {
+		logInfo("Deleting internal ingress if it exists, because the mesh routes the internal hosts...")
+		runCommand("kubectl", []string{"delete", "ingress", fmt.Sprintf("%v-internal", name), "-n", namespace, "--ignore-not-found=true"})
+	}
 }
 
 func deleteBackendConfigAndIAPOauthSecret(templateData TemplateData, name, namespace string) {
@@ -416,20 +579,98 @@ func deleteBackendConfigAndIAPOauthSecret(templateData TemplateData, name, names
 	}
 }
 
+func deleteCertificatesForProviderChange(templateData TemplateData, name, namespace string) {
+	// the certificate and managedcertificate resources only exist if cert-manager or gke managed certificates are available, so failing to
+	// delete them is not an error
+	if templateData.CertificateProvider != "cert-manager" {
+		logInfo("Deleting cert-manager certificate and its secret if they exist, because certificate provider is not set to cert-manager...")
+		err := runCommandExtended("kubectl", []string{"delete", "certificates.cert-manager.io", name, "-n", namespace, "--ignore-not-found=true"})
+		if err != nil {
+			logInfo("Failed deleting cert-manager certificate: %v", err)
+		}
+		if templateData.CertificateSecretName != fmt.Sprintf("%v-certificate", name) {
+			runCommand("kubectl", []string{"delete", "secret", fmt.Sprintf("%v-certificate", name), "-n", namespace, "--ignore-not-found=true"})
+		}
+	}
+	if templateData.CertificateProvider != "managed" {
+		logInfo("Deleting managed certificate if it exists, because certificate provider is not set to managed...")
+		err := runCommandExtended("kubectl", []string{"delete", "managedcertificates.networking.gke.io", name, "-n", namespace, "--ignore-not-found=true"})
+		if err != nil {
+			logInfo("Failed deleting managed certificate: %v", err)
+		}
+	}
+	if templateData.CertificateSecretName != fmt.Sprintf("%v-letsencrypt-certificate", name) {
+		logInfo("Deleting letsencrypt certificate secret if it exists, because the certificate comes from secret %v...", templateData.CertificateSecretName)
+		runCommand("kubectl", []string{"delete", "secret", fmt.Sprintf("%v-letsencrypt-certificate", name), "-n", namespace, "--ignore-not-found=true"})
+	}
+}
+
+func deleteMeshResourcesIfDisabled(templateData TemplateData, name, namespace string) {
+	// the istio resources only exist in clusters running istio, so failing to delete them is not an error
+	resources := []string{}
+	if !templateData.UseIstio {
+		logInfo("Deleting istio virtual service, destination rule and gateway if they exist, because mesh type is not set to istio...")
+		resources = append(resources, "virtualservices.networking.istio.io", "destinationrules.networking.istio.io", "gateways.networking.istio.io")
+	} else if !templateData.Mesh.RenderGateway {
+		logInfo("Deleting istio gateway if it exists, because the virtual service is bound to gateway %v...", strings.Join(templateData.Mesh.Gateways, ", "))
+		resources = append(resources, "gateways.networking.istio.io")
+	}
+	for _, resource := range resources {
+		err := runCommandExtended("kubectl", []string{"delete", resource, name, "-n", namespace, "--ignore-not-found=true"})
+		if err != nil {
+			logInfo("Failed deleting %v: %v", resource, err)
+		}
+	}
+}
+
+func shiftTrafficToCanaryIfRequired(params Params, templateData TemplateData) {
+	if !templateData.UseIstio || params.Action != "deploy-canary" {
+		return
+	}
+
+	// the virtual service is applied without canary traffic first, so the canary subset only gets traffic once its pods are ready
+	logInfo("Shifting %v%% of the traffic to the canary...", params.Mesh.CanaryWeight)
+	templateData.Mesh.CanaryWeight = params.Mesh.CanaryWeight
+	templates := []string{}
+	for _, t := range getTemplates(params) {
+		if filepath.Base(t) == "virtualservice.yaml" {
+			templates = append(templates, t)
+		}
+	}
+	renderedManifests, err := renderManifests(templates, templateData)
+	if err != nil {
+		log.Fatal("Failed rendering virtual service: ", err)
+	}
+	err = ioutil.WriteFile("/kubernetes-canary-traffic.yaml", renderedManifests.Bytes(), 0600)
+	if err != nil {
+		log.Fatal("Failed writing virtual service: ", err)
+	}
+	runCommand("kubectl", []string{"apply", "-f", "/kubernetes-canary-traffic.yaml", "-n", templateData.Namespace})
+}
+
+func deleteNetworkPolicyIfDisabled(params Params, name, namespace string) {
+	if !params.NetworkPolicy.Enabled {
+		logInfo("Deleting network policy if it exists, because networkpolicy.enabled is not set to true...")
+		runCommand("kubectl", []string{"delete", "networkpolicy", name, "-n", namespace, "--ignore-not-found=true"})
+	}
+}
+
 func removePoddisruptionBudgetIfRequired(params Params, name, namespace string) {
-	if params.Kind == "deployment" && (params.Action == "deploy-simple" || params.Action == "deploy-stable") {
-		// if there's a pdb that doesn't use maxUnavailable: 1 remove it so a new one can be created with correct settings
+	if (params.Kind == "deployment" || params.Kind == "statefulset") && (params.Action == "deploy-simple" || params.Action == "deploy-stable") {
+		if params.PodDisruptionBudget.Enabled != nil && !*params.PodDisruptionBudget.Enabled {
+			logInfo("Deleting pdb %v if it exists, because it's disabled...", name)
+			runCommand("kubectl", []string{"delete", "pdb", name, "-n", namespace, "--ignore-not-found=true"})
+			return
+		}
+
+		// if there's a pdb with a different spec remove it so a new one can be created with correct settings, since older versions of
+		// kubernetes don't allow updating it
 		deletePoddisruptionBudget := false
-		maxUnavailable, err := getCommandOutput("kubectl", []string{"get", "pdb", name, "-n", namespace, "-o=jsonpath={.spec.maxUnavailable}"})
+		desiredSpec := fmt.Sprintf("minAvailable=%v,maxUnavailable=%v", params.PodDisruptionBudget.MinAvailable, params.PodDisruptionBudget.MaxUnavailable)
+		currentSpec, err := getCommandOutput("kubectl", []string{"get", "pdb", name, "-n", namespace, "-o=jsonpath=minAvailable={.spec.minAvailable},maxUnavailable={.spec.maxUnavailable}"})
 		if err == nil {
-			maxUnavailableInt, err := strconv.Atoi(maxUnavailable)
-			if err == nil {
-				if maxUnavailableInt != 1 {
-					logInfo("MaxUnavailable from pdb %v is %v instead of 1", name, maxUnavailableInt)
-					deletePoddisruptionBudget = true
-				}
-			} else {
-				logInfo("Failed reading maxUnavailable from pdb %v: %v", name, err)
+			if currentSpec != desiredSpec {
+				logInfo("Spec of pdb %v is %v instead of %v", name, currentSpec, desiredSpec)
 				deletePoddisruptionBudget = true
 			}
 		} else {
@@ -449,36 +690,69 @@ func removeIngressIfRequired(params Params, templateData TemplateData, name, nam
 	if params.Kind == "deployment" && (params.Action == "deploy-simple" || params.Action == "deploy-canary" || params.Action == "deploy-stable") {
 		if templateData.UseNginxIngress {
 			// check if ingress exists and has kubernetes.io/ingress.class: gce, then delete it because of https://github.com/kubernetes/ingress-gce/issues/481
-			ingressClass, err := getCommandOutput("kubectl", []string{"get", "ing", name, "-n", namespace, "-o=go-template={{index .metadata.annotations \"kubernetes.io/ingress.class\"}}"})
+			ingressClass, err := getIngressClass(name, namespace)
 			if err == nil {
 				if ingressClass == "gce" {
 					// delete the ingress so all related load balancers, etc get deleted
 					logInfo("Deleting ingress so the gce ingress controller removes the related load balancer...")
 					runCommand("kubectl", []string{"delete", "ingress", name, "-n", namespace, "--ignore-not-found=true"})
 				} else {
-					logInfo("Ingress %v already has kubernetes.io/ingress.class: %v annotation, no need to delete the ingress", name, ingressClass)
+					logInfo("Ingress %v already has ingress class %v, no need to delete the ingress", name, ingressClass)
 				}
 			} else {
-				logInfo("Ingress %v or kubernetes.io/ingress.class annotation doesn't exist, no need to delete the ingress: %v", name, err)
+				logInfo("Ingress %v or its ingress class doesn't exist, no need to delete the ingress: %v", name, err)
 			}
 		} else if templateData.UseGCEIngress {
-			// check if ingress exists and has kubernetes.io/ingress.class: gce, then delete it to ensure there's no nginx ingress annotations lingering around
-			ingressClass, err := getCommandOutput("kubectl", []string{"get", "ing", name, "-n", namespace, "-o=go-template={{index .metadata.annotations \"kubernetes.io/ingress.class\"}}"})
+			// check if ingress exists and has ingress class nginx, then delete it to ensure there's no nginx ingress annotations lingering around
+			ingressClass, err := getIngressClass(name, namespace)
 			if err == nil {
 				if ingressClass == "nginx" {
 					// delete the ingress so all related nginx ingress config gets deleted
 					logInfo("Deleting ingress so the nginx ingress controller removes related config...")
 					runCommand("kubectl", []string{"delete", "ingress", name, "-n", namespace, "--ignore-not-found=true"})
 				} else {
-					logInfo("Ingress %v already has kubernetes.io/ingress.class: %v annotation, no need to delete the ingress", name, ingressClass)
+					logInfo("Ingress %v already has ingress class %v, no need to delete the ingress", name, ingressClass)
 				}
 			} else {
-				logInfo("Ingress %v or kubernetes.io/ingress.class annotation doesn't exist, no need to delete the ingress: %v", name, err)
+				logInfo("Ingress %v or its ingress class doesn't exist, no need to delete the ingress: %v", name, err)
 			}
 		}
 	}
 }
 
+// getIngressClass returns the class of an existing ingress from either the deprecated kubernetes.io/ingress.class annotation or the
+// ingressClassName of a networking.k8s.io/v1 ingress
+func getIngressClass(name, namespace string) (string, error) {
+	return getCommandOutput("kubectl", []string{"get", "ing", name, "-n", namespace, "-o=go-template={{with .metadata.annotations}}{{with index . \"kubernetes.io/ingress.class\"}}{{.}}{{end}}{{end}}{{with .spec.ingressClassName}}{{.}}{{end}}"})
+}
+
+// migrateIngressClassIfRequired moves the ingress class of existing nginx ingresses from the deprecated annotation to ingressClassName in a
+// single patch, so the ingress controller never sees the ingress without class when the networking.k8s.io/v1 ingress is applied
+func migrateIngressClassIfRequired(params Params, templateData TemplateData, name, namespace string) {
+	if params.Kind != "deployment" || useLegacyIngress(templateData) {
+		return
+	}
+
+	ingresses := map[string]string{}
+	if templateData.UseNginxIngress {
+		ingresses[name] = "nginx"
+	}
+	if templateData.UseInternalIngress {
+		ingresses[fmt.Sprintf("%v-internal", name)] = "nginx-internal"
+	}
+
+	for ingressName, ingressClass := range ingresses {
+		annotationClass, err := getCommandOutput("kubectl", []string{"get", "ing", ingressName, "-n", namespace, "-o=go-template={{with .metadata.annotations}}{{with index . \"kubernetes.io/ingress.class\"}}{{.}}{{end}}{{end}}"})
+		if err != nil || annotationClass == "" {
+			continue
+		}
+
+		logInfo("Moving ingress class of ingress %v from kubernetes.io/ingress.class annotation to ingressClassName...", ingressName)
+		patch := fmt.Sprintf(`[{"op": "remove", "path": "/metadata/annotations/kubernetes.io~1ingress.class"}, {"op": "add", "path": "/spec/ingressClassName", "value": "%v"}]`, ingressClass)
+		runCommand("kubectl", []string{"patch", "ingress", ingressName, "-n", namespace, "--type=json", "-p", patch})
+	}
+}
+
 func patchServiceIfRequired(params Params, templateData TemplateData, name, namespace string) {
 	if params.Kind == "deployment" && templateData.ServiceType == "ClusterIP" {
 		serviceType, err := getCommandOutput("kubectl", []string{"get", "service", name, "-n", namespace, "-o=jsonpath={.spec.type}"})
@@ -517,6 +791,23 @@ func cleanupJobIfRequired(params Params, templateData TemplateData, name, namesp
 	}
 }
 
+// getClusterVersion returns the server version of the cluster, or an empty string if it can't be determined, in which case the manifests
+// are rendered with the latest api versions
+func getClusterVersion() string {
+	output, err := getCommandOutput("kubectl", []string{"version", "-o", "json"})
+	if err != nil {
+		logInfo("Failed retrieving cluster version: %v; using latest api versions", err)
+		return ""
+	}
+	clusterVersion, err := parseServerVersion([]byte(output))
+	if err != nil {
+		logInfo("Failed parsing cluster version: %v; using latest api versions", err)
+		return ""
+	}
+	logInfo("Cluster has version %v", clusterVersion)
+	return clusterVersion
+}
+
 func getExistingNumberOfReplicas(params Params) int {
 	if params.Kind == "deployment" {
 		deploymentName := ""
@@ -564,14 +855,39 @@ func patchDeploymentIfRequired(params Params, name, namespace string) {
 	}
 }
 
-func removeEstafetteCloudflareAnnotations(templateData TemplateData, name, namespace string) {
-	if !templateData.UseDNSAnnotationsOnService {
-		// ingress is used and has the estafette.io/cloudflare annotations, so they should be removed from the service
-		logInfo("Removing estafette.io/cloudflare annotations on the service if they exists, since they're now set on the ingress instead...")
-		runCommand("kubectl", []string{"annotate", "svc", name, "-n", namespace, "estafette.io/cloudflare-dns-"})
-		runCommand("kubectl", []string{"annotate", "svc", name, "-n", namespace, "estafette.io/cloudflare-proxy-"})
-		runCommand("kubectl", []string{"annotate", "svc", name, "-n", namespace, "estafette.io/cloudflare-hostnames-"})
-		runCommand("kubectl", []string{"annotate", "svc", name, "-n", namespace, "estafette.io/cloudflare-state-"})
+func removeUnusedDNSAnnotations(templateData TemplateData, name, namespace string) {
+	// the public hosts' dns annotations are set on the ingress, or on the service for visibility public, and the dns controllers add state
+	// annotations themselves, so remove the ones of providers that are no longer used from either of them
+	serviceDNSProvider := "none"
+	if templateData.UseDNSAnnotationsOnService {
+		serviceDNSProvider = templateData.PublicDNSProvider
+	}
+	removeDNSAnnotationsOfOtherProviders("svc", name, namespace, serviceDNSProvider)
+	if templateData.UseDNSAnnotationsOnIngress {
+		removeDNSAnnotationsOfOtherProviders("ingress", name, namespace, templateData.PublicDNSProvider)
+	}
+	if templateData.UseInternalIngress {
+		removeDNSAnnotationsOfOtherProviders("ingress", fmt.Sprintf("%v-internal", name), namespace, templateData.InternalDNSProvider)
+	}
+}
+
+func removeDNSAnnotationsOfOtherProviders(kind, name, namespace, dnsProvider string) {
+	providers := []string{}
+	for provider := range dnsProviderAnnotations {
+		providers = append(providers, provider)
+	}
+	sort.Strings(providers)
+
+	for _, provider := range providers {
+		if provider == dnsProvider {
+			continue
+		}
+		logInfo("Removing %v dns annotations on %v %v if they exist, since dns provider is set to %v...", provider, kind, name, dnsProvider)
+		args := []string{"annotate", kind, name, "-n", namespace}
+		for _, annotation := range dnsProviderAnnotations[provider] {
+			args = append(args, annotation+"-")
+		}
+		runCommand("kubectl", args)
 	}
 }
 
@@ -0,0 +1,1456 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// buildManifest returns the typed object for the built-in manifest with the given file name, or false if there is no such manifest
+func buildManifest(filename string, data TemplateData) (interface{}, bool) {
+	switch filename {
+	case "namespace.yaml":
+		return buildNamespace(data), true
+	case "serviceaccount.yaml":
+		return buildServiceAccount(data), true
+	case "service.yaml":
+		return buildService(data), true
+	case "certificate-secret.yaml":
+		return buildCertificateSecret(data), true
+	case "certificate.yaml":
+		return buildCertificate(data), true
+	case "managedcertificate.yaml":
+		return buildManagedCertificate(data), true
+	case "deployment.yaml":
+		return buildDeployment(data), true
+	case "statefulset.yaml":
+		return buildStatefulSet(data), true
+	case "service-headless.yaml":
+		return buildHeadlessService(data), true
+	case "daemonset.yaml":
+		return buildDaemonSet(data), true
+	case "job.yaml":
+		return buildJob(data), true
+	case "cronjob.yaml":
+		return buildCronJob(data), true
+	case "poddisruptionbudget.yaml":
+		return buildPodDisruptionBudget(data), true
+	case "networkpolicy.yaml":
+		return buildNetworkPolicy(data), true
+	case "horizontalpodautoscaler.yaml":
+		return buildHorizontalPodAutoscaler(data), true
+	case "ingress.yaml":
+		return buildIngress(data), true
+	case "ingress-internal.yaml":
+		return buildInternalIngress(data), true
+	case "gateway.yaml":
+		return buildGateway(data), true
+	case "virtualservice.yaml":
+		return buildVirtualService(data), true
+	case "destinationrule.yaml":
+		return buildDestinationRule(data), true
+	case "backend-config.yaml":
+		return buildBackendConfig(data), true
+	case "iap-oauth-credentials-secret.yaml":
+		return buildIAPOauthCredentialsSecret(data), true
+	case "application-secrets.yaml":
+		return buildApplicationSecrets(data), true
+	case "service-account-secret.yaml":
+		return buildServiceAccountSecret(data), true
+	case "configmap.yaml":
+		return buildConfigMap(data), true
+	}
+
+	return nil, false
+}
+
+func buildNamespace(data TemplateData) Namespace {
+	return Namespace{
+		TypeMeta: TypeMeta{APIVersion: "v1", Kind: "Namespace"},
+		Metadata: ObjectMeta{Name: data.Namespace},
+	}
+}
+
+func buildServiceAccount(data TemplateData) ServiceAccount {
+	return ServiceAccount{
+		TypeMeta: TypeMeta{APIVersion: "v1", Kind: "ServiceAccount"},
+		Metadata: buildObjectMeta(data.Name, data),
+	}
+}
+
+func buildService(data TemplateData) Service {
+
+	service := Service{
+		TypeMeta: TypeMeta{APIVersion: "v1", Kind: "Service"},
+		Metadata: buildObjectMeta(data.Name, data),
+		Spec: ServiceSpec{
+			Type: data.ServiceType,
+			Ports: []ServicePort{
+				{Name: "http", Port: 80, TargetPort: "http", Protocol: "TCP"},
+				{Name: "https", Port: 443, TargetPort: "https", Protocol: "TCP"},
+			},
+			Selector: map[string]string{
+				"app": data.AppLabelSelector,
+			},
+		},
+	}
+
+	// the mesh provides tls, so the service goes straight to the application container without openresty sidecar
+	if data.UseIstio {
+		service.Spec.Ports = []ServicePort{
+			{Name: data.Mesh.ServicePortName, Port: 80, TargetPort: "web", Protocol: "TCP"},
+		}
+	}
+
+	service.Metadata.Annotations = map[string]string{
+		"prometheus.io/probe":                       "true",
+		"prometheus.io/probe-path":                  data.Container.Readiness.Path,
+		"service.alpha.kubernetes.io/app-protocols": fmt.Sprintf(`{"https":"%v"}`, data.ServiceAppProtocol),
+	}
+	if data.UseDNSAnnotationsOnService {
+		for k, v := range data.PublicDNSAnnotations {
+			service.Metadata.Annotations[k] = v
+		}
+	}
+	if data.UseBackendConfigAnnotationOnService {
+		service.Metadata.Annotations["beta.cloud.google.com/backend-config"] = fmt.Sprintf(`{"default": "%v"}`, data.Name)
+	}
+
+	if data.LimitTrustedIPRanges {
+		service.Spec.LoadBalancerSourceRanges = data.TrustedIPRanges
+	}
+
+	for _, ap := range data.AdditionalServicePorts {
+		service.Spec.Ports = append(service.Spec.Ports, ServicePort{
+			Name:       ap.Name,
+			Port:       ap.Port,
+			TargetPort: IntOrString(ap.Name),
+			Protocol:   ap.Protocol,
+		})
+	}
+
+	return service
+}
+
+func buildCertificateSecret(data TemplateData) Secret {
+
+	secret := Secret{
+		TypeMeta: TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		Metadata: buildObjectMeta(fmt.Sprintf("%v-letsencrypt-certificate", data.Name), data),
+		Type:     "Opaque",
+	}
+
+	secret.Metadata.Labels = copyLabels(data.Labels, map[string]string{"type": "letsencrypt-certificate"})
+	secret.Metadata.Annotations = map[string]string{
+		"estafette.io/letsencrypt-certificate":           "true",
+		"estafette.io/letsencrypt-certificate-hostnames": data.AllHostsJoined,
+	}
+
+	return secret
+}
+
+func buildCertificate(data TemplateData) Certificate {
+	return Certificate{
+		TypeMeta: TypeMeta{APIVersion: "cert-manager.io/v1", Kind: "Certificate"},
+		Metadata: buildObjectMeta(data.Name, data),
+		Spec: CertificateSpec{
+			SecretName: certificateSecretName(data),
+			DNSNames:   data.AllHosts,
+			IssuerRef: CertificateIssuerRef{
+				Name:  data.CertManagerIssuer,
+				Kind:  data.CertManagerIssuerKind,
+				Group: "cert-manager.io",
+			},
+		},
+	}
+}
+
+// buildManagedCertificate has google issue the certificate for the public hosts served by the gce ingress
+func buildManagedCertificate(data TemplateData) ManagedCertificate {
+	return ManagedCertificate{
+		TypeMeta: TypeMeta{APIVersion: "networking.gke.io/v1", Kind: "ManagedCertificate"},
+		Metadata: buildObjectMeta(data.Name, data),
+		Spec: ManagedCertificateSpec{
+			Domains: data.Hosts,
+		},
+	}
+}
+
+// certificateSecretName returns the name of the secret with the tls certificate, which is the estafette letsencrypt one unless another
+// certificate provider is used
+func certificateSecretName(data TemplateData) string {
+	if data.CertificateSecretName != "" {
+		return data.CertificateSecretName
+	}
+	return fmt.Sprintf("%v-letsencrypt-certificate", data.Name)
+}
+
+// buildCertificateVolume mounts the certificate secret for the openresty sidecar, which expects the file names of the estafette
+// letsencrypt secret, so the keys of a kubernetes.io/tls secret are mapped to those
+func buildCertificateVolume(data TemplateData) Volume {
+
+	volume := Volume{Name: "ssl-certificate", Secret: &SecretVolumeSource{SecretName: certificateSecretName(data)}}
+	if data.CertificateSecretHasTLSKeys {
+		volume.Secret.Items = []KeyToPath{
+			{Key: "tls.crt", Path: "ssl.pem"},
+			{Key: "tls.key", Path: "ssl.key"},
+		}
+	}
+
+	return volume
+}
+
+func buildDeployment(data TemplateData) Deployment {
+
+	deployment := Deployment{
+		TypeMeta: TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		Metadata: buildObjectMeta(data.NameWithTrack, data),
+		Spec: DeploymentSpec{
+			Strategy: DeploymentStrategy{
+				Type: "RollingUpdate",
+				RollingUpdate: &RollingUpdateDeployment{
+					MaxSurge:       IntOrString(data.RollingUpdateMaxSurge),
+					MaxUnavailable: IntOrString(data.RollingUpdateMaxUnavailable),
+				},
+			},
+			RevisionHistoryLimit: 10,
+			Selector: LabelSelector{
+				MatchLabels: buildSelectorLabels(data),
+			},
+			Template: buildServicePodTemplate(data, true),
+		},
+	}
+
+	if data.IncludeReplicas {
+		replicas := data.Replicas
+		deployment.Spec.Replicas = &replicas
+	}
+
+	return deployment
+}
+
+// buildServicePodTemplate has the pods of the long running kinds run the application container with its ports, probes and sidecars; the
+// certificate volume is only needed by the openresty sidecar of deployments
+func buildServicePodTemplate(data TemplateData, mountCertificate bool) PodTemplateSpec {
+
+	template := PodTemplateSpec{
+		Metadata: buildPodTemplateMetadata(data, data.IncludeTrackLabel),
+		Spec: PodSpec{
+			ServiceAccount:                data.Name,
+			TerminationGracePeriodSeconds: 300,
+		},
+	}
+
+	if data.AddSafeToEvictAnnotation {
+		template.Metadata.Annotations["cluster-autoscaler.kubernetes.io/safe-to-evict"] = "true"
+	}
+	if data.UseIstio {
+		template.Metadata.Annotations["sidecar.istio.io/inject"] = "true"
+	}
+
+	podSpec := &template.Spec
+	applyScheduling(podSpec, data)
+
+	if data.MountPayloadLogging {
+		podSpec.InitContainers = []Container{
+			{
+				Name:            "init-myservice",
+				Image:           "busybox:1.27.2",
+				ImagePullPolicy: "IfNotPresent",
+				Command:         []string{"sh", "-c", fmt.Sprintf("mkdir -p /var/log/fluentd-payload-logger/%v", data.Name)},
+				VolumeMounts: []VolumeMount{
+					{Name: "var-log", MountPath: "/var/log"},
+				},
+			},
+		}
+	}
+	podSpec.InitContainers = append(podSpec.InitContainers, buildInitContainers(data)...)
+
+	container := buildApplicationContainer(data, true)
+	container.Ports = []ContainerPort{
+		{Name: "web", ContainerPort: data.Container.Port},
+	}
+	for _, ap := range data.AdditionalContainerPorts {
+		container.Ports = append(container.Ports, ContainerPort{Name: ap.Name, ContainerPort: ap.Port, Protocol: ap.Protocol})
+	}
+	container.LivenessProbe = buildProbe(data.Container.Liveness)
+	if data.Container.Readiness.IncludeOnContainer {
+		container.ReadinessProbe = buildProbe(data.Container.Readiness)
+	}
+	if data.Container.Startup.IncludeOnContainer {
+		container.StartupProbe = buildProbe(data.Container.Startup)
+	}
+	if data.Container.UseLifecyclePreStopSleepCommand {
+		container.Lifecycle = &Lifecycle{
+			PreStop: &Handler{
+				Exec: &ExecAction{
+					Command: []string{"/bin/sleep", fmt.Sprintf("%vs", data.Container.PreStopSleepSeconds)},
+				},
+			},
+		}
+	}
+	podSpec.Containers = append(podSpec.Containers, container)
+
+	for _, sidecar := range data.Sidecars {
+		switch sidecar.Type {
+		case "openresty":
+			podSpec.Containers = append(podSpec.Containers, buildOpenrestySidecar(data, sidecar))
+		case "cloudsqlproxy":
+			podSpec.Containers = append(podSpec.Containers, buildCloudSQLProxySidecar(data, sidecar))
+		}
+	}
+
+	podSpec.Volumes = buildApplicationVolumes(data, data.NameWithTrack, true)
+	if mountCertificate {
+		podSpec.Volumes = append([]Volume{buildCertificateVolume(data)}, podSpec.Volumes...)
+	}
+
+	return template
+}
+
+func buildStatefulSet(data TemplateData) StatefulSet {
+
+	replicas := data.StatefulSet.Replicas
+	statefulSet := StatefulSet{
+		TypeMeta: TypeMeta{APIVersion: "apps/v1", Kind: "StatefulSet"},
+		Metadata: buildObjectMeta(data.Name, data),
+		Spec: StatefulSetSpec{
+			Replicas:            &replicas,
+			ServiceName:         data.StatefulSet.ServiceName,
+			PodManagementPolicy: data.StatefulSet.PodManagementPolicy,
+			UpdateStrategy: StatefulSetUpdateStrategy{
+				Type: "RollingUpdate",
+			},
+			RevisionHistoryLimit: 10,
+			Selector: LabelSelector{
+				MatchLabels: buildSelectorLabels(data),
+			},
+			Template: buildServicePodTemplate(data, false),
+		},
+	}
+
+	if data.StatefulSet.Partition != nil {
+		statefulSet.Spec.UpdateStrategy.RollingUpdate = &RollingUpdateStatefulSetStrategy{Partition: data.StatefulSet.Partition}
+	}
+
+	container := &statefulSet.Spec.Template.Spec.Containers[0]
+	for _, claim := range data.StatefulSet.VolumeClaimTemplates {
+		container.VolumeMounts = append(container.VolumeMounts, VolumeMount{Name: claim.Name, MountPath: claim.MountPath})
+
+		statefulSet.Spec.VolumeClaimTemplates = append(statefulSet.Spec.VolumeClaimTemplates, PersistentVolumeClaimTemplate{
+			Metadata: ObjectMeta{Name: claim.Name},
+			Spec: PersistentVolumeClaimSpec{
+				AccessModes:      claim.AccessModes,
+				StorageClassName: claim.StorageClass,
+				Resources: PersistentVolumeClaimResources{
+					Requests: map[string]string{"storage": claim.Size},
+				},
+			},
+		})
+	}
+
+	return statefulSet
+}
+
+func buildDaemonSet(data TemplateData) DaemonSet {
+
+	daemonSet := DaemonSet{
+		TypeMeta: TypeMeta{APIVersion: "apps/v1", Kind: "DaemonSet"},
+		Metadata: buildObjectMeta(data.Name, data),
+		Spec: DaemonSetSpec{
+			UpdateStrategy: DaemonSetUpdateStrategy{
+				Type: data.DaemonSet.UpdateStrategy,
+			},
+			RevisionHistoryLimit: 10,
+			Selector: LabelSelector{
+				MatchLabels: buildSelectorLabels(data),
+			},
+			Template: buildServicePodTemplate(data, false),
+		},
+	}
+
+	if data.DaemonSet.UpdateStrategy == "RollingUpdate" && data.DaemonSet.MaxUnavailable != "" {
+		daemonSet.Spec.UpdateStrategy.RollingUpdate = &RollingUpdateDaemonSet{MaxUnavailable: IntOrString(data.DaemonSet.MaxUnavailable)}
+	}
+
+	podSpec := &daemonSet.Spec.Template.Spec
+	if data.DaemonSet.HostNetwork {
+		// without this dns policy a pod on the host network resolves names with the dns config of the node instead of the cluster
+		podSpec.HostNetwork = true
+		podSpec.DNSPolicy = "ClusterFirstWithHostNet"
+	}
+
+	container := &podSpec.Containers[0]
+	for _, volume := range data.DaemonSet.HostPathVolumes {
+		container.VolumeMounts = append(container.VolumeMounts, VolumeMount{Name: volume.Name, MountPath: volume.MountPath, ReadOnly: volume.ReadOnly})
+		podSpec.Volumes = append(podSpec.Volumes, Volume{Name: volume.Name, HostPath: &HostPathVolumeSource{Path: volume.HostPath, Type: volume.Type}})
+	}
+
+	return daemonSet
+}
+
+// buildHeadlessService gives each pod of a statefulset a stable dns name, like myapp-0.myapp-headless.mynamespace.svc
+func buildHeadlessService(data TemplateData) Service {
+
+	service := Service{
+		TypeMeta: TypeMeta{APIVersion: "v1", Kind: "Service"},
+		Metadata: buildObjectMeta(data.StatefulSet.ServiceName, data),
+		Spec: ServiceSpec{
+			ClusterIP: "None",
+			Ports: []ServicePort{
+				{Name: "web", Port: data.Container.Port, TargetPort: "web", Protocol: "TCP"},
+			},
+			Selector: map[string]string{
+				"app": data.AppLabelSelector,
+			},
+		},
+	}
+
+	for _, ap := range data.AdditionalContainerPorts {
+		service.Spec.Ports = append(service.Spec.Ports, ServicePort{
+			Name:       ap.Name,
+			Port:       ap.Port,
+			TargetPort: IntOrString(ap.Name),
+			Protocol:   ap.Protocol,
+		})
+	}
+
+	return service
+}
+
+func buildJob(data TemplateData) Job {
+	return Job{
+		TypeMeta: TypeMeta{APIVersion: "batch/v1", Kind: "Job"},
+		Metadata: buildObjectMeta(data.Name, data),
+		Spec:     buildJobSpec(data),
+	}
+}
+
+func buildCronJob(data TemplateData) CronJob {
+	return CronJob{
+		TypeMeta: TypeMeta{APIVersion: data.CronJobAPIVersion, Kind: "CronJob"},
+		Metadata: buildObjectMeta(data.Name, data),
+		Spec: CronJobSpec{
+			Schedule:                   data.Schedule,
+			ConcurrencyPolicy:          data.ConcurrencyPolicy,
+			FailedJobsHistoryLimit:     1,
+			SuccessfulJobsHistoryLimit: 3,
+			Suspend:                    false,
+			JobTemplate: JobTemplateSpec{
+				Spec: buildJobSpec(data),
+			},
+		},
+	}
+}
+
+func buildJobSpec(data TemplateData) JobSpec {
+	jobSpec := JobSpec{
+		Completions: 1,
+		Parallelism: 1,
+		Template: PodTemplateSpec{
+			Metadata: buildPodTemplateMetadata(data, false),
+			Spec: PodSpec{
+				RestartPolicy:                 "OnFailure",
+				ServiceAccount:                data.Name,
+				InitContainers:                buildInitContainers(data),
+				Containers:                    []Container{buildApplicationContainer(data, false)},
+				TerminationGracePeriodSeconds: 300,
+				Volumes:                       buildApplicationVolumes(data, data.Name, false),
+			},
+		},
+	}
+
+	applyScheduling(&jobSpec.Template.Spec, data)
+
+	return jobSpec
+}
+
+func buildPodDisruptionBudget(data TemplateData) PodDisruptionBudget {
+	return PodDisruptionBudget{
+		TypeMeta: TypeMeta{APIVersion: data.PodDisruptionBudgetAPIVersion, Kind: "PodDisruptionBudget"},
+		Metadata: buildObjectMeta(data.NameWithTrack, data),
+		Spec: PodDisruptionBudgetSpec{
+			Selector: LabelSelector{
+				MatchLabels: buildSelectorLabels(data),
+			},
+			MinAvailable:   IntOrString(data.PodDisruptionBudgetMinAvailable),
+			MaxUnavailable: IntOrString(data.PodDisruptionBudgetMaxUnavailable),
+		},
+	}
+}
+
+func buildNetworkPolicy(data TemplateData) NetworkPolicy {
+
+	networkPolicy := NetworkPolicy{
+		TypeMeta: TypeMeta{APIVersion: "networking.k8s.io/v1", Kind: "NetworkPolicy"},
+		Metadata: buildObjectMeta(data.Name, data),
+		Spec: NetworkPolicySpec{
+			// select stable and canary pods alike, so the policy doesn't depend on the track
+			PodSelector: LabelSelector{
+				MatchLabels: map[string]string{"app": data.AppLabelSelector},
+			},
+			PolicyTypes: []string{"Ingress"},
+		},
+	}
+
+	if data.NetworkPolicy.AllowAllIngress {
+		networkPolicy.Spec.Ingress = append(networkPolicy.Spec.Ingress, NetworkPolicyIngressRule{})
+	}
+
+	if len(data.NetworkPolicy.IngressNamespaces) > 0 {
+		rule := NetworkPolicyIngressRule{}
+		for _, namespace := range data.NetworkPolicy.IngressNamespaces {
+			rule.From = append(rule.From, buildNetworkPolicyPeer("", namespace))
+		}
+		networkPolicy.Spec.Ingress = append(networkPolicy.Spec.Ingress, rule)
+	}
+
+	if len(data.NetworkPolicy.IngressIPBlocks) > 0 {
+		rule := NetworkPolicyIngressRule{}
+		for _, cidr := range data.NetworkPolicy.IngressIPBlocks {
+			rule.From = append(rule.From, NetworkPolicyPeer{IPBlock: &IPBlock{CIDR: cidr}})
+		}
+		networkPolicy.Spec.Ingress = append(networkPolicy.Spec.Ingress, rule)
+	}
+
+	if len(data.NetworkPolicy.MetricsPorts) > 0 && data.NetworkPolicy.PrometheusNamespace != "" {
+		rule := NetworkPolicyIngressRule{
+			From: []NetworkPolicyPeer{buildNetworkPolicyPeer("", data.NetworkPolicy.PrometheusNamespace)},
+		}
+		for _, port := range data.NetworkPolicy.MetricsPorts {
+			rule.Ports = append(rule.Ports, NetworkPolicyPort{Protocol: "TCP", Port: IntOrString(strconv.Itoa(port))})
+		}
+		networkPolicy.Spec.Ingress = append(networkPolicy.Spec.Ingress, rule)
+	}
+
+	if len(data.NetworkPolicy.AllowedClients) > 0 {
+		rule := NetworkPolicyIngressRule{}
+		for _, client := range data.NetworkPolicy.AllowedClients {
+			rule.From = append(rule.From, buildNetworkPolicyPeer(client.App, client.Namespace))
+		}
+		networkPolicy.Spec.Ingress = append(networkPolicy.Spec.Ingress, rule)
+	}
+
+	if data.NetworkPolicy.RestrictEgress {
+		networkPolicy.Spec.PolicyTypes = append(networkPolicy.Spec.PolicyTypes, "Egress")
+
+		// dns lookups are always allowed, otherwise none of the destinations can be resolved
+		networkPolicy.Spec.Egress = append(networkPolicy.Spec.Egress, NetworkPolicyEgressRule{
+			Ports: []NetworkPolicyPort{
+				{Protocol: "UDP", Port: "53"},
+				{Protocol: "TCP", Port: "53"},
+			},
+		})
+
+		for _, egress := range data.NetworkPolicy.Egress {
+			rule := NetworkPolicyEgressRule{}
+			if egress.CIDR != "" {
+				rule.To = []NetworkPolicyPeer{{IPBlock: &IPBlock{CIDR: egress.CIDR}}}
+			} else {
+				rule.To = []NetworkPolicyPeer{buildNetworkPolicyPeer(egress.App, egress.Namespace)}
+			}
+			for _, port := range egress.Ports {
+				rule.Ports = append(rule.Ports, NetworkPolicyPort{Protocol: port.Protocol, Port: IntOrString(strconv.Itoa(port.Port))})
+			}
+			networkPolicy.Spec.Egress = append(networkPolicy.Spec.Egress, rule)
+		}
+	}
+
+	return networkPolicy
+}
+
+// buildNetworkPolicyPeer selects pods by app label within the namespace of the policy, all pods in a namespace or pods by app label in a
+// namespace
+func buildNetworkPolicyPeer(app, namespace string) NetworkPolicyPeer {
+	peer := NetworkPolicyPeer{}
+	if app != "" {
+		peer.PodSelector = &LabelSelector{MatchLabels: map[string]string{"app": app}}
+	}
+	if namespace != "" {
+		peer.NamespaceSelector = &LabelSelector{MatchLabels: map[string]string{"kubernetes.io/metadata.name": namespace}}
+	}
+	return peer
+}
+
+func buildHorizontalPodAutoscaler(data TemplateData) HorizontalPodAutoscaler {
+
+	hpa := HorizontalPodAutoscaler{
+		TypeMeta: TypeMeta{APIVersion: data.HorizontalPodAutoscalerAPIVersion, Kind: "HorizontalPodAutoscaler"},
+		Metadata: buildObjectMeta(data.NameWithTrack, data),
+		Spec: HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: CrossVersionObjectReference{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Name:       data.NameWithTrack,
+			},
+			MinReplicas: data.MinReplicas,
+			MaxReplicas: data.MaxReplicas,
+			Metrics:     buildMetricSpecs(data),
+		},
+	}
+
+	if data.AutoscaleScaleUp != nil || data.AutoscaleScaleDown != nil {
+		hpa.Spec.Behavior = &HorizontalPodAutoscalerBehavior{
+			ScaleUp:   buildHPAScalingRules(data.AutoscaleScaleUp),
+			ScaleDown: buildHPAScalingRules(data.AutoscaleScaleDown),
+		}
+	}
+
+	if data.UseHpaScaler {
+		hpa.Metadata.Annotations = map[string]string{
+			"estafette.io/hpa-scaler":                      "true",
+			"estafette.io/hpa-scaler-prometheus-query":     data.HpaScalerPromQuery,
+			"estafette.io/hpa-scaler-requests-per-replica": data.HpaScalerRequestsPerReplica,
+			"estafette.io/hpa-scaler-delta":                data.HpaScalerDelta,
+			"estafette.io/hpa-scaler-scale-down-max-ratio": data.HpaScalerScaleDownMaxRatio,
+		}
+	}
+
+	return hpa
+}
+
+// buildMetricSpecs returns the cpu and memory utilization metrics followed by the pods, object and external metrics
+func buildMetricSpecs(data TemplateData) []MetricSpec {
+
+	metrics := []MetricSpec{}
+
+	if data.TargetCPUPercentage > 0 {
+		metrics = append(metrics, MetricSpec{
+			Type:     "Resource",
+			Resource: &ResourceMetricSource{Name: "cpu", Target: MetricTarget{Type: "Utilization", AverageUtilization: data.TargetCPUPercentage}},
+		})
+	}
+	if data.TargetMemoryPercentage > 0 {
+		metrics = append(metrics, MetricSpec{
+			Type:     "Resource",
+			Resource: &ResourceMetricSource{Name: "memory", Target: MetricTarget{Type: "Utilization", AverageUtilization: data.TargetMemoryPercentage}},
+		})
+	}
+
+	for _, m := range data.AutoscaleMetrics {
+		metric := MetricIdentifier{Name: m.Name}
+		if len(m.Selector) > 0 {
+			metric.Selector = &LabelSelector{MatchLabels: m.Selector}
+		}
+		target := MetricTarget{Type: m.TargetType}
+		if m.TargetType == "Value" {
+			target.Value = Quantity(m.Target)
+		} else {
+			target.AverageValue = Quantity(m.Target)
+		}
+
+		switch m.Type {
+		case "pods":
+			metrics = append(metrics, MetricSpec{Type: "Pods", Pods: &PodsMetricSource{Metric: metric, Target: target}})
+		case "object":
+			metrics = append(metrics, MetricSpec{Type: "Object", Object: &ObjectMetricSource{
+				DescribedObject: CrossVersionObjectReference{APIVersion: m.ObjectAPIVersion, Kind: m.ObjectKind, Name: m.ObjectName},
+				Metric:          metric,
+				Target:          target,
+			}})
+		case "external":
+			metrics = append(metrics, MetricSpec{Type: "External", External: &ExternalMetricSource{Metric: metric, Target: target}})
+		}
+	}
+
+	return metrics
+}
+
+func buildHPAScalingRules(rules *AutoscaleScalingRulesData) *HPAScalingRules {
+	if rules == nil {
+		return nil
+	}
+
+	scalingRules := &HPAScalingRules{
+		StabilizationWindowSeconds: rules.StabilizationWindowSeconds,
+		SelectPolicy:               rules.SelectPolicy,
+	}
+	for _, policy := range rules.Policies {
+		scalingRules.Policies = append(scalingRules.Policies, HPAScalingPolicy{Type: policy.Type, Value: policy.Value, PeriodSeconds: policy.PeriodSeconds})
+	}
+
+	return scalingRules
+}
+
+func buildIngress(data TemplateData) Ingress {
+
+	ingressClass := "nginx"
+	if data.UseGCEIngress {
+		ingressClass = "gce"
+	}
+
+	ingress := Ingress{
+		TypeMeta: TypeMeta{APIVersion: data.IngressAPIVersion, Kind: "Ingress"},
+		Metadata: buildObjectMeta(data.Name, data),
+		Spec:     buildIngressSpec(data, data.Hosts, data.IngressPath, false),
+	}
+
+	ingress.Metadata.Annotations = map[string]string{}
+	if data.UseNginxIngress {
+		ingress.Metadata.Annotations = buildNginxIngressAnnotations(data)
+		if data.OverrideDefaultWhitelist {
+			ingress.Metadata.Annotations["nginx.ingress.kubernetes.io/whitelist-source-range"] = data.NginxIngressWhitelist
+		}
+	}
+	if data.UseGCEIngress {
+		ingress.Metadata.Annotations["kubernetes.io/ingress.allow-http"] = "false"
+		if data.CertificateProvider == "managed" {
+			ingress.Metadata.Annotations["networking.gke.io/managed-certificates"] = data.Name
+		}
+	}
+	setIngressClass(&ingress, data, ingressClass)
+	if data.UseDNSAnnotationsOnIngress {
+		for k, v := range data.PublicDNSAnnotations {
+			ingress.Metadata.Annotations[k] = v
+		}
+	}
+	for k, v := range data.IngressCustomAnnotations {
+		ingress.Metadata.Annotations[k] = v
+	}
+
+	return ingress
+}
+
+func buildInternalIngress(data TemplateData) Ingress {
+
+	ingress := Ingress{
+		TypeMeta: TypeMeta{APIVersion: data.IngressAPIVersion, Kind: "Ingress"},
+		Metadata: buildObjectMeta(fmt.Sprintf("%v-internal", data.Name), data),
+		Spec:     buildIngressSpec(data, data.InternalHosts, data.InternalIngressPath, true),
+	}
+
+	ingress.Metadata.Annotations = buildNginxIngressAnnotations(data)
+	setIngressClass(&ingress, data, "nginx-internal")
+	for k, v := range data.InternalDNSAnnotations {
+		ingress.Metadata.Annotations[k] = v
+	}
+	for k, v := range data.IngressCustomAnnotations {
+		ingress.Metadata.Annotations[k] = v
+	}
+
+	return ingress
+}
+
+// useLegacyIngress returns whether the ingress is rendered as extensions/v1beta1 for clusters older than 1.19
+func useLegacyIngress(data TemplateData) bool {
+	return data.IngressAPIVersion == "extensions/v1beta1"
+}
+
+// setIngressClass sets the ingressClassName for networking.k8s.io/v1 ingresses and the deprecated annotation for older ones; the gce
+// ingress controller only supports the annotation, so it always gets that
+func setIngressClass(ingress *Ingress, data TemplateData, ingressClass string) {
+	if useLegacyIngress(data) || ingressClass == "gce" {
+		ingress.Metadata.Annotations["kubernetes.io/ingress.class"] = ingressClass
+		return
+	}
+	ingress.Spec.IngressClassName = ingressClass
+}
+
+// buildIngressSpec routes the basepath of each host to the application, unless there are routes for the host, which replace it
+func buildIngressSpec(data TemplateData, hosts []string, path string, internal bool) IngressSpec {
+
+	spec := IngressSpec{}
+
+	// a managed certificate is attached to the gce ingress by annotation instead
+	if internal || !data.UseGCEIngress || data.CertificateProvider != "managed" {
+		spec.TLS = []IngressTLS{
+			{Hosts: hosts, SecretName: certificateSecretName(data)},
+		}
+	}
+
+	for _, host := range hosts {
+		paths := []HTTPIngressPath{}
+		for _, route := range data.Routes {
+			if len(route.Hosts) > 0 && !stringArrayContains(route.Hosts, host) {
+				continue
+			}
+			routePath := route.Path
+			if internal {
+				routePath = route.InternalPath
+			}
+			paths = append(paths, buildHTTPIngressPath(data, routePath, route.ServiceName, route.ServicePort))
+		}
+		if len(paths) == 0 {
+			paths = append(paths, buildHTTPIngressPath(data, path, data.Name, "https"))
+		}
+
+		spec.Rules = append(spec.Rules, IngressRule{
+			Host: host,
+			HTTP: &HTTPIngressRuleValue{
+				Paths: paths,
+			},
+		})
+	}
+
+	return spec
+}
+
+// buildHTTPIngressPath routes path to the named or numbered port of a service; networking.k8s.io/v1 requires a path type, which is prefix unless the
+// path has a wildcard as used by the gce ingress controller
+func buildHTTPIngressPath(data TemplateData, path, serviceName, servicePort string) HTTPIngressPath {
+
+	if useLegacyIngress(data) {
+		return HTTPIngressPath{
+			Path:    path,
+			Backend: IngressBackend{ServiceName: serviceName, ServicePort: IntOrString(servicePort)},
+		}
+	}
+
+	pathType := "Prefix"
+	if strings.Contains(path, "*") {
+		pathType = "ImplementationSpecific"
+	}
+
+	backendPort := ServiceBackendPort{Name: servicePort}
+	if portNumber, err := strconv.Atoi(servicePort); err == nil {
+		backendPort = ServiceBackendPort{Number: portNumber}
+	}
+
+	return HTTPIngressPath{
+		Path:     path,
+		PathType: pathType,
+		Backend: IngressBackend{
+			Service: &IngressServiceBackend{Name: serviceName, Port: backendPort},
+		},
+	}
+}
+
+// buildGateway accepts traffic for all hosts on the istio ingress gateway, redirecting http to https
+func buildGateway(data TemplateData) Gateway {
+	return Gateway{
+		TypeMeta: TypeMeta{APIVersion: "networking.istio.io/v1beta1", Kind: "Gateway"},
+		Metadata: buildObjectMeta(data.Name, data),
+		Spec: GatewaySpec{
+			Selector: data.Mesh.GatewaySelector,
+			Servers: []GatewayServer{
+				{
+					Port:  GatewayPort{Number: 443, Name: "https", Protocol: "HTTPS"},
+					Hosts: data.AllHosts,
+					TLS:   &GatewayServerTLS{Mode: "SIMPLE", CredentialName: certificateSecretName(data)},
+				},
+				{
+					Port:  GatewayPort{Number: 80, Name: "http", Protocol: "HTTP"},
+					Hosts: data.AllHosts,
+					TLS:   &GatewayServerTLS{HTTPSRedirect: true},
+				},
+			},
+		},
+	}
+}
+
+// buildVirtualService routes the paths of all hosts like the ingresses do, with routes replacing the basepath for the hosts they apply to
+func buildVirtualService(data TemplateData) VirtualService {
+
+	virtualService := VirtualService{
+		TypeMeta: TypeMeta{APIVersion: "networking.istio.io/v1beta1", Kind: "VirtualService"},
+		Metadata: buildObjectMeta(data.Name, data),
+		Spec: VirtualServiceSpec{
+			Hosts:    data.AllHosts,
+			Gateways: data.Mesh.Gateways,
+		},
+	}
+
+	routedHosts := map[string]bool{}
+	routesAllHosts := false
+	for _, route := range data.Routes {
+		if len(route.Hosts) == 0 {
+			routesAllHosts = true
+		}
+		for _, host := range route.Hosts {
+			routedHosts[host] = true
+		}
+		virtualService.Spec.HTTP = append(virtualService.Spec.HTTP, buildMeshHTTPRoute(data, route.Hosts, route.Path, route.ServiceName, route.ServicePort))
+	}
+
+	// hosts without routes of their own get the basepath, which matches any host if no route is limited to specific hosts
+	if !routesAllHosts {
+		basepathHosts := []string{}
+		for _, host := range data.AllHosts {
+			if len(routedHosts) > 0 && !routedHosts[host] {
+				basepathHosts = append(basepathHosts, host)
+			}
+		}
+		if len(routedHosts) == 0 || len(basepathHosts) > 0 {
+			virtualService.Spec.HTTP = append(virtualService.Spec.HTTP, buildMeshHTTPRoute(data, basepathHosts, data.IngressPath, data.Name, "http"))
+		}
+	}
+
+	return virtualService
+}
+
+// buildMeshHTTPRoute matches the path prefix for the hosts, or any host if none are given, and routes it to a port of a service; the
+// app's own service is split into the stable and canary subsets during canary releases
+func buildMeshHTTPRoute(data TemplateData, hosts []string, path, serviceName, servicePort string) HTTPRoute {
+
+	httpRoute := HTTPRoute{
+		Timeout: data.Mesh.Timeout,
+	}
+	if data.Mesh.Retries != nil {
+		httpRoute.Retries = &HTTPRetry{Attempts: *data.Mesh.Retries, RetryOn: data.Mesh.RetryOn}
+	}
+
+	if len(hosts) == 0 {
+		httpRoute.Match = []HTTPMatchRequest{{URI: &StringMatch{Prefix: path}}}
+	}
+	for _, host := range hosts {
+		httpRoute.Match = append(httpRoute.Match, HTTPMatchRequest{URI: &StringMatch{Prefix: path}, Authority: &StringMatch{Exact: host}})
+	}
+
+	// the service ports http and https both go to the application container, since the mesh provides tls
+	port := 80
+	if portNumber, err := strconv.Atoi(servicePort); err == nil {
+		port = portNumber
+	}
+	for _, ap := range data.AdditionalServicePorts {
+		if ap.Name == servicePort {
+			port = ap.Port
+		}
+	}
+
+	if serviceName != data.Name || !data.Mesh.UseSubsets {
+		httpRoute.Route = []HTTPRouteDestination{
+			{Destination: Destination{Host: serviceName, Port: PortSelector{Number: port}}},
+		}
+		return httpRoute
+	}
+
+	stableWeight := 100 - data.Mesh.CanaryWeight
+	canaryWeight := data.Mesh.CanaryWeight
+	httpRoute.Route = []HTTPRouteDestination{
+		{Destination: Destination{Host: serviceName, Subset: "stable", Port: PortSelector{Number: port}}, Weight: &stableWeight},
+		{Destination: Destination{Host: serviceName, Subset: "canary", Port: PortSelector{Number: port}}, Weight: &canaryWeight},
+	}
+
+	return httpRoute
+}
+
+// buildDestinationRule defines the stable and canary subsets by track label for canary releases
+func buildDestinationRule(data TemplateData) DestinationRule {
+
+	destinationRule := DestinationRule{
+		TypeMeta: TypeMeta{APIVersion: "networking.istio.io/v1beta1", Kind: "DestinationRule"},
+		Metadata: buildObjectMeta(data.Name, data),
+		Spec: DestinationRuleSpec{
+			Host: data.Name,
+		},
+	}
+
+	if data.Mesh.UseSubsets {
+		destinationRule.Spec.Subsets = []Subset{
+			{Name: "stable", Labels: map[string]string{"track": "stable"}},
+			{Name: "canary", Labels: map[string]string{"track": "canary"}},
+		}
+	}
+
+	return destinationRule
+}
+
+func buildNginxIngressAnnotations(data TemplateData) map[string]string {
+	annotations := map[string]string{
+		"nginx.ingress.kubernetes.io/backend-protocol":        data.NginxIngressBackendProtocol,
+		"nginx.ingress.kubernetes.io/client-body-buffer-size": data.NginxIngressClientBodyBufferSize,
+		"nginx.ingress.kubernetes.io/proxy-body-size":         data.NginxIngressProxyBodySize,
+		"nginx.ingress.kubernetes.io/proxy-buffers-number":    data.NginxIngressProxyBuffersNumber,
+		"nginx.ingress.kubernetes.io/proxy-buffer-size":       data.NginxIngressProxyBufferSize,
+		"nginx.ingress.kubernetes.io/proxy-connect-timeout":   strconv.Itoa(data.NginxIngressProxyConnectTimeout),
+		"nginx.ingress.kubernetes.io/proxy-send-timeout":      strconv.Itoa(data.NginxIngressProxySendTimeout),
+		"nginx.ingress.kubernetes.io/proxy-read-timeout":      strconv.Itoa(data.NginxIngressProxyReadTimeout),
+	}
+	for k, v := range data.NginxIngressFeatureAnnotations {
+		annotations[k] = v
+	}
+	return annotations
+}
+
+func buildBackendConfig(data TemplateData) BackendConfig {
+	return BackendConfig{
+		TypeMeta: TypeMeta{APIVersion: "cloud.google.com/v1beta1", Kind: "BackendConfig"},
+		Metadata: buildObjectMeta(data.Name, data),
+		Spec: BackendConfigSpec{
+			IAP: &IAPConfig{
+				Enabled: true,
+				OAuthClientCredentials: OAuthClientCredentials{
+					SecretName: fmt.Sprintf("%v-iap-oauth-credentials", data.Name),
+				},
+			},
+		},
+	}
+}
+
+func buildIAPOauthCredentialsSecret(data TemplateData) Secret {
+	return Secret{
+		TypeMeta: TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		Metadata: buildObjectMeta(fmt.Sprintf("%v-iap-oauth-credentials", data.Name), data),
+		Type:     "Opaque",
+		Data: map[string]string{
+			"client_id":     data.IapOauthCredentialsClientID,
+			"client_secret": data.IapOauthCredentialsClientSecret,
+		},
+	}
+}
+
+func buildApplicationSecrets(data TemplateData) Secret {
+
+	secret := Secret{
+		TypeMeta: TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		Metadata: buildObjectMeta(fmt.Sprintf("%v-secrets", data.NameWithTrack), data),
+		Type:     "Opaque",
+		Data:     map[string]string{},
+	}
+
+	for key, value := range data.Secrets {
+		secret.Data[key] = fmt.Sprint(value)
+	}
+
+	return secret
+}
+
+func buildServiceAccountSecret(data TemplateData) Secret {
+
+	secret := Secret{
+		TypeMeta: TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		Metadata: buildObjectMeta(fmt.Sprintf("%v-gcp-service-account", data.GoogleCloudCredentialsAppName), data),
+		Type:     "Opaque",
+	}
+
+	secret.Metadata.Labels = copyLabels(data.Labels, map[string]string{"type": "gcp-service-account"})
+	secret.Metadata.Annotations = map[string]string{
+		"estafette.io/gcp-service-account":      "true",
+		"estafette.io/gcp-service-account-name": data.GoogleCloudCredentialsAppName,
+	}
+	if data.DisableServiceAccountKeyRotation {
+		secret.Metadata.Annotations["estafette.io/gcp-service-account-disable-key-rotation"] = "true"
+	}
+
+	return secret
+}
+
+func buildConfigMap(data TemplateData) ConfigMap {
+	return ConfigMap{
+		TypeMeta: TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		Metadata: buildObjectMeta(fmt.Sprintf("%v-configs", data.NameWithTrack), data),
+		Data:     data.ConfigmapFiles,
+	}
+}
+
+func buildObjectMeta(name string, data TemplateData) ObjectMeta {
+	return ObjectMeta{
+		Name:      name,
+		Namespace: data.Namespace,
+		Labels:    copyLabels(data.Labels, nil),
+	}
+}
+
+// copyLabels returns a copy of labels with the additional labels added to it, so the labels in the template data are never changed
+func copyLabels(labels map[string]string, additionalLabels map[string]string) map[string]string {
+	copiedLabels := make(map[string]string, len(labels)+len(additionalLabels))
+	for k, v := range labels {
+		copiedLabels[k] = v
+	}
+	for k, v := range additionalLabels {
+		copiedLabels[k] = v
+	}
+	return copiedLabels
+}
+
+func buildSelectorLabels(data TemplateData) map[string]string {
+	labels := map[string]string{
+		"app": data.AppLabelSelector,
+	}
+	if data.IncludeTrackLabel {
+		labels["track"] = data.TrackLabel
+	}
+	return labels
+}
+
+func buildPodTemplateMetadata(data TemplateData, includeTrackLabel bool) ObjectMeta {
+
+	metadata := ObjectMeta{
+		Labels: copyLabels(data.Labels, map[string]string{"version": data.BuildVersion}),
+		Annotations: map[string]string{
+			"prometheus.io/scrape":               strconv.FormatBool(data.Container.Metrics.Scrape),
+			"prometheus.io/path":                 data.Container.Metrics.Path,
+			"prometheus.io/port":                 strconv.Itoa(data.Container.Metrics.Port),
+			"prometheus.io/scrape-nginx-sidecar": "true",
+		},
+	}
+
+	if data.IncludeReleaseIDLabel {
+		metadata.Labels["release-id"] = data.ReleaseIDLabel
+	}
+	if data.IncludeTriggeredByLabel {
+		metadata.Labels["triggered-by"] = data.TriggeredByLabel
+	}
+	if includeTrackLabel {
+		metadata.Labels["track"] = data.TrackLabel
+	}
+
+	return metadata
+}
+
+// applyScheduling sets the node selection, affinity, tolerations, topology spread and priority shared by deployments and jobs
+func applyScheduling(spec *PodSpec, data TemplateData) {
+	spec.PriorityClassName = data.PriorityClassName
+	spec.NodeSelector = data.NodeSelector
+	spec.Affinity = buildAffinity(data)
+	spec.SecurityContext = buildPodSecurityContext(data.PodSecurityContext)
+
+	for _, t := range data.Tolerations {
+		spec.Tolerations = append(spec.Tolerations, Toleration{
+			Key:               t.Key,
+			Operator:          t.Operator,
+			Value:             t.Value,
+			Effect:            t.Effect,
+			TolerationSeconds: t.TolerationSeconds,
+		})
+	}
+
+	for _, tsc := range data.TopologySpreadConstraints {
+		spec.TopologySpreadConstraints = append(spec.TopologySpreadConstraints, TopologySpreadConstraint{
+			MaxSkew:           tsc.MaxSkew,
+			TopologyKey:       tsc.TopologyKey,
+			WhenUnsatisfiable: tsc.WhenUnsatisfiable,
+			LabelSelector: LabelSelector{
+				MatchLabels: buildSelectorLabels(data),
+			},
+		})
+	}
+}
+
+func buildAffinity(data TemplateData) *Affinity {
+
+	affinity := &Affinity{}
+
+	antiAffinityTerm := PodAffinityTerm{
+		LabelSelector: LabelSelector{
+			MatchExpressions: []LabelSelectorRequirement{
+				{Key: "app", Operator: "In", Values: []string{data.Name}},
+			},
+		},
+		TopologyKey: data.AntiAffinityTopologyKey,
+	}
+
+	switch data.AntiAffinityType {
+	case "preferred":
+		affinity.PodAntiAffinity = &PodAntiAffinity{
+			PreferredDuringSchedulingIgnoredDuringExecution: []WeightedPodAffinityTerm{
+				{
+					Weight:          data.AntiAffinityWeight,
+					PodAffinityTerm: antiAffinityTerm,
+				},
+			},
+		}
+	case "required":
+		affinity.PodAntiAffinity = &PodAntiAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: []PodAffinityTerm{antiAffinityTerm},
+		}
+	}
+
+	if data.PreferPreemptibles {
+		affinity.NodeAffinity = &NodeAffinity{
+			PreferredDuringSchedulingIgnoredDuringExecution: []PreferredSchedulingTerm{
+				{
+					Weight: 10,
+					Preference: NodeSelectorTerm{
+						MatchExpressions: []NodeSelectorRequirement{
+							{Key: "cloud.google.com/gke-preemptible", Operator: "In", Values: []string{"true"}},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	if affinity.PodAntiAffinity == nil && affinity.NodeAffinity == nil {
+		return nil
+	}
+
+	return affinity
+}
+
+// buildApplicationContainer returns the application container with the properties shared by deployments and jobs
+func buildApplicationContainer(data TemplateData, mountPayloadLogging bool) Container {
+
+	container := Container{
+		Name:            data.Name,
+		Image:           fmt.Sprintf("%v/%v:%v", data.Container.Repository, data.Container.Name, data.Container.Tag),
+		ImagePullPolicy: "IfNotPresent",
+		Command:         data.Container.Command,
+		Args:            data.Container.Args,
+		Env:             buildEnvVars([]EnvVar{jaegerAgentHostEnvVar()}, data.Container.EnvironmentVariables),
+		Resources:       buildResourceRequirements(data.Container.CPURequest, data.Container.MemoryRequest, data.Container.CPULimit, data.Container.MemoryLimit),
+		SecurityContext: buildSecurityContext(data.Container.SecurityContext),
+	}
+
+	container.VolumeMounts = buildApplicationVolumeMounts(data, mountPayloadLogging)
+
+	return container
+}
+
+// buildApplicationVolumeMounts returns the mounts of the application secrets, configs and volumes, shared by the application and init containers
+func buildApplicationVolumeMounts(data TemplateData, mountPayloadLogging bool) []VolumeMount {
+
+	volumeMounts := []VolumeMount{}
+
+	if data.MountApplicationSecrets {
+		volumeMounts = append(volumeMounts, VolumeMount{Name: "app-secrets", MountPath: data.SecretMountPath})
+	}
+	if data.MountConfigmap {
+		volumeMounts = append(volumeMounts, VolumeMount{Name: "app-configs", MountPath: data.ConfigMountPath})
+	}
+	if data.MountServiceAccountSecret {
+		volumeMounts = append(volumeMounts, VolumeMount{Name: "gcp-service-account", MountPath: "/gcp-service-account"})
+	}
+	if mountPayloadLogging && data.MountPayloadLogging {
+		volumeMounts = append(volumeMounts, VolumeMount{Name: "pod-log", MountPath: "/var/log/travix"})
+	}
+	for _, vm := range data.AdditionalVolumeMounts {
+		volumeMounts = append(volumeMounts, VolumeMount{Name: vm.Name, MountPath: vm.MountPath})
+	}
+
+	return volumeMounts
+}
+
+// buildInitContainers returns the user-defined init containers, which run in order before the application container
+func buildInitContainers(data TemplateData) []Container {
+
+	initContainers := []Container{}
+
+	for _, ic := range data.InitContainers {
+		initContainers = append(initContainers, Container{
+			Name:            ic.Name,
+			Image:           ic.Image,
+			ImagePullPolicy: "IfNotPresent",
+			Command:         ic.Command,
+			Args:            ic.Args,
+			Env:             buildEnvVars(nil, ic.EnvironmentVariables),
+			Resources:       buildResourceRequirements(ic.CPURequest, ic.MemoryRequest, ic.CPULimit, ic.MemoryLimit),
+			VolumeMounts:    buildApplicationVolumeMounts(data, false),
+			SecurityContext: buildSecurityContext(ic.SecurityContext),
+		})
+	}
+
+	return initContainers
+}
+
+// buildApplicationVolumes returns the volumes for the mounts of the application container; secretsName is the name the application
+// secrets and configmap are prefixed with
+func buildApplicationVolumes(data TemplateData, secretsName string, mountPayloadLogging bool) []Volume {
+
+	volumes := []Volume{}
+
+	if data.MountApplicationSecrets {
+		volumes = append(volumes, Volume{Name: "app-secrets", Secret: &SecretVolumeSource{SecretName: fmt.Sprintf("%v-secrets", secretsName)}})
+	}
+	if data.MountConfigmap {
+		volumes = append(volumes, Volume{Name: "app-configs", ConfigMap: &ConfigMapVolumeSource{Name: fmt.Sprintf("%v-configs", secretsName)}})
+	}
+	if data.MountServiceAccountSecret {
+		volumes = append(volumes, Volume{Name: "gcp-service-account", Secret: &SecretVolumeSource{SecretName: fmt.Sprintf("%v-gcp-service-account", data.GoogleCloudCredentialsAppName)}})
+	}
+	if mountPayloadLogging && data.MountPayloadLogging {
+		volumes = append(volumes,
+			Volume{Name: "pod-log", HostPath: &HostPathVolumeSource{Path: fmt.Sprintf("/var/log/fluentd-payload-logger/%v", data.Name)}},
+			Volume{Name: "var-log", HostPath: &HostPathVolumeSource{Path: "/var/log"}},
+		)
+	}
+	for _, vm := range data.AdditionalVolumeMounts {
+		volumes = append(volumes, Volume{Name: vm.Name, Source: vm.Volume})
+	}
+
+	return volumes
+}
+
+func buildOpenrestySidecar(data TemplateData, sidecar SidecarData) Container {
+	return Container{
+		Name:            fmt.Sprintf("%v-openresty", data.Name),
+		Image:           sidecar.Image,
+		ImagePullPolicy: "IfNotPresent",
+		Resources:       buildResourceRequirements(sidecar.CPURequest, sidecar.MemoryRequest, sidecar.CPULimit, sidecar.MemoryLimit),
+		Ports: []ContainerPort{
+			{Name: "http", ContainerPort: 80},
+			{Name: "https", ContainerPort: 443},
+			{Name: "nginx-liveness", ContainerPort: 82},
+			{Name: "nginx-readiness", ContainerPort: 81},
+			{Name: "nginx-prom", ContainerPort: 9101},
+		},
+		Env: buildEnvVars([]EnvVar{
+			jaegerAgentHostEnvVar(),
+			{Name: "OFFLOAD_TO_HOST", Value: "localhost"},
+			{Name: "OFFLOAD_TO_PORT", Value: strconv.Itoa(data.Container.Port)},
+			{Name: "SERVICE_NAME", Value: data.Name},
+			{Name: "NAMESPACE", Value: data.Namespace},
+			{Name: "HEALT_CHECK_PATH", Value: fmt.Sprint(sidecar.SidecarSpecificProperties["healthcheckpath"])},
+		}, sidecar.EnvironmentVariables),
+		VolumeMounts: []VolumeMount{
+			{Name: "ssl-certificate", MountPath: "/etc/ssl/private"},
+		},
+		LivenessProbe: &Probe{
+			HTTPGet: &HTTPGetAction{
+				Path: "/liveness",
+				Port: "nginx-liveness",
+			},
+			InitialDelaySeconds: 15,
+		},
+		ReadinessProbe:  buildOpenrestyReadinessProbe(data.Container.Readiness),
+		SecurityContext: buildSecurityContext(sidecar.SecurityContext),
+	}
+}
+
+// buildOpenrestyReadinessProbe returns the readiness probe of the openresty sidecar, which proxies an http readiness probe to the
+// application container; other types of readiness probes are set on the application container, so the sidecar only checks it's listening
+func buildOpenrestyReadinessProbe(readiness ProbeData) *Probe {
+
+	probe := buildProbe(readiness)
+	probe.HTTPGet, probe.TCPSocket, probe.Exec, probe.GRPC = nil, nil, nil, nil
+
+	switch readiness.Type {
+	case "", "http":
+		probe.HTTPGet = &HTTPGetAction{
+			Path:   readiness.Path,
+			Port:   "https",
+			Scheme: "HTTPS",
+		}
+	default:
+		probe.TCPSocket = &TCPSocketAction{Port: "https"}
+	}
+
+	return probe
+}
+
+// buildProbe returns a probe with the action for the type of probe
+func buildProbe(probe ProbeData) *Probe {
+
+	builtProbe := &Probe{
+		InitialDelaySeconds: probe.InitialDelaySeconds,
+		TimeoutSeconds:      probe.TimeoutSeconds,
+		PeriodSeconds:       probe.PeriodSeconds,
+		SuccessThreshold:    probe.SuccessThreshold,
+		FailureThreshold:    probe.FailureThreshold,
+	}
+
+	switch probe.Type {
+	case "tcp":
+		builtProbe.TCPSocket = &TCPSocketAction{Port: IntOrString(strconv.Itoa(probe.Port))}
+	case "exec":
+		builtProbe.Exec = &ExecAction{Command: probe.Command}
+	case "grpc":
+		builtProbe.GRPC = &GRPCAction{Port: probe.Port, Service: probe.Service}
+	default:
+		builtProbe.HTTPGet = &HTTPGetAction{
+			Path:   probe.Path,
+			Port:   IntOrString(strconv.Itoa(probe.Port)),
+			Scheme: probe.Scheme,
+		}
+	}
+
+	return builtProbe
+}
+
+func buildCloudSQLProxySidecar(data TemplateData, sidecar SidecarData) Container {
+
+	container := Container{
+		Name:      fmt.Sprintf("%v-cloudsql-proxy", data.Name),
+		Image:     sidecar.Image,
+		Resources: buildResourceRequirements(sidecar.CPURequest, sidecar.MemoryRequest, sidecar.CPULimit, sidecar.MemoryLimit),
+		Command: []string{
+			"/cloud_sql_proxy",
+			fmt.Sprintf("-instances=%v=tcp:%v", sidecar.SidecarSpecificProperties["dbinstanceconnectionname"], sidecar.SidecarSpecificProperties["sqlproxyport"]),
+			"-credential_file=/gcp-service-account/service-account-key.json",
+			fmt.Sprintf("-term_timeout=%vs", sidecar.SidecarSpecificProperties["sqlproxyterminationtimeoutseconds"]),
+		},
+		Env:             buildEnvVars(nil, sidecar.EnvironmentVariables),
+		SecurityContext: buildSecurityContext(sidecar.SecurityContext),
+	}
+
+	if data.MountServiceAccountSecret {
+		container.VolumeMounts = []VolumeMount{
+			{Name: "gcp-service-account", MountPath: "/gcp-service-account"},
+		}
+	}
+
+	return container
+}
+
+// buildPodSecurityContext returns nil if nothing is set, so the image and cluster defaults apply
+func buildPodSecurityContext(data PodSecurityContextData) *PodSecurityContext {
+
+	securityContext := &PodSecurityContext{
+		RunAsNonRoot: data.RunAsNonRoot,
+		RunAsUser:    data.RunAsUser,
+		RunAsGroup:   data.RunAsGroup,
+		FSGroup:      data.FSGroup,
+	}
+	if data.SeccompProfile != "" {
+		securityContext.SeccompProfile = &SeccompProfile{Type: data.SeccompProfile}
+	}
+
+	if *securityContext == (PodSecurityContext{}) {
+		return nil
+	}
+
+	return securityContext
+}
+
+// buildSecurityContext returns nil if nothing is set, so the pod security context applies
+func buildSecurityContext(data ContainerSecurityContextData) *SecurityContext {
+
+	securityContext := &SecurityContext{
+		RunAsNonRoot:             data.RunAsNonRoot,
+		RunAsUser:                data.RunAsUser,
+		RunAsGroup:               data.RunAsGroup,
+		ReadOnlyRootFilesystem:   data.ReadOnlyRootFilesystem,
+		AllowPrivilegeEscalation: data.AllowPrivilegeEscalation,
+	}
+	if len(data.AddCapabilities) > 0 || len(data.DropCapabilities) > 0 {
+		securityContext.Capabilities = &Capabilities{
+			Add:  data.AddCapabilities,
+			Drop: data.DropCapabilities,
+		}
+	}
+
+	if *securityContext == (SecurityContext{}) {
+		return nil
+	}
+
+	return securityContext
+}
+
+func jaegerAgentHostEnvVar() EnvVar {
+	return EnvVar{
+		Name: "JAEGER_AGENT_HOST",
+		ValueFrom: map[string]interface{}{
+			"fieldRef": map[string]interface{}{
+				"fieldPath": "status.hostIP",
+			},
+		},
+	}
+}
+
+// buildEnvVars appends the environment variables sorted by name to the fixed ones; values with a source are set as valueFrom
+func buildEnvVars(envVars []EnvVar, environmentVariables map[string]interface{}) []EnvVar {
+
+	names := make([]string, 0, len(environmentVariables))
+	for name := range environmentVariables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if source, ok := environmentVariables[name].(EnvironmentVariableSourceData); ok {
+			envVars = append(envVars, EnvVar{Name: name, ValueFrom: source.ValueFrom})
+			continue
+		}
+		envVars = append(envVars, EnvVar{Name: name, Value: fmt.Sprint(environmentVariables[name])})
+	}
+
+	return envVars
+}
+
+func buildResourceRequirements(cpuRequest, memoryRequest, cpuLimit, memoryLimit string) ResourceRequirements {
+	return ResourceRequirements{
+		Requests: ResourceList{CPU: cpuRequest, Memory: memoryRequest},
+		Limits:   ResourceList{CPU: cpuLimit, Memory: memoryLimit},
+	}
+}
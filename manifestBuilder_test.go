@@ -0,0 +1,1556 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestBuildManifest(t *testing.T) {
+
+	t.Run("BuildsNamespace", func(t *testing.T) {
+
+		data := TemplateData{
+			Namespace: "mynamespace",
+		}
+
+		// act
+		object, ok := buildManifest("namespace.yaml", data)
+
+		assert.True(t, ok)
+		manifest, err := yaml.Marshal(object)
+		assert.Nil(t, err)
+		assert.Equal(t, "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: mynamespace\n", string(manifest))
+	})
+
+	t.Run("BuildsServiceAccount", func(t *testing.T) {
+
+		data := TemplateData{
+			Name:      "myapp",
+			Namespace: "mynamespace",
+			Labels: map[string]string{
+				"app":  "myapp",
+				"team": "myteam",
+			},
+		}
+
+		// act
+		object, ok := buildManifest("serviceaccount.yaml", data)
+
+		assert.True(t, ok)
+		manifest, err := yaml.Marshal(object)
+		assert.Nil(t, err)
+		assert.Equal(t, "apiVersion: v1\nkind: ServiceAccount\nmetadata:\n  name: myapp\n  namespace: mynamespace\n  labels:\n    app: myapp\n    team: myteam\n", string(manifest))
+	})
+
+	t.Run("BuildsHorizontalPodAutoscaler", func(t *testing.T) {
+
+		data := TemplateData{
+			Name:                              "myapp",
+			NameWithTrack:                     "myapp-canary",
+			Namespace:                         "mynamespace",
+			HorizontalPodAutoscalerAPIVersion: "autoscaling/v2",
+			Labels: map[string]string{
+				"app":  "myapp",
+				"team": "myteam",
+			},
+			MinReplicas:         3,
+			MaxReplicas:         19,
+			TargetCPUPercentage: 65,
+		}
+
+		// act
+		object, ok := buildManifest("horizontalpodautoscaler.yaml", data)
+
+		assert.True(t, ok)
+		manifest, err := yaml.Marshal(object)
+		assert.Nil(t, err)
+		assert.Equal(t, "apiVersion: autoscaling/v2\nkind: HorizontalPodAutoscaler\nmetadata:\n  name: myapp-canary\n  namespace: mynamespace\n  labels:\n    app: myapp\n    team: myteam\nspec:\n  scaleTargetRef:\n    apiVersion: apps/v1\n    kind: Deployment\n    name: myapp-canary\n  minReplicas: 3\n  maxReplicas: 19\n  metrics:\n  - type: Resource\n    resource:\n      name: cpu\n      target:\n        type: Utilization\n        averageUtilization: 65\n", string(manifest))
+	})
+
+	t.Run("ReturnsFalseForUnknownManifest", func(t *testing.T) {
+
+		// act
+		_, ok := buildManifest("another-ingress.yaml", TemplateData{})
+
+		assert.False(t, ok)
+	})
+}
+
+func TestBuildService(t *testing.T) {
+
+	t.Run("SetsAppProtocolForHttpsPort", func(t *testing.T) {
+
+		data := TemplateData{
+			Name:               "myapp",
+			AppLabelSelector:   "myapp",
+			ServiceType:        "NodePort",
+			ServiceAppProtocol: "HTTP2",
+		}
+
+		// act
+		service := buildService(data)
+
+		assert.Equal(t, `{"https":"HTTP2"}`, service.Metadata.Annotations["service.alpha.kubernetes.io/app-protocols"])
+	})
+
+	t.Run("SetsPublicDNSAnnotationsIfUsedOnService", func(t *testing.T) {
+
+		data := TemplateData{
+			Name:                       "myapp",
+			ServiceType:                "LoadBalancer",
+			UseDNSAnnotationsOnService: true,
+			PublicDNSAnnotations:       map[string]string{"external-dns.alpha.kubernetes.io/hostname": "myapp.example.com"},
+		}
+
+		// act
+		service := buildService(data)
+
+		assert.Equal(t, "myapp.example.com", service.Metadata.Annotations["external-dns.alpha.kubernetes.io/hostname"])
+		assert.NotContains(t, service.Metadata.Annotations, "estafette.io/cloudflare-dns")
+	})
+
+	t.Run("RoutesStraightToApplicationContainerIfUsingIstio", func(t *testing.T) {
+
+		data := TemplateData{
+			Name:        "myapp",
+			ServiceType: "ClusterIP",
+			UseIstio:    true,
+			Mesh:        MeshData{ServicePortName: "grpc"},
+		}
+
+		// act
+		service := buildService(data)
+
+		assert.Equal(t, []ServicePort{{Name: "grpc", Port: 80, TargetPort: "web", Protocol: "TCP"}}, service.Spec.Ports)
+	})
+}
+
+func TestBuildDeployment(t *testing.T) {
+
+	t.Run("SetsRollingUpdateAsNumberOrPercentage", func(t *testing.T) {
+
+		data := TemplateData{
+			RollingUpdateMaxSurge:       "25%",
+			RollingUpdateMaxUnavailable: "0",
+		}
+
+		// act
+		deployment := buildDeployment(data)
+
+		manifest, err := yaml.Marshal(deployment.Spec.Strategy)
+		assert.Nil(t, err)
+		assert.Equal(t, "type: RollingUpdate\nrollingUpdate:\n  maxSurge: 25%\n  maxUnavailable: 0\n", string(manifest))
+	})
+
+	t.Run("KeepsEnvironmentVariableValuesWithQuotesColonsAndNewlinesIntact", func(t *testing.T) {
+
+		data := TemplateData{
+			Container: ContainerData{
+				EnvironmentVariables: map[string]interface{}{
+					"MY_CUSTOM_ENV": "value: \"quoted\"\nsecond line",
+				},
+			},
+		}
+
+		// act
+		deployment := buildDeployment(data)
+
+		manifest, err := yaml.Marshal(deployment)
+		assert.Nil(t, err)
+		var unmarshalledDeployment struct {
+			Spec struct {
+				Template struct {
+					Spec struct {
+						Containers []struct {
+							Env []struct {
+								Name  string `yaml:"name"`
+								Value string `yaml:"value"`
+							} `yaml:"env"`
+						} `yaml:"containers"`
+					} `yaml:"spec"`
+				} `yaml:"template"`
+			} `yaml:"spec"`
+		}
+		err = yaml.Unmarshal(manifest, &unmarshalledDeployment)
+		assert.Nil(t, err)
+		env := unmarshalledDeployment.Spec.Template.Spec.Containers[0].Env
+		assert.Equal(t, 2, len(env))
+		assert.Equal(t, "MY_CUSTOM_ENV", env[1].Name)
+		assert.Equal(t, "value: \"quoted\"\nsecond line", env[1].Value)
+	})
+
+	t.Run("AddsOpenrestyAndCloudSQLProxySidecarsAfterApplicationContainer", func(t *testing.T) {
+
+		data := TemplateData{
+			Name: "myapp",
+			Sidecars: []SidecarData{
+				{
+					Type: "openresty",
+				},
+				{
+					Type: "cloudsqlproxy",
+					SidecarSpecificProperties: map[string]interface{}{
+						"dbinstanceconnectionname":          "project:region:db",
+						"sqlproxyport":                      5043,
+						"sqlproxyterminationtimeoutseconds": 30,
+					},
+				},
+			},
+		}
+
+		// act
+		deployment := buildDeployment(data)
+
+		containers := deployment.Spec.Template.Spec.Containers
+		assert.Equal(t, 3, len(containers))
+		assert.Equal(t, "myapp", containers[0].Name)
+		assert.Equal(t, "myapp-openresty", containers[1].Name)
+		assert.Equal(t, "myapp-cloudsql-proxy", containers[2].Name)
+		assert.Equal(t, []string{"/cloud_sql_proxy", "-instances=project:region:db=tcp:5043", "-credential_file=/gcp-service-account/service-account-key.json", "-term_timeout=30s"}, containers[2].Command)
+	})
+
+	t.Run("AddsAdditionalVolumesAsIs", func(t *testing.T) {
+
+		data := TemplateData{
+			Name: "myapp",
+			AdditionalVolumeMounts: []VolumeMountData{
+				{
+					Name:      "client-certs",
+					MountPath: "/client-certs",
+					Volume: map[string]interface{}{
+						"secret": map[string]interface{}{
+							"secretName": "client-certs",
+						},
+					},
+				},
+			},
+		}
+
+		// act
+		deployment := buildDeployment(data)
+
+		manifest, err := yaml.Marshal(deployment.Spec.Template.Spec.Volumes)
+		assert.Nil(t, err)
+		assert.Equal(t, "- name: ssl-certificate\n  secret:\n    secretName: myapp-letsencrypt-certificate\n- name: client-certs\n  secret:\n    secretName: client-certs\n", string(manifest))
+	})
+
+	t.Run("MapsTLSSecretKeysToOpenrestyCertificateFiles", func(t *testing.T) {
+
+		data := TemplateData{
+			Name:                        "myapp",
+			CertificateSecretName:       "myapp-certificate",
+			CertificateSecretHasTLSKeys: true,
+		}
+
+		// act
+		deployment := buildDeployment(data)
+
+		manifest, err := yaml.Marshal(deployment.Spec.Template.Spec.Volumes)
+		assert.Nil(t, err)
+		assert.Equal(t, "- name: ssl-certificate\n  secret:\n    secretName: myapp-certificate\n    items:\n    - key: tls.crt\n      path: ssl.pem\n    - key: tls.key\n      path: ssl.key\n", string(manifest))
+	})
+
+	t.Run("SetsProbeActionForProbeType", func(t *testing.T) {
+
+		data := TemplateData{
+			Container: ContainerData{
+				Liveness:  ProbeData{Type: "tcp", Port: 5000, PeriodSeconds: 10, FailureThreshold: 3},
+				Readiness: ProbeData{Type: "grpc", Port: 5001, Service: "health", SuccessThreshold: 2, IncludeOnContainer: true},
+				Startup:   ProbeData{Type: "exec", Command: []string{"cat", "/tmp/started"}, FailureThreshold: 30, IncludeOnContainer: true},
+			},
+		}
+
+		// act
+		deployment := buildDeployment(data)
+
+		container := deployment.Spec.Template.Spec.Containers[0]
+		assert.Equal(t, &Probe{TCPSocket: &TCPSocketAction{Port: "5000"}, PeriodSeconds: 10, FailureThreshold: 3}, container.LivenessProbe)
+		assert.Equal(t, &Probe{GRPC: &GRPCAction{Port: 5001, Service: "health"}, SuccessThreshold: 2}, container.ReadinessProbe)
+		assert.Equal(t, &Probe{Exec: &ExecAction{Command: []string{"cat", "/tmp/started"}}, FailureThreshold: 30}, container.StartupProbe)
+	})
+
+	t.Run("ProxiesHttpReadinessProbeViaOpenrestySidecar", func(t *testing.T) {
+
+		data := TemplateData{
+			Name: "myapp",
+			Container: ContainerData{
+				Readiness: ProbeData{Type: "http", Path: "/readiness", Port: 5000, TimeoutSeconds: 1, PeriodSeconds: 5},
+			},
+			Sidecars: []SidecarData{
+				{Type: "openresty"},
+			},
+		}
+
+		// act
+		deployment := buildDeployment(data)
+
+		containers := deployment.Spec.Template.Spec.Containers
+		assert.Nil(t, containers[0].ReadinessProbe)
+		assert.Equal(t, &Probe{HTTPGet: &HTTPGetAction{Path: "/readiness", Port: "https", Scheme: "HTTPS"}, TimeoutSeconds: 1, PeriodSeconds: 5}, containers[1].ReadinessProbe)
+	})
+
+	t.Run("ChecksOpenrestySidecarIsListeningIfReadinessProbeIsNotHttp", func(t *testing.T) {
+
+		data := TemplateData{
+			Name: "myapp",
+			Container: ContainerData{
+				Readiness: ProbeData{Type: "tcp", Port: 5000, TimeoutSeconds: 1, IncludeOnContainer: true},
+			},
+			Sidecars: []SidecarData{
+				{Type: "openresty"},
+			},
+		}
+
+		// act
+		deployment := buildDeployment(data)
+
+		containers := deployment.Spec.Template.Spec.Containers
+		assert.Equal(t, &Probe{TCPSocket: &TCPSocketAction{Port: "5000"}, TimeoutSeconds: 1}, containers[0].ReadinessProbe)
+		assert.Equal(t, &Probe{TCPSocket: &TCPSocketAction{Port: "https"}, TimeoutSeconds: 1}, containers[1].ReadinessProbe)
+	})
+
+	t.Run("SetsRequiredAntiAffinityNodeSelectorTolerationsTopologySpreadAndPriorityClass", func(t *testing.T) {
+
+		tolerationSeconds := 60
+		data := TemplateData{
+			Name:                    "myapp",
+			AppLabelSelector:        "myapp",
+			NodeSelector:            map[string]string{"cloud.google.com/gke-nodepool": "dedicated"},
+			AntiAffinityType:        "required",
+			AntiAffinityTopologyKey: "kubernetes.io/hostname",
+			PriorityClassName:       "high-priority",
+			Tolerations: []TolerationData{
+				{Key: "dedicated", Operator: "Equal", Value: "myapp", Effect: "NoExecute", TolerationSeconds: &tolerationSeconds},
+			},
+			TopologySpreadConstraints: []TopologySpreadConstraintData{
+				{TopologyKey: "topology.kubernetes.io/zone", MaxSkew: 1, WhenUnsatisfiable: "ScheduleAnyway"},
+			},
+		}
+
+		// act
+		deployment := buildDeployment(data)
+
+		manifest, err := yaml.Marshal(deployment.Spec.Template.Spec)
+		assert.Nil(t, err)
+		assert.Contains(t, string(manifest), `priorityClassName: high-priority
+nodeSelector:
+  cloud.google.com/gke-nodepool: dedicated
+affinity:
+  podAntiAffinity:
+    requiredDuringSchedulingIgnoredDuringExecution:
+    - labelSelector:
+        matchExpressions:
+        - key: app
+          operator: In
+          values:
+          - myapp
+      topologyKey: kubernetes.io/hostname
+tolerations:
+- key: dedicated
+  operator: Equal
+  value: myapp
+  effect: NoExecute
+  tolerationSeconds: 60
+topologySpreadConstraints:
+- maxSkew: 1
+  topologyKey: topology.kubernetes.io/zone
+  whenUnsatisfiable: ScheduleAnyway
+  labelSelector:
+    matchLabels:
+      app: myapp
+`)
+	})
+
+	t.Run("SetsPreferredAntiAffinityWithWeightAndPreemptibleNodeAffinity", func(t *testing.T) {
+
+		data := TemplateData{
+			Name:                    "myapp",
+			AntiAffinityType:        "preferred",
+			AntiAffinityTopologyKey: "topology.kubernetes.io/zone",
+			AntiAffinityWeight:      50,
+			PreferPreemptibles:      true,
+		}
+
+		// act
+		deployment := buildDeployment(data)
+
+		affinity := deployment.Spec.Template.Spec.Affinity
+		if assert.NotNil(t, affinity) {
+			assert.Equal(t, 50, affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution[0].Weight)
+			assert.Equal(t, "topology.kubernetes.io/zone", affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution[0].PodAffinityTerm.TopologyKey)
+			assert.Equal(t, 0, len(affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution))
+			assert.Equal(t, 10, affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution[0].Weight)
+		}
+	})
+
+	t.Run("OmitsAffinityIfAntiAffinityIsNoneAndPreemptiblesAreNotPreferred", func(t *testing.T) {
+
+		data := TemplateData{
+			Name:             "myapp",
+			AntiAffinityType: "none",
+		}
+
+		// act
+		deployment := buildDeployment(data)
+
+		assert.Nil(t, deployment.Spec.Template.Spec.Affinity)
+	})
+
+	t.Run("SetsPodAndContainerSecurityContexts", func(t *testing.T) {
+
+		trueValue := true
+		falseValue := false
+		user := 1000
+		data := TemplateData{
+			Name: "myapp",
+			PodSecurityContext: PodSecurityContextData{
+				RunAsNonRoot:   &trueValue,
+				RunAsUser:      &user,
+				SeccompProfile: "RuntimeDefault",
+			},
+			Container: ContainerData{
+				SecurityContext: ContainerSecurityContextData{
+					ReadOnlyRootFilesystem:   &trueValue,
+					AllowPrivilegeEscalation: &falseValue,
+					DropCapabilities:         []string{"ALL"},
+				},
+			},
+			Sidecars: []SidecarData{
+				{
+					Type: "openresty",
+					SecurityContext: ContainerSecurityContextData{
+						AllowPrivilegeEscalation: &falseValue,
+						AddCapabilities:          []string{"NET_BIND_SERVICE"},
+						DropCapabilities:         []string{"ALL"},
+					},
+				},
+				{
+					Type: "cloudsqlproxy",
+				},
+			},
+		}
+
+		// act
+		deployment := buildDeployment(data)
+
+		manifest, err := yaml.Marshal(deployment.Spec.Template.Spec.SecurityContext)
+		assert.Nil(t, err)
+		assert.Equal(t, `runAsNonRoot: true
+runAsUser: 1000
+seccompProfile:
+  type: RuntimeDefault
+`, string(manifest))
+
+		containers := deployment.Spec.Template.Spec.Containers
+		manifest, err = yaml.Marshal(containers[0].SecurityContext)
+		assert.Nil(t, err)
+		assert.Equal(t, `readOnlyRootFilesystem: true
+allowPrivilegeEscalation: false
+capabilities:
+  drop:
+  - ALL
+`, string(manifest))
+		assert.Equal(t, &SecurityContext{AllowPrivilegeEscalation: &falseValue, Capabilities: &Capabilities{Add: []string{"NET_BIND_SERVICE"}, Drop: []string{"ALL"}}}, containers[1].SecurityContext)
+		assert.Nil(t, containers[2].SecurityContext)
+	})
+
+	t.Run("OmitsSecurityContextsIfNothingIsSet", func(t *testing.T) {
+
+		data := TemplateData{
+			Name: "myapp",
+		}
+
+		// act
+		deployment := buildDeployment(data)
+
+		manifest, err := yaml.Marshal(deployment.Spec.Template.Spec)
+		assert.Nil(t, err)
+		assert.NotContains(t, string(manifest), "securityContext")
+	})
+
+	t.Run("SetsCommandArgsAndInitContainersAfterPayloadLoggingInitContainer", func(t *testing.T) {
+
+		data := TemplateData{
+			Name:                "myapp",
+			MountPayloadLogging: true,
+			MountConfigmap:      true,
+			ConfigMountPath:     "/configs",
+			Container: ContainerData{
+				Command: []string{"/app/web"},
+				Args:    []string{"--port", "5000"},
+			},
+			InitContainers: []InitContainerData{
+				{
+					Name:                 "migrate",
+					Image:                "extensions/myapp:1.0.0",
+					Command:              []string{"/app/migrate"},
+					EnvironmentVariables: map[string]interface{}{"DB_HOST": "localhost"},
+					CPURequest:           "100m",
+					CPULimit:             "200m",
+					MemoryRequest:        "64Mi",
+					MemoryLimit:          "128Mi",
+				},
+			},
+		}
+
+		// act
+		deployment := buildDeployment(data)
+
+		podSpec := deployment.Spec.Template.Spec
+		assert.Equal(t, []string{"/app/web"}, podSpec.Containers[0].Command)
+		assert.Equal(t, []string{"--port", "5000"}, podSpec.Containers[0].Args)
+		assert.Equal(t, 2, len(podSpec.InitContainers))
+		assert.Equal(t, "init-myservice", podSpec.InitContainers[0].Name)
+
+		manifest, err := yaml.Marshal(podSpec.InitContainers[1])
+		assert.Nil(t, err)
+		assert.Equal(t, `name: migrate
+image: extensions/myapp:1.0.0
+imagePullPolicy: IfNotPresent
+command:
+- /app/migrate
+env:
+- name: DB_HOST
+  value: localhost
+resources:
+  requests:
+    cpu: 100m
+    memory: 64Mi
+  limits:
+    cpu: 200m
+    memory: 128Mi
+volumeMounts:
+- name: app-configs
+  mountPath: /configs
+`, string(manifest))
+	})
+}
+
+func TestBuildJob(t *testing.T) {
+
+	t.Run("SetsEnvironmentVariableFromSource", func(t *testing.T) {
+
+		data := TemplateData{
+			Name:      "myapp",
+			Namespace: "mynamespace",
+			Container: ContainerData{
+				EnvironmentVariables: map[string]interface{}{
+					"MY_CUSTOM_ENV": "value1",
+					"POD_NAME": EnvironmentVariableSourceData{
+						ValueFrom: map[string]interface{}{
+							"fieldRef": map[string]interface{}{"fieldPath": "metadata.name"},
+						},
+					},
+				},
+			},
+		}
+
+		// act
+		job := buildJob(data)
+
+		env := job.Spec.Template.Spec.Containers[0].Env
+		assert.Equal(t, 3, len(env))
+		assert.Equal(t, EnvVar{Name: "MY_CUSTOM_ENV", Value: "value1"}, env[1])
+		assert.Equal(t, EnvVar{Name: "POD_NAME", ValueFrom: map[string]interface{}{"fieldRef": map[string]interface{}{"fieldPath": "metadata.name"}}}, env[2])
+	})
+
+	t.Run("OmitsVolumesIfNothingIsMounted", func(t *testing.T) {
+
+		data := TemplateData{
+			Name: "myapp",
+		}
+
+		// act
+		job := buildJob(data)
+
+		manifest, err := yaml.Marshal(job.Spec.Template.Spec)
+		assert.Nil(t, err)
+		assert.NotContains(t, string(manifest), "volumes")
+	})
+
+	t.Run("SetsPodAndApplicationContainerSecurityContexts", func(t *testing.T) {
+
+		trueValue := true
+		data := TemplateData{
+			Name: "myapp",
+			PodSecurityContext: PodSecurityContextData{
+				RunAsNonRoot: &trueValue,
+			},
+			Container: ContainerData{
+				SecurityContext: ContainerSecurityContextData{
+					ReadOnlyRootFilesystem: &trueValue,
+				},
+			},
+		}
+
+		// act
+		job := buildJob(data)
+
+		assert.Equal(t, &PodSecurityContext{RunAsNonRoot: &trueValue}, job.Spec.Template.Spec.SecurityContext)
+		assert.Equal(t, &SecurityContext{ReadOnlyRootFilesystem: &trueValue}, job.Spec.Template.Spec.Containers[0].SecurityContext)
+	})
+
+	t.Run("SetsInitContainersAndCommand", func(t *testing.T) {
+
+		data := TemplateData{
+			Name: "myapp",
+			Container: ContainerData{
+				Command: []string{"/app/job"},
+			},
+			InitContainers: []InitContainerData{
+				{Name: "wait", Image: "busybox:1.27.2", Args: []string{"sleep", "5"}},
+			},
+		}
+
+		// act
+		job := buildJob(data)
+
+		assert.Equal(t, []string{"/app/job"}, job.Spec.Template.Spec.Containers[0].Command)
+		assert.Equal(t, 1, len(job.Spec.Template.Spec.InitContainers))
+		assert.Equal(t, []string{"sleep", "5"}, job.Spec.Template.Spec.InitContainers[0].Args)
+	})
+}
+
+func TestBuildCronJob(t *testing.T) {
+
+	t.Run("SetsSchedulingLikeDeployment", func(t *testing.T) {
+
+		data := TemplateData{
+			Name:              "myapp",
+			Schedule:          "*/5 * * * *",
+			NodeSelector:      map[string]string{"cloud.google.com/gke-nodepool": "batch"},
+			PriorityClassName: "low-priority",
+			Tolerations: []TolerationData{
+				{Key: "batch", Operator: "Exists", Effect: "NoSchedule"},
+			},
+		}
+
+		// act
+		cronJob := buildCronJob(data)
+
+		podSpec := cronJob.Spec.JobTemplate.Spec.Template.Spec
+		assert.Equal(t, map[string]string{"cloud.google.com/gke-nodepool": "batch"}, podSpec.NodeSelector)
+		assert.Equal(t, "low-priority", podSpec.PriorityClassName)
+		assert.Equal(t, []Toleration{{Key: "batch", Operator: "Exists", Effect: "NoSchedule"}}, podSpec.Tolerations)
+		assert.Nil(t, podSpec.Affinity)
+	})
+
+	t.Run("UsesCronJobAPIVersion", func(t *testing.T) {
+
+		data := TemplateData{
+			Name:              "myapp",
+			Schedule:          "*/5 * * * *",
+			CronJobAPIVersion: "batch/v1beta1",
+		}
+
+		// act
+		cronJob := buildCronJob(data)
+
+		assert.Equal(t, "batch/v1beta1", cronJob.APIVersion)
+	})
+}
+
+func TestBuildStatefulSet(t *testing.T) {
+
+	t.Run("MountsVolumeClaimTemplatesInApplicationContainer", func(t *testing.T) {
+
+		data := TemplateData{
+			Name:             "myapp",
+			Namespace:        "mynamespace",
+			AppLabelSelector: "myapp",
+			StatefulSet: StatefulSetData{
+				ServiceName:         "myapp-headless",
+				Replicas:            3,
+				PodManagementPolicy: "Parallel",
+				VolumeClaimTemplates: []VolumeClaimTemplateData{
+					{Name: "data", MountPath: "/data", Size: "10Gi", StorageClass: "ssd", AccessModes: []string{"ReadWriteOnce"}},
+				},
+			},
+		}
+
+		// act
+		statefulSet := buildStatefulSet(data)
+
+		assert.Equal(t, "apps/v1", statefulSet.APIVersion)
+		assert.Equal(t, 3, *statefulSet.Spec.Replicas)
+		assert.Equal(t, "myapp-headless", statefulSet.Spec.ServiceName)
+		assert.Equal(t, "Parallel", statefulSet.Spec.PodManagementPolicy)
+		assert.Contains(t, statefulSet.Spec.Template.Spec.Containers[0].VolumeMounts, VolumeMount{Name: "data", MountPath: "/data"})
+		assert.Equal(t, 1, len(statefulSet.Spec.VolumeClaimTemplates))
+		assert.Equal(t, "data", statefulSet.Spec.VolumeClaimTemplates[0].Metadata.Name)
+		assert.Equal(t, "ssd", statefulSet.Spec.VolumeClaimTemplates[0].Spec.StorageClassName)
+		assert.Equal(t, map[string]string{"storage": "10Gi"}, statefulSet.Spec.VolumeClaimTemplates[0].Spec.Resources.Requests)
+	})
+
+	t.Run("SetsPartitionOfRollingUpdate", func(t *testing.T) {
+
+		partition := 2
+		data := TemplateData{
+			Name: "myapp",
+			StatefulSet: StatefulSetData{
+				Replicas:  3,
+				Partition: &partition,
+			},
+		}
+
+		// act
+		statefulSet := buildStatefulSet(data)
+
+		assert.Equal(t, "RollingUpdate", statefulSet.Spec.UpdateStrategy.Type)
+		assert.Equal(t, &partition, statefulSet.Spec.UpdateStrategy.RollingUpdate.Partition)
+	})
+
+	t.Run("OmitsRollingUpdateAndCertificateVolumeIfPartitionIsNotSet", func(t *testing.T) {
+
+		data := TemplateData{
+			Name: "myapp",
+			StatefulSet: StatefulSetData{
+				Replicas: 1,
+			},
+		}
+
+		// act
+		statefulSet := buildStatefulSet(data)
+
+		manifest, err := yaml.Marshal(statefulSet)
+		assert.Nil(t, err)
+		assert.NotContains(t, string(manifest), "rollingUpdate")
+		assert.NotContains(t, string(manifest), "volumes")
+		assert.NotContains(t, string(manifest), "volumeClaimTemplates")
+	})
+}
+
+func TestBuildDaemonSet(t *testing.T) {
+
+	t.Run("MountsHostPathVolumesInApplicationContainer", func(t *testing.T) {
+
+		data := TemplateData{
+			Name: "myapp",
+			DaemonSet: DaemonSetData{
+				UpdateStrategy: "RollingUpdate",
+				MaxUnavailable: "10%",
+				HostPathVolumes: []HostPathVolumeData{
+					{Name: "var-log", HostPath: "/var/log", MountPath: "/host/var/log", Type: "Directory", ReadOnly: true},
+				},
+			},
+		}
+
+		// act
+		daemonSet := buildDaemonSet(data)
+
+		assert.Equal(t, "apps/v1", daemonSet.APIVersion)
+		assert.Equal(t, "DaemonSet", daemonSet.Kind)
+		assert.Equal(t, &RollingUpdateDaemonSet{MaxUnavailable: "10%"}, daemonSet.Spec.UpdateStrategy.RollingUpdate)
+		assert.Contains(t, daemonSet.Spec.Template.Spec.Containers[0].VolumeMounts, VolumeMount{Name: "var-log", MountPath: "/host/var/log", ReadOnly: true})
+		assert.Contains(t, daemonSet.Spec.Template.Spec.Volumes, Volume{Name: "var-log", HostPath: &HostPathVolumeSource{Path: "/var/log", Type: "Directory"}})
+	})
+
+	t.Run("SetsHostNetworkWithClusterDNSPolicy", func(t *testing.T) {
+
+		data := TemplateData{
+			Name: "myapp",
+			DaemonSet: DaemonSetData{
+				HostNetwork:    true,
+				UpdateStrategy: "RollingUpdate",
+			},
+		}
+
+		// act
+		daemonSet := buildDaemonSet(data)
+
+		assert.True(t, daemonSet.Spec.Template.Spec.HostNetwork)
+		assert.Equal(t, "ClusterFirstWithHostNet", daemonSet.Spec.Template.Spec.DNSPolicy)
+	})
+
+	t.Run("OmitsRollingUpdateIfUpdateStrategyIsOnDelete", func(t *testing.T) {
+
+		data := TemplateData{
+			Name: "myapp",
+			DaemonSet: DaemonSetData{
+				UpdateStrategy: "OnDelete",
+			},
+		}
+
+		// act
+		daemonSet := buildDaemonSet(data)
+
+		manifest, err := yaml.Marshal(daemonSet)
+		assert.Nil(t, err)
+		assert.Contains(t, string(manifest), "type: OnDelete")
+		assert.NotContains(t, string(manifest), "rollingUpdate")
+		assert.NotContains(t, string(manifest), "hostNetwork")
+	})
+}
+
+func TestBuildHeadlessService(t *testing.T) {
+
+	t.Run("SetsClusterIPToNoneAndExposesContainerPort", func(t *testing.T) {
+
+		data := TemplateData{
+			Name:             "myapp",
+			AppLabelSelector: "myapp",
+			Container: ContainerData{
+				Port: 5432,
+			},
+			AdditionalContainerPorts: []AdditionalPortData{
+				{Name: "metrics", Port: 9187, Protocol: "TCP"},
+			},
+			StatefulSet: StatefulSetData{
+				ServiceName: "myapp-headless",
+			},
+		}
+
+		// act
+		service := buildHeadlessService(data)
+
+		assert.Equal(t, "myapp-headless", service.Metadata.Name)
+		assert.Equal(t, "None", service.Spec.ClusterIP)
+		assert.Equal(t, []ServicePort{
+			{Name: "web", Port: 5432, TargetPort: "web", Protocol: "TCP"},
+			{Name: "metrics", Port: 9187, TargetPort: "metrics", Protocol: "TCP"},
+		}, service.Spec.Ports)
+		assert.Equal(t, map[string]string{"app": "myapp"}, service.Spec.Selector)
+	})
+}
+
+func TestBuildHorizontalPodAutoscaler(t *testing.T) {
+
+	t.Run("SetsMemoryCustomAndExternalMetrics", func(t *testing.T) {
+
+		data := TemplateData{
+			NameWithTrack:          "myapp",
+			MinReplicas:            3,
+			MaxReplicas:            10,
+			TargetCPUPercentage:    80,
+			TargetMemoryPercentage: 75,
+			AutoscaleMetrics: []AutoscaleMetricData{
+				{Type: "pods", Name: "http_requests_per_second", Target: "100", TargetType: "AverageValue"},
+				{Type: "object", Name: "requests_per_second", Target: "2k", TargetType: "Value", ObjectAPIVersion: "networking.k8s.io/v1", ObjectKind: "Ingress", ObjectName: "myapp"},
+				{Type: "external", Name: "pubsub.googleapis.com|subscription|num_undelivered_messages", Selector: map[string]string{"resource.labels.subscription_id": "orders"}, Target: "50", TargetType: "AverageValue"},
+			},
+		}
+
+		// act
+		hpa := buildHorizontalPodAutoscaler(data)
+
+		manifest, err := yaml.Marshal(hpa.Spec.Metrics)
+		assert.Nil(t, err)
+		assert.Equal(t, `- type: Resource
+  resource:
+    name: cpu
+    target:
+      type: Utilization
+      averageUtilization: 80
+- type: Resource
+  resource:
+    name: memory
+    target:
+      type: Utilization
+      averageUtilization: 75
+- type: Pods
+  pods:
+    metric:
+      name: http_requests_per_second
+    target:
+      type: AverageValue
+      averageValue: 100
+- type: Object
+  object:
+    describedObject:
+      apiVersion: networking.k8s.io/v1
+      kind: Ingress
+      name: myapp
+    metric:
+      name: requests_per_second
+    target:
+      type: Value
+      value: 2k
+- type: External
+  external:
+    metric:
+      name: pubsub.googleapis.com|subscription|num_undelivered_messages
+      selector:
+        matchLabels:
+          resource.labels.subscription_id: orders
+    target:
+      type: AverageValue
+      averageValue: 50
+`, string(manifest))
+		assert.Nil(t, hpa.Spec.Behavior)
+	})
+
+	t.Run("SetsBehaviorIfScaleUpOrScaleDownIsSet", func(t *testing.T) {
+
+		stabilizationWindowSeconds := 600
+		data := TemplateData{
+			NameWithTrack:       "myapp",
+			TargetCPUPercentage: 80,
+			AutoscaleScaleDown: &AutoscaleScalingRulesData{
+				StabilizationWindowSeconds: &stabilizationWindowSeconds,
+				SelectPolicy:               "Min",
+				Policies: []AutoscalingPolicyData{
+					{Type: "Percent", Value: 10, PeriodSeconds: 60},
+				},
+			},
+		}
+
+		// act
+		hpa := buildHorizontalPodAutoscaler(data)
+
+		manifest, err := yaml.Marshal(hpa.Spec.Behavior)
+		assert.Nil(t, err)
+		assert.Equal(t, `scaleDown:
+  stabilizationWindowSeconds: 600
+  selectPolicy: Min
+  policies:
+  - type: Percent
+    value: 10
+    periodSeconds: 60
+`, string(manifest))
+	})
+}
+
+func TestBuildPodDisruptionBudget(t *testing.T) {
+
+	t.Run("SetsMinAvailableAsPercentage", func(t *testing.T) {
+
+		data := TemplateData{
+			NameWithTrack:                   "myapp",
+			AppLabelSelector:                "myapp",
+			PodDisruptionBudgetMinAvailable: "50%",
+		}
+
+		// act
+		pdb := buildPodDisruptionBudget(data)
+
+		manifest, err := yaml.Marshal(pdb.Spec)
+		assert.Nil(t, err)
+		assert.Equal(t, "selector:\n  matchLabels:\n    app: myapp\nminAvailable: 50%\n", string(manifest))
+	})
+
+	t.Run("SetsMaxUnavailableAsNumber", func(t *testing.T) {
+
+		data := TemplateData{
+			NameWithTrack:                     "myapp",
+			AppLabelSelector:                  "myapp",
+			PodDisruptionBudgetMaxUnavailable: "2",
+		}
+
+		// act
+		pdb := buildPodDisruptionBudget(data)
+
+		manifest, err := yaml.Marshal(pdb.Spec)
+		assert.Nil(t, err)
+		assert.Equal(t, "selector:\n  matchLabels:\n    app: myapp\nmaxUnavailable: 2\n", string(manifest))
+	})
+
+	t.Run("UsesPodDisruptionBudgetAPIVersion", func(t *testing.T) {
+
+		data := TemplateData{
+			NameWithTrack:                     "myapp",
+			AppLabelSelector:                  "myapp",
+			PodDisruptionBudgetMaxUnavailable: "1",
+			PodDisruptionBudgetAPIVersion:     "policy/v1",
+		}
+
+		// act
+		pdb := buildPodDisruptionBudget(data)
+
+		assert.Equal(t, "policy/v1", pdb.APIVersion)
+	})
+}
+
+func TestBuildNetworkPolicy(t *testing.T) {
+
+	t.Run("AllowsIngressControllerAndPrometheusNamespaces", func(t *testing.T) {
+
+		data := TemplateData{
+			Name:             "myapp",
+			AppLabelSelector: "myapp",
+			UseNetworkPolicy: true,
+			NetworkPolicy: NetworkPolicyData{
+				IngressNamespaces:   []string{"ingress-nginx"},
+				PrometheusNamespace: "monitoring",
+				MetricsPorts:        []int{5000, 9101},
+			},
+		}
+
+		// act
+		networkPolicy := buildNetworkPolicy(data)
+
+		assert.Equal(t, "networking.k8s.io/v1", networkPolicy.APIVersion)
+		manifest, err := yaml.Marshal(networkPolicy.Spec)
+		assert.Nil(t, err)
+		assert.Equal(t, `podSelector:
+  matchLabels:
+    app: myapp
+policyTypes:
+- Ingress
+ingress:
+- from:
+  - namespaceSelector:
+      matchLabels:
+        kubernetes.io/metadata.name: ingress-nginx
+- from:
+  - namespaceSelector:
+      matchLabels:
+        kubernetes.io/metadata.name: monitoring
+  ports:
+  - protocol: TCP
+    port: 5000
+  - protocol: TCP
+    port: 9101
+`, string(manifest))
+	})
+
+	t.Run("AllowsGCELoadBalancerRangesAndDeclaredClients", func(t *testing.T) {
+
+		data := TemplateData{
+			Name:             "myapp",
+			AppLabelSelector: "myapp",
+			UseNetworkPolicy: true,
+			NetworkPolicy: NetworkPolicyData{
+				IngressIPBlocks: []string{"35.191.0.0/16"},
+				AllowedClients: []NetworkPolicyPeerData{
+					{App: "frontend"},
+					{App: "worker", Namespace: "jobs"},
+				},
+			},
+		}
+
+		// act
+		networkPolicy := buildNetworkPolicy(data)
+
+		manifest, err := yaml.Marshal(networkPolicy.Spec.Ingress)
+		assert.Nil(t, err)
+		assert.Equal(t, `- from:
+  - ipBlock:
+      cidr: 35.191.0.0/16
+- from:
+  - podSelector:
+      matchLabels:
+        app: frontend
+  - podSelector:
+      matchLabels:
+        app: worker
+    namespaceSelector:
+      matchLabels:
+        kubernetes.io/metadata.name: jobs
+`, string(manifest))
+	})
+
+	t.Run("AllowsAllIngressForLoadBalancer", func(t *testing.T) {
+
+		data := TemplateData{
+			Name:             "myapp",
+			AppLabelSelector: "myapp",
+			UseNetworkPolicy: true,
+			NetworkPolicy: NetworkPolicyData{
+				AllowAllIngress: true,
+			},
+		}
+
+		// act
+		networkPolicy := buildNetworkPolicy(data)
+
+		assert.Equal(t, []NetworkPolicyIngressRule{{}}, networkPolicy.Spec.Ingress)
+	})
+
+	t.Run("RestrictsEgressToDNSAndDeclaredDestinations", func(t *testing.T) {
+
+		data := TemplateData{
+			Name:             "myapp",
+			AppLabelSelector: "myapp",
+			UseNetworkPolicy: true,
+			NetworkPolicy: NetworkPolicyData{
+				RestrictEgress: true,
+				Egress: []NetworkPolicyEgressData{
+					{CIDR: "10.0.0.0/8", Ports: []NetworkPolicyPortData{{Port: 5432, Protocol: "TCP"}}},
+					{Namespace: "kafka"},
+				},
+			},
+		}
+
+		// act
+		networkPolicy := buildNetworkPolicy(data)
+
+		assert.Equal(t, []string{"Ingress", "Egress"}, networkPolicy.Spec.PolicyTypes)
+		manifest, err := yaml.Marshal(networkPolicy.Spec.Egress)
+		assert.Nil(t, err)
+		assert.Equal(t, `- ports:
+  - protocol: UDP
+    port: 53
+  - protocol: TCP
+    port: 53
+- to:
+  - ipBlock:
+      cidr: 10.0.0.0/8
+  ports:
+  - protocol: TCP
+    port: 5432
+- to:
+  - namespaceSelector:
+      matchLabels:
+        kubernetes.io/metadata.name: kafka
+`, string(manifest))
+	})
+
+	t.Run("LeavesEgressUnrestrictedWithoutDestinations", func(t *testing.T) {
+
+		data := TemplateData{
+			Name:             "myapp",
+			AppLabelSelector: "myapp",
+			UseNetworkPolicy: true,
+		}
+
+		// act
+		networkPolicy := buildNetworkPolicy(data)
+
+		assert.Equal(t, []string{"Ingress"}, networkPolicy.Spec.PolicyTypes)
+		assert.Nil(t, networkPolicy.Spec.Egress)
+	})
+}
+
+func TestBuildIngress(t *testing.T) {
+
+	t.Run("SetsIngressClassNameAndPathTypeForNetworkingV1", func(t *testing.T) {
+
+		data := TemplateData{
+			Name:              "myapp",
+			Hosts:             []string{"myapp.example.com"},
+			IngressPath:       "/",
+			UseNginxIngress:   true,
+			IngressAPIVersion: "networking.k8s.io/v1",
+		}
+
+		// act
+		ingress := buildIngress(data)
+
+		assert.Equal(t, "networking.k8s.io/v1", ingress.APIVersion)
+		assert.Equal(t, "nginx", ingress.Spec.IngressClassName)
+		assert.NotContains(t, ingress.Metadata.Annotations, "kubernetes.io/ingress.class")
+		manifest, err := yaml.Marshal(ingress.Spec.Rules)
+		assert.Nil(t, err)
+		assert.Equal(t, `- host: myapp.example.com
+  http:
+    paths:
+    - path: /
+      pathType: Prefix
+      backend:
+        service:
+          name: myapp
+          port:
+            name: https
+`, string(manifest))
+	})
+
+	t.Run("KeepsIngressClassAnnotationAndUsesImplementationSpecificPathTypeForGCE", func(t *testing.T) {
+
+		data := TemplateData{
+			Name:              "myapp",
+			Hosts:             []string{"myapp.example.com"},
+			IngressPath:       "/*",
+			UseGCEIngress:     true,
+			IngressAPIVersion: "networking.k8s.io/v1",
+		}
+
+		// act
+		ingress := buildIngress(data)
+
+		assert.Equal(t, "gce", ingress.Metadata.Annotations["kubernetes.io/ingress.class"])
+		assert.Equal(t, "", ingress.Spec.IngressClassName)
+		assert.Equal(t, "ImplementationSpecific", ingress.Spec.Rules[0].HTTP.Paths[0].PathType)
+	})
+
+	t.Run("UsesIngressClassAnnotationAndServiceNameForExtensionsV1beta1", func(t *testing.T) {
+
+		data := TemplateData{
+			Name:              "myapp",
+			Hosts:             []string{"myapp.example.com"},
+			IngressPath:       "/",
+			UseNginxIngress:   true,
+			IngressAPIVersion: "extensions/v1beta1",
+		}
+
+		// act
+		ingress := buildIngress(data)
+
+		assert.Equal(t, "extensions/v1beta1", ingress.APIVersion)
+		assert.Equal(t, "nginx", ingress.Metadata.Annotations["kubernetes.io/ingress.class"])
+		assert.Equal(t, "", ingress.Spec.IngressClassName)
+		manifest, err := yaml.Marshal(ingress.Spec.Rules[0].HTTP.Paths)
+		assert.Nil(t, err)
+		assert.Equal(t, "- path: /\n  backend:\n    serviceName: myapp\n    servicePort: https\n", string(manifest))
+	})
+
+	t.Run("SetsNginxInternalIngressClassNameOnInternalIngress", func(t *testing.T) {
+
+		data := TemplateData{
+			Name:                "myapp",
+			InternalHosts:       []string{"myapp.internal.example.com"},
+			InternalIngressPath: "/",
+			IngressAPIVersion:   "networking.k8s.io/v1",
+		}
+
+		// act
+		ingress := buildInternalIngress(data)
+
+		assert.Equal(t, "myapp-internal", ingress.Metadata.Name)
+		assert.Equal(t, "nginx-internal", ingress.Spec.IngressClassName)
+		assert.NotContains(t, ingress.Metadata.Annotations, "kubernetes.io/ingress.class")
+	})
+
+	t.Run("ReplacesBasepathWithRoutesForMatchingHosts", func(t *testing.T) {
+
+		data := TemplateData{
+			Name:              "myapp",
+			Hosts:             []string{"myapp.example.com", "static.example.com"},
+			IngressPath:       "/",
+			UseNginxIngress:   true,
+			IngressAPIVersion: "networking.k8s.io/v1",
+			Routes: []RouteData{
+				{Hosts: []string{"static.example.com"}, Path: "/static/", ServiceName: "static-bucket", ServicePort: "80"},
+				{Hosts: []string{"static.example.com"}, Path: "/admin/", ServiceName: "myapp", ServicePort: "admin"},
+			},
+		}
+
+		// act
+		ingress := buildIngress(data)
+
+		manifest, err := yaml.Marshal(ingress.Spec.Rules)
+		assert.Nil(t, err)
+		assert.Equal(t, `- host: myapp.example.com
+  http:
+    paths:
+    - path: /
+      pathType: Prefix
+      backend:
+        service:
+          name: myapp
+          port:
+            name: https
+- host: static.example.com
+  http:
+    paths:
+    - path: /static/
+      pathType: Prefix
+      backend:
+        service:
+          name: static-bucket
+          port:
+            number: 80
+    - path: /admin/
+      pathType: Prefix
+      backend:
+        service:
+          name: myapp
+          port:
+            name: admin
+`, string(manifest))
+	})
+
+	t.Run("UsesInternalPathOfRoutesOnInternalIngress", func(t *testing.T) {
+
+		data := TemplateData{
+			Name:                "myapp",
+			InternalHosts:       []string{"myapp.internal.example.com"},
+			InternalIngressPath: "/",
+			IngressAPIVersion:   "networking.k8s.io/v1",
+			Routes: []RouteData{
+				{Path: "/api/*", InternalPath: "/api/", ServiceName: "myapp", ServicePort: "https"},
+			},
+		}
+
+		// act
+		ingress := buildInternalIngress(data)
+
+		assert.Equal(t, "/api/", ingress.Spec.Rules[0].HTTP.Paths[0].Path)
+		assert.Equal(t, "Prefix", ingress.Spec.Rules[0].HTTP.Paths[0].PathType)
+	})
+
+	t.Run("AddsNginxFeatureAndCustomAnnotationsToInternalIngress", func(t *testing.T) {
+
+		data := TemplateData{
+			Name:                           "myapp",
+			InternalHosts:                  []string{"myapp.internal.example.com"},
+			InternalIngressPath:            "/",
+			IngressAPIVersion:              "networking.k8s.io/v1",
+			NginxIngressFeatureAnnotations: map[string]string{"nginx.ingress.kubernetes.io/limit-rps": "10"},
+			IngressCustomAnnotations:       map[string]string{"nginx.ingress.kubernetes.io/proxy-next-upstream": "off"},
+		}
+
+		// act
+		ingress := buildInternalIngress(data)
+
+		assert.Equal(t, "10", ingress.Metadata.Annotations["nginx.ingress.kubernetes.io/limit-rps"])
+		assert.Equal(t, "off", ingress.Metadata.Annotations["nginx.ingress.kubernetes.io/proxy-next-upstream"])
+	})
+
+	t.Run("AddsOnlyCustomAnnotationsToGCEIngress", func(t *testing.T) {
+
+		data := TemplateData{
+			Name:                           "myapp",
+			Hosts:                          []string{"myapp.example.com"},
+			IngressPath:                    "/*",
+			UseGCEIngress:                  true,
+			IngressAPIVersion:              "networking.k8s.io/v1",
+			NginxIngressFeatureAnnotations: map[string]string{"nginx.ingress.kubernetes.io/limit-rps": "10"},
+			IngressCustomAnnotations:       map[string]string{"networking.gke.io/v1beta1.FrontendConfig": "myapp"},
+		}
+
+		// act
+		ingress := buildIngress(data)
+
+		assert.NotContains(t, ingress.Metadata.Annotations, "nginx.ingress.kubernetes.io/limit-rps")
+		assert.Equal(t, "myapp", ingress.Metadata.Annotations["networking.gke.io/v1beta1.FrontendConfig"])
+	})
+
+	t.Run("SetsNginxBackendProtocol", func(t *testing.T) {
+
+		data := TemplateData{
+			Name:                        "myapp",
+			Hosts:                       []string{"myapp.example.com"},
+			IngressPath:                 "/",
+			UseNginxIngress:             true,
+			IngressAPIVersion:           "networking.k8s.io/v1",
+			NginxIngressBackendProtocol: "GRPCS",
+		}
+
+		// act
+		ingress := buildIngress(data)
+
+		assert.Equal(t, "GRPCS", ingress.Metadata.Annotations["nginx.ingress.kubernetes.io/backend-protocol"])
+	})
+
+	t.Run("AttachesManagedCertificateToGCEIngressInsteadOfTLSSecret", func(t *testing.T) {
+
+		data := TemplateData{
+			Name:                "myapp",
+			Hosts:               []string{"myapp.example.com"},
+			IngressPath:         "/*",
+			UseGCEIngress:       true,
+			IngressAPIVersion:   "networking.k8s.io/v1",
+			CertificateProvider: "managed",
+		}
+
+		// act
+		ingress := buildIngress(data)
+
+		assert.Equal(t, "myapp", ingress.Metadata.Annotations["networking.gke.io/managed-certificates"])
+		assert.Equal(t, 0, len(ingress.Spec.TLS))
+	})
+
+	t.Run("UsesCertificateSecretForInternalIngressWithManagedCertificate", func(t *testing.T) {
+
+		data := TemplateData{
+			Name:                  "myapp",
+			InternalHosts:         []string{"myapp.internal.example.com"},
+			InternalIngressPath:   "/",
+			UseGCEIngress:         true,
+			IngressAPIVersion:     "networking.k8s.io/v1",
+			CertificateProvider:   "managed",
+			CertificateSecretName: "myapp-ev-certificate",
+		}
+
+		// act
+		ingress := buildInternalIngress(data)
+
+		assert.Equal(t, "myapp-ev-certificate", ingress.Spec.TLS[0].SecretName)
+	})
+
+	t.Run("SetsPublicAndInternalDNSAnnotationsOnTheirIngress", func(t *testing.T) {
+
+		data := TemplateData{
+			Name:                       "myapp",
+			Hosts:                      []string{"myapp.example.com"},
+			InternalHosts:              []string{"myapp.internal.example.com"},
+			IngressPath:                "/",
+			InternalIngressPath:        "/",
+			UseNginxIngress:            true,
+			UseDNSAnnotationsOnIngress: true,
+			IngressAPIVersion:          "networking.k8s.io/v1",
+			PublicDNSAnnotations:       map[string]string{"estafette.io/cloudflare-dns": "true", "estafette.io/cloudflare-proxy": "true", "estafette.io/cloudflare-hostnames": "myapp.example.com"},
+			InternalDNSAnnotations:     map[string]string{"estafette.io/google-cloud-dns": "true", "estafette.io/google-cloud-dns-hostnames": "myapp.internal.example.com"},
+		}
+
+		// act
+		ingress := buildIngress(data)
+		internalIngress := buildInternalIngress(data)
+
+		assert.Equal(t, "myapp.example.com", ingress.Metadata.Annotations["estafette.io/cloudflare-hostnames"])
+		assert.NotContains(t, ingress.Metadata.Annotations, "estafette.io/google-cloud-dns")
+		assert.Equal(t, "myapp.internal.example.com", internalIngress.Metadata.Annotations["estafette.io/google-cloud-dns-hostnames"])
+		assert.NotContains(t, internalIngress.Metadata.Annotations, "estafette.io/cloudflare-dns")
+	})
+}
+
+func TestBuildCertificate(t *testing.T) {
+
+	t.Run("RequestsCertificateForAllHostsFromIssuer", func(t *testing.T) {
+
+		data := TemplateData{
+			Name:                  "myapp",
+			Namespace:             "mynamespace",
+			AllHosts:              []string{"myapp.example.com", "myapp.internal.example.com"},
+			CertificateProvider:   "cert-manager",
+			CertificateSecretName: "myapp-certificate",
+			CertManagerIssuer:     "letsencrypt-prod",
+			CertManagerIssuerKind: "ClusterIssuer",
+		}
+
+		// act
+		certificate := buildCertificate(data)
+
+		assert.Equal(t, "cert-manager.io/v1", certificate.APIVersion)
+		manifest, err := yaml.Marshal(certificate.Spec)
+		assert.Nil(t, err)
+		assert.Equal(t, `secretName: myapp-certificate
+dnsNames:
+- myapp.example.com
+- myapp.internal.example.com
+issuerRef:
+  name: letsencrypt-prod
+  kind: ClusterIssuer
+  group: cert-manager.io
+`, string(manifest))
+	})
+}
+
+func TestBuildManagedCertificate(t *testing.T) {
+
+	t.Run("RequestsCertificateForPublicHosts", func(t *testing.T) {
+
+		data := TemplateData{
+			Name:          "myapp",
+			Hosts:         []string{"myapp.example.com"},
+			InternalHosts: []string{"myapp.internal.example.com"},
+		}
+
+		// act
+		managedCertificate := buildManagedCertificate(data)
+
+		assert.Equal(t, "networking.gke.io/v1", managedCertificate.APIVersion)
+		assert.Equal(t, "ManagedCertificate", managedCertificate.Kind)
+		assert.Equal(t, []string{"myapp.example.com"}, managedCertificate.Spec.Domains)
+	})
+}
+
+func TestBuildVirtualService(t *testing.T) {
+
+	t.Run("SplitsTrafficBetweenStableAndCanarySubsets", func(t *testing.T) {
+
+		retries := 2
+		data := TemplateData{
+			Name:        "myapp",
+			AllHosts:    []string{"myapp.example.com"},
+			IngressPath: "/",
+			UseIstio:    true,
+			Mesh: MeshData{
+				Gateways:     []string{"myapp"},
+				UseSubsets:   true,
+				CanaryWeight: 10,
+				Timeout:      "60s",
+				Retries:      &retries,
+				RetryOn:      "5xx",
+			},
+		}
+
+		// act
+		virtualService := buildVirtualService(data)
+
+		assert.Equal(t, "networking.istio.io/v1beta1", virtualService.APIVersion)
+		manifest, err := yaml.Marshal(virtualService.Spec)
+		assert.Nil(t, err)
+		assert.Equal(t, `hosts:
+- myapp.example.com
+gateways:
+- myapp
+http:
+- match:
+  - uri:
+      prefix: /
+  route:
+  - destination:
+      host: myapp
+      subset: stable
+      port:
+        number: 80
+    weight: 90
+  - destination:
+      host: myapp
+      subset: canary
+      port:
+        number: 80
+    weight: 10
+  timeout: 60s
+  retries:
+    attempts: 2
+    retryOn: 5xx
+`, string(manifest))
+	})
+
+	t.Run("RoutesPathsPerHostAndBasepathForOtherHosts", func(t *testing.T) {
+
+		data := TemplateData{
+			Name:        "myapp",
+			AllHosts:    []string{"myapp.example.com", "api.example.com"},
+			IngressPath: "/",
+			UseIstio:    true,
+			Routes: []RouteData{
+				{Hosts: []string{"api.example.com"}, Path: "/v1", ServiceName: "myapi", ServicePort: "8080"},
+			},
+			Mesh: MeshData{Gateways: []string{"istio-system/public-gateway"}},
+		}
+
+		// act
+		virtualService := buildVirtualService(data)
+
+		assert.Equal(t, 2, len(virtualService.Spec.HTTP))
+		assert.Equal(t, "api.example.com", virtualService.Spec.HTTP[0].Match[0].Authority.Exact)
+		assert.Equal(t, "/v1", virtualService.Spec.HTTP[0].Match[0].URI.Prefix)
+		assert.Equal(t, "myapi", virtualService.Spec.HTTP[0].Route[0].Destination.Host)
+		assert.Equal(t, 8080, virtualService.Spec.HTTP[0].Route[0].Destination.Port.Number)
+		assert.Equal(t, "myapp.example.com", virtualService.Spec.HTTP[1].Match[0].Authority.Exact)
+		assert.Equal(t, "myapp", virtualService.Spec.HTTP[1].Route[0].Destination.Host)
+		assert.Nil(t, virtualService.Spec.HTTP[1].Route[0].Weight)
+	})
+}
+
+func TestBuildDestinationRule(t *testing.T) {
+
+	t.Run("DefinesSubsetsByTrack", func(t *testing.T) {
+
+		data := TemplateData{
+			Name: "myapp",
+			Mesh: MeshData{UseSubsets: true},
+		}
+
+		// act
+		destinationRule := buildDestinationRule(data)
+
+		assert.Equal(t, "myapp", destinationRule.Spec.Host)
+		assert.Equal(t, 2, len(destinationRule.Spec.Subsets))
+		assert.Equal(t, map[string]string{"track": "canary"}, destinationRule.Spec.Subsets[1].Labels)
+	})
+}
+
+func TestBuildGateway(t *testing.T) {
+
+	t.Run("TerminatesTLSWithCertificateSecretAndRedirectsHTTP", func(t *testing.T) {
+
+		data := TemplateData{
+			Name:     "myapp",
+			AllHosts: []string{"myapp.example.com"},
+			Mesh:     MeshData{GatewaySelector: map[string]string{"istio": "ingressgateway"}},
+		}
+
+		// act
+		gateway := buildGateway(data)
+
+		assert.Equal(t, map[string]string{"istio": "ingressgateway"}, gateway.Spec.Selector)
+		assert.Equal(t, "myapp-letsencrypt-certificate", gateway.Spec.Servers[0].TLS.CredentialName)
+		assert.True(t, gateway.Spec.Servers[1].TLS.HTTPSRedirect)
+	})
+}
@@ -3,39 +3,70 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"net"
+	"reflect"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 )
 
+var (
+	// supported sources for environment variables with their required properties
+	environmentVariableSources = map[string][]string{
+		"secretKeyRef":     []string{"key"},
+		"configMapKeyRef":  []string{"name", "key"},
+		"fieldRef":         []string{"fieldPath"},
+		"resourceFieldRef": []string{"resource"},
+	}
+
+	// container names have to be valid dns labels
+	containerNameRegex = regexp.MustCompile("^[a-z0-9]([-a-z0-9]*[a-z0-9])?$")
+)
+
 // Params is used to parameterize the deployment, set from custom properties in the manifest
 type Params struct {
 	// control params
-	Action          string          `json:"action,omitempty"`
-	Kind            string          `json:"kind,omitempty"`
-	DryRun          bool            `json:"dryrun,omitempty"`
-	BuildVersion    string          `json:"-"`
-	ChaosProof      bool            `json:"chaosproof,omitempty"`
-	Manifests       ManifestsParams `json:"manifests,omitempty"`
-	TrustedIPRanges []string        `json:"trustedips,omitempty"`
+	Action            string          `json:"action,omitempty"`
+	Kind              string          `json:"kind,omitempty"`
+	DryRun            bool            `json:"dryrun,omitempty"`
+	BuildVersion      string          `json:"-"`
+	ClusterVersion    string          `json:"-"`
+	ChaosProof        bool            `json:"chaosproof,omitempty"`
+	Manifests         ManifestsParams `json:"manifests,omitempty"`
+	TrustedIPRanges   []string        `json:"trustedips,omitempty"`
+	UnknownProperties string          `json:"unknownproperties,omitempty"`
 
 	// app params
-	App                             string              `json:"app,omitempty"`
-	Namespace                       string              `json:"namespace,omitempty"`
-	Schedule                        string              `json:"schedule,omitempty"`
-	ConcurrencyPolicy               string              `json:"concurrencypolicy,omitempty"`
-	Labels                          map[string]string   `json:"labels,omitempty"`
-	Visibility                      string              `json:"visibility,omitempty"`
-	IapOauthCredentialsClientID     string              `json:"iapOauthClientID,omitempty"`
-	IapOauthCredentialsClientSecret string              `json:"iapOauthClientSecret,omitempty"`
-	WhitelistedIPS                  []string            `json:"whitelist,omitempty"`
-	Hosts                           []string            `json:"hosts,omitempty"`
-	InternalHosts                   []string            `json:"internalhosts,omitempty"`
-	Basepath                        string              `json:"basepath,omitempty"`
-	Autoscale                       AutoscaleParams     `json:"autoscale,omitempty"`
-	Request                         RequestParams       `json:"request,omitempty"`
-	Secrets                         SecretsParams       `json:"secrets,omitempty"`
-	Configs                         ConfigsParams       `json:"configs,omitempty"`
-	VolumeMounts                    []VolumeMountParams `json:"volumemounts,omitempty"`
+	App                             string                    `json:"app,omitempty"`
+	Namespace                       string                    `json:"namespace,omitempty"`
+	Schedule                        string                    `json:"schedule,omitempty"`
+	ConcurrencyPolicy               string                    `json:"concurrencypolicy,omitempty"`
+	Labels                          map[string]string         `json:"labels,omitempty"`
+	Visibility                      string                    `json:"visibility,omitempty"`
+	Protocol                        string                    `json:"protocol,omitempty"`
+	IapOauthCredentialsClientID     string                    `json:"iapOauthClientID,omitempty"`
+	IapOauthCredentialsClientSecret string                    `json:"iapOauthClientSecret,omitempty"`
+	WhitelistedIPS                  []string                  `json:"whitelist,omitempty"`
+	Hosts                           []string                  `json:"hosts,omitempty"`
+	InternalHosts                   []string                  `json:"internalhosts,omitempty"`
+	Basepath                        string                    `json:"basepath,omitempty"`
+	Routes                          []RouteParams             `json:"routes,omitempty"`
+	Ingress                         IngressParams             `json:"ingress,omitempty"`
+	Certificate                     CertificateParams         `json:"certificate,omitempty"`
+	DNS                             DNSParams                 `json:"dns,omitempty"`
+	Mesh                            MeshParams                `json:"mesh,omitempty"`
+	StatefulSet                     StatefulSetParams         `json:"statefulset,omitempty"`
+	DaemonSet                       DaemonSetParams           `json:"daemonset,omitempty"`
+	Autoscale                       AutoscaleParams           `json:"autoscale,omitempty"`
+	PodDisruptionBudget             PodDisruptionBudgetParams `json:"pdb,omitempty"`
+	NetworkPolicy                   NetworkPolicyParams       `json:"networkpolicy,omitempty"`
+	Request                         RequestParams             `json:"request,omitempty"`
+	Secrets                         SecretsParams             `json:"secrets,omitempty"`
+	Configs                         ConfigsParams             `json:"configs,omitempty"`
+	VolumeMounts                    []VolumeMountParams       `json:"volumemounts,omitempty"`
+	Scheduling                      SchedulingParams          `json:"scheduling,omitempty"`
+	SecurityContext                 SecurityContextParams     `json:"securitycontext,omitempty"`
 
 	EnablePayloadLogging             bool   `json:"enablePayloadLogging,omitempty"`
 	UseGoogleCloudCredentials        bool   `json:"useGoogleCloudCredentials,omitempty"`
@@ -43,12 +74,16 @@ type Params struct {
 	GoogleCloudCredentialsApp        string `json:"googleCloudCredentialsApp,omitempty"`
 
 	// container params
-	Container              ContainerParams     `json:"container,omitempty"`
-	InjectHTTPProxySidecar *bool               `json:"injecthttpproxysidecar,omitempty"`
-	Sidecar                SidecarParams       `json:"sidecar,omitempty"`
-	Sidecars               []*SidecarParams    `json:"sidecars,omitempty"`
-	RollingUpdate          RollingUpdateParams `json:"rollingupdate,omitempty"`
-	Babysitter             BabysitterParams    `json:"babysitter,omitempty"`
+	Container              ContainerParams       `json:"container,omitempty"`
+	InjectHTTPProxySidecar *bool                 `json:"injecthttpproxysidecar,omitempty"`
+	Sidecar                SidecarParams         `json:"sidecar,omitempty"`
+	Sidecars               []*SidecarParams      `json:"sidecars,omitempty"`
+	InitContainers         []InitContainerParams `json:"initcontainers,omitempty"`
+	RollingUpdate          RollingUpdateParams   `json:"rollingupdate,omitempty"`
+	Babysitter             BabysitterParams      `json:"babysitter,omitempty"`
+
+	// environment params, merged over the params above for the release with the same name
+	Environments map[string]*Params `json:"environments,omitempty"`
 }
 
 // ContainerParams defines the container image to deploy
@@ -58,11 +93,14 @@ type ContainerParams struct {
 	ImageTag             string                 `json:"tag,omitempty"`
 	Port                 int                    `json:"port,omitempty"`
 	EnvironmentVariables map[string]interface{} `json:"env,omitempty"`
+	Command              []string               `json:"command,omitempty"`
+	Args                 []string               `json:"args,omitempty"`
 
 	CPU            CPUParams       `json:"cpu,omitempty"`
 	Memory         MemoryParams    `json:"memory,omitempty"`
 	LivenessProbe  ProbeParams     `json:"liveness,omitempty"`
 	ReadinessProbe ProbeParams     `json:"readiness,omitempty"`
+	StartupProbe   *ProbeParams    `json:"startup,omitempty"`
 	Metrics        MetricsParams   `json:"metrics,omitempty"`
 	Lifecycle      LifecycleParams `json:"lifecycle,omitempty"`
 
@@ -77,6 +115,92 @@ type AdditionalPortParams struct {
 	Visibility string `json:"visibility,omitempty"`
 }
 
+// RouteParams routes a path to a port of the application's service or to another service in the same namespace; the routes replace the
+// basepath for the hosts they apply to, which are all hosts and internal hosts unless hosts is set
+type RouteParams struct {
+	Hosts   []string `json:"hosts,omitempty"`
+	Path    string   `json:"path,omitempty"`
+	Port    string   `json:"port,omitempty"`
+	Service string   `json:"service,omitempty"`
+}
+
+// IngressParams configures nginx ingress features for the public and internal ingress; annotations are passed through as is, but only if
+// allowed by allowedannotations, which is only taken from the credential defaults
+type IngressParams struct {
+	CORS               IngressCORSParams      `json:"cors,omitempty"`
+	RateLimit          IngressRateLimitParams `json:"ratelimit,omitempty"`
+	Auth               IngressAuthParams      `json:"auth,omitempty"`
+	ServerSnippet      string                 `json:"serversnippet,omitempty"`
+	HTTPSRedirect      *bool                  `json:"httpsredirect,omitempty"`
+	CustomErrors       []int                  `json:"customerrors,omitempty"`
+	DefaultBackend     string                 `json:"defaultbackend,omitempty"`
+	Annotations        map[string]string      `json:"annotations,omitempty"`
+	AllowedAnnotations []string               `json:"allowedannotations,omitempty"`
+}
+
+// CertificateParams selects who provides the tls certificate for the hosts; letsencrypt has the estafette letsencrypt controller fill a
+// secret, cert-manager requests it from an issuer, managed has google issue a ManagedCertificate for the gce ingress used with visibility iap
+// and secret uses an existing tls secret, for example with an ev certificate; with managed the openresty sidecar and internal ingress still
+// use the secret from secretname or, if not set, the letsencrypt secret
+type CertificateParams struct {
+	Provider   string `json:"provider,omitempty"`
+	Issuer     string `json:"issuer,omitempty"`
+	IssuerKind string `json:"issuerkind,omitempty"`
+	SecretName string `json:"secretname,omitempty"`
+}
+
+// DNSParams selects the dns provider that creates the records for the public hosts and for the internal hosts
+type DNSParams struct {
+	Public   DNSProviderParams `json:"public,omitempty"`
+	Internal DNSProviderParams `json:"internal,omitempty"`
+}
+
+// DNSProviderParams is the dns provider, picked up by its controller from annotations on the ingress or service; proxy only applies to cloudflare
+type DNSProviderParams struct {
+	Provider string `json:"provider,omitempty"`
+	Proxy    *bool  `json:"proxy,omitempty"`
+}
+
+// MeshParams routes the hosts through an istio ingress gateway with a VirtualService and DestinationRule instead of an ingress, shifting
+// canaryweight percent of the traffic to the canary subset during canary releases; without gateway a Gateway is rendered for the hosts,
+// which istio only serves if the certificate secret is in the namespace of the gateway pods selected by gatewayselector
+type MeshParams struct {
+	Type             string            `json:"type,omitempty"`
+	Gateway          string            `json:"gateway,omitempty"`
+	GatewaySelector  map[string]string `json:"gatewayselector,omitempty"`
+	GatewayNamespace string            `json:"gatewaynamespace,omitempty"`
+	CanaryWeight     int               `json:"canaryweight,omitempty"`
+}
+
+// IngressCORSParams enables cross-origin resource sharing on the ingress
+type IngressCORSParams struct {
+	Enabled          bool   `json:"enabled,omitempty"`
+	AllowOrigin      string `json:"alloworigin,omitempty"`
+	AllowMethods     string `json:"allowmethods,omitempty"`
+	AllowHeaders     string `json:"allowheaders,omitempty"`
+	AllowCredentials *bool  `json:"allowcredentials,omitempty"`
+	MaxAge           int    `json:"maxage,omitempty"`
+}
+
+// IngressRateLimitParams limits the requests and connections per client ip address
+type IngressRateLimitParams struct {
+	RPS             int      `json:"rps,omitempty"`
+	RPM             int      `json:"rpm,omitempty"`
+	Connections     int      `json:"connections,omitempty"`
+	BurstMultiplier int      `json:"burstmultiplier,omitempty"`
+	Whitelist       []string `json:"whitelist,omitempty"`
+}
+
+// IngressAuthParams requires authentication, either basic auth with the htpasswd file in an existing secret or by an external service
+type IngressAuthParams struct {
+	Type            string   `json:"type,omitempty"`
+	Secret          string   `json:"secret,omitempty"`
+	Realm           string   `json:"realm,omitempty"`
+	URL             string   `json:"url,omitempty"`
+	SigninURL       string   `json:"signinurl,omitempty"`
+	ResponseHeaders []string `json:"responseheaders,omitempty"`
+}
+
 // CPUParams sets cpu request and limit values
 type CPUParams struct {
 	Request string `json:"request,omitempty"`
@@ -91,10 +215,52 @@ type MemoryParams struct {
 
 // AutoscaleParams controls autoscaling
 type AutoscaleParams struct {
-	MinReplicas   int                   `json:"min,omitempty"`
-	MaxReplicas   int                   `json:"max,omitempty"`
-	CPUPercentage int                   `json:"cpu,omitempty"`
-	Safety        AutoscaleSafetyParams `json:"safety,omitempty"`
+	MinReplicas      int                     `json:"min,omitempty"`
+	MaxReplicas      int                     `json:"max,omitempty"`
+	CPUPercentage    int                     `json:"cpu,omitempty"`
+	MemoryPercentage int                     `json:"memory,omitempty"`
+	Metrics          []AutoscaleMetricParams `json:"metrics,omitempty"`
+	Behavior         AutoscaleBehaviorParams `json:"behavior,omitempty"`
+	Safety           AutoscaleSafetyParams   `json:"safety,omitempty"`
+}
+
+// AutoscaleMetricParams scales on a custom or external metric in addition to cpu and memory utilization; pods metrics are averaged over
+// the pods of the application, object metrics describe another kubernetes object and external metrics come from outside the cluster,
+// like the backlog of a Pub/Sub subscription
+type AutoscaleMetricParams struct {
+	Type       string                      `json:"type,omitempty"`
+	Name       string                      `json:"name,omitempty"`
+	Selector   map[string]string           `json:"selector,omitempty"`
+	Target     string                      `json:"target,omitempty"`
+	TargetType string                      `json:"targettype,omitempty"`
+	Object     AutoscaleMetricObjectParams `json:"object,omitempty"`
+}
+
+// AutoscaleMetricObjectParams refers to the kubernetes object an object metric describes
+type AutoscaleMetricObjectParams struct {
+	APIVersion string `json:"apiversion,omitempty"`
+	Kind       string `json:"kind,omitempty"`
+	Name       string `json:"name,omitempty"`
+}
+
+// AutoscaleBehaviorParams controls how fast the autoscaler scales up and down
+type AutoscaleBehaviorParams struct {
+	ScaleUp   *AutoscaleScalingRulesParams `json:"scaleup,omitempty"`
+	ScaleDown *AutoscaleScalingRulesParams `json:"scaledown,omitempty"`
+}
+
+// AutoscaleScalingRulesParams limits the change in replicas per period; with multiple policies selectpolicy determines which one applies
+type AutoscaleScalingRulesParams struct {
+	StabilizationWindowSeconds *int                      `json:"stabilizationwindowseconds,omitempty"`
+	SelectPolicy               string                    `json:"selectpolicy,omitempty"`
+	Policies                   []AutoscalingPolicyParams `json:"policies,omitempty"`
+}
+
+// AutoscalingPolicyParams allows a change of value pods or percent of the current replicas per period
+type AutoscalingPolicyParams struct {
+	Type          string `json:"type,omitempty"`
+	Value         int    `json:"value,omitempty"`
+	PeriodSeconds int    `json:"periodseconds,omitempty"`
 }
 
 // AutoscaleSafetyParams configures the autoscaler to use estafette-hpa-scaler as a safety net
@@ -106,21 +272,108 @@ type AutoscaleSafetyParams struct {
 	ScaleDownRatio float64 `json:"scaledownratio,string,omitempty"`
 }
 
+// PodDisruptionBudgetParams limits the number of pods that can be down at the same time during voluntary disruptions like node upgrades;
+// either minavailable or maxunavailable can be set, as a number or percentage
+type PodDisruptionBudgetParams struct {
+	Enabled        *bool  `json:"enabled,omitempty"`
+	MinAvailable   string `json:"minavailable,omitempty"`
+	MaxUnavailable string `json:"maxunavailable,omitempty"`
+}
+
+// NetworkPolicyParams restricts the traffic to the pods to what's needed for the visibility, metrics scraping and the declared clients;
+// egress is only restricted once any egress destination is declared
+type NetworkPolicyParams struct {
+	Enabled                     bool                        `json:"enabled,omitempty"`
+	IngressControllerNamespaces []string                    `json:"ingresscontrollernamespaces,omitempty"`
+	PrometheusNamespace         string                      `json:"prometheusnamespace,omitempty"`
+	GCELoadBalancerRanges       []string                    `json:"gceloadbalancerranges,omitempty"`
+	AllowedClients              []NetworkPolicyPeerParams   `json:"allowedclients,omitempty"`
+	Egress                      []NetworkPolicyEgressParams `json:"egress,omitempty"`
+}
+
+// NetworkPolicyPeerParams selects pods by app label, namespace or both
+type NetworkPolicyPeerParams struct {
+	App       string `json:"app,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// NetworkPolicyEgressParams allows traffic to an app, namespace or ip range, optionally limited to a set of ports
+type NetworkPolicyEgressParams struct {
+	App       string                    `json:"app,omitempty"`
+	Namespace string                    `json:"namespace,omitempty"`
+	CIDR      string                    `json:"cidr,omitempty"`
+	Ports     []NetworkPolicyPortParams `json:"ports,omitempty"`
+}
+
+// NetworkPolicyPortParams is a port and protocol traffic is allowed for
+type NetworkPolicyPortParams struct {
+	Port     int    `json:"port,omitempty"`
+	Protocol string `json:"protocol,omitempty"`
+}
+
+// StatefulSetParams configures kind statefulset: pods get stable names through a headless service and their own volumes from the volume
+// claim templates, they're started one by one unless podmanagementpolicy is Parallel and a rolling update only replaces the pods with an
+// ordinal of at least partition
+type StatefulSetParams struct {
+	Replicas             int                         `json:"replicas,omitempty"`
+	PodManagementPolicy  string                      `json:"podmanagementpolicy,omitempty"`
+	Partition            *int                        `json:"partition,omitempty"`
+	VolumeClaimTemplates []VolumeClaimTemplateParams `json:"volumeclaimtemplates,omitempty"`
+}
+
+// VolumeClaimTemplateParams is a persistent volume claimed for each pod and mounted into the application container
+type VolumeClaimTemplateParams struct {
+	Name         string   `json:"name,omitempty"`
+	MountPath    string   `json:"mountpath,omitempty"`
+	Size         string   `json:"size,omitempty"`
+	StorageClass string   `json:"storageclass,omitempty"`
+	AccessModes  []string `json:"accessmodes,omitempty"`
+}
+
+// DaemonSetParams configures kind daemonset, which runs a pod on every node matching the scheduling params; node agents usually need
+// toleratealltaints to run on tainted node pools as well and hostpathvolumes to read logs or metrics of the node
+type DaemonSetParams struct {
+	HostNetwork       bool                   `json:"hostnetwork,omitempty"`
+	HostPathVolumes   []HostPathVolumeParams `json:"hostpathvolumes,omitempty"`
+	UpdateStrategy    string                 `json:"updatestrategy,omitempty"`
+	MaxUnavailable    string                 `json:"maxunavailable,omitempty"`
+	TolerateAllTaints bool                   `json:"toleratealltaints,omitempty"`
+}
+
+// HostPathVolumeParams is a file or directory of the node mounted into the application container
+type HostPathVolumeParams struct {
+	Name      string `json:"name,omitempty"`
+	HostPath  string `json:"hostpath,omitempty"`
+	MountPath string `json:"mountpath,omitempty"`
+	Type      string `json:"type,omitempty"`
+	ReadOnly  bool   `json:"readonly,omitempty"`
+}
+
 // RequestParams controls timeouts, max body size, etc
 type RequestParams struct {
 	Timeout              string `json:"timeout,omitempty"`
+	Retries              *int   `json:"retries,omitempty"`
+	RetryOn              string `json:"retryon,omitempty"`
 	MaxBodySize          string `json:"maxbodysize,omitempty"`
 	ProxyBufferSize      string `json:"proxybuffersize,omitempty"`
 	ProxyBuffersNumber   int    `json:"proxybuffersnumber,omitempty"`
 	ClientBodyBufferSize string `json:"clientbodybuffersize,omitempty"`
 }
 
-// ProbeParams sets params for liveness or readiness probe
+// ProbeParams sets params for liveness, readiness or startup probe; the type determines which of path, port, scheme, command and service
+// are used
 type ProbeParams struct {
-	Path                string `json:"path,omitempty"`
-	Port                int    `json:"port,omitempty"`
-	InitialDelaySeconds int    `json:"delay,omitempty"`
-	TimeoutSeconds      int    `json:"timeout,omitempty"`
+	Type                string   `json:"type,omitempty"`
+	Path                string   `json:"path,omitempty"`
+	Port                int      `json:"port,omitempty"`
+	Scheme              string   `json:"scheme,omitempty"`
+	Command             []string `json:"command,omitempty"`
+	Service             string   `json:"service,omitempty"`
+	InitialDelaySeconds int      `json:"delay,omitempty"`
+	TimeoutSeconds      int      `json:"timeout,omitempty"`
+	PeriodSeconds       int      `json:"period,omitempty"`
+	FailureThreshold    int      `json:"failurethreshold,omitempty"`
+	SuccessThreshold    int      `json:"successthreshold,omitempty"`
 }
 
 // MetricsParams sets params for scraping prometheus metrics
@@ -138,15 +391,29 @@ type LifecycleParams struct {
 
 // SidecarParams sets params for sidecar injection
 type SidecarParams struct {
-	Type                              string                 `json:"type,omitempty"`
-	Image                             string                 `json:"image,omitempty"`
-	EnvironmentVariables              map[string]interface{} `json:"env,omitempty"`
-	CPU                               CPUParams              `json:"cpu,omitempty"`
-	Memory                            MemoryParams           `json:"memory,omitempty"`
-	HealthCheckPath                   string                 `json:"healthcheckpath,omitempty"`
-	DbInstanceConnectionName          string                 `json:"dbinstanceconnectionname,omitempty"`
-	SQLProxyPort                      int                    `json:"sqlproxyport,omitempty"`
-	SQLProxyTerminationTimeoutSeconds int                    `json:"sqlproxyterminationtimeoutseconds,omitempty"`
+	Type                              string                         `json:"type,omitempty"`
+	Image                             string                         `json:"image,omitempty"`
+	EnvironmentVariables              map[string]interface{}         `json:"env,omitempty"`
+	CPU                               CPUParams                      `json:"cpu,omitempty"`
+	Memory                            MemoryParams                   `json:"memory,omitempty"`
+	HealthCheckPath                   string                         `json:"healthcheckpath,omitempty"`
+	DbInstanceConnectionName          string                         `json:"dbinstanceconnectionname,omitempty"`
+	SQLProxyPort                      int                            `json:"sqlproxyport,omitempty"`
+	SQLProxyTerminationTimeoutSeconds int                            `json:"sqlproxyterminationtimeoutseconds,omitempty"`
+	SecurityContext                   ContainerSecurityContextParams `json:"securitycontext,omitempty"`
+}
+
+// InitContainerParams sets params for an init container that runs to completion before the application starts, like a database migration;
+// it mounts the same secrets, configs and volumes as the application container
+type InitContainerParams struct {
+	Name                 string                         `json:"name,omitempty"`
+	Image                string                         `json:"image,omitempty"`
+	Command              []string                       `json:"command,omitempty"`
+	Args                 []string                       `json:"args,omitempty"`
+	EnvironmentVariables map[string]interface{}         `json:"env,omitempty"`
+	CPU                  CPUParams                      `json:"cpu,omitempty"`
+	Memory               MemoryParams                   `json:"memory,omitempty"`
+	SecurityContext      ContainerSecurityContextParams `json:"securitycontext,omitempty"`
 }
 
 // RollingUpdateParams sets params for controlling rolling update speed
@@ -184,6 +451,66 @@ type VolumeMountParams struct {
 	Volume    map[string]interface{} `json:"volume,omitempty"`
 }
 
+// SchedulingParams controls on which nodes the pods get scheduled and how they're spread across them
+type SchedulingParams struct {
+	NodeSelector              map[string]string                `json:"nodeselector,omitempty"`
+	Tolerations               []TolerationParams               `json:"tolerations,omitempty"`
+	TopologySpreadConstraints []TopologySpreadConstraintParams `json:"topologyspreadconstraints,omitempty"`
+	AntiAffinity              AntiAffinityParams               `json:"antiaffinity,omitempty"`
+	PriorityClassName         string                           `json:"priorityclassname,omitempty"`
+}
+
+// TolerationParams allows pods to be scheduled on nodes with a matching taint, like the ones in a dedicated node pool
+type TolerationParams struct {
+	Key               string `json:"key,omitempty"`
+	Operator          string `json:"operator,omitempty"`
+	Value             string `json:"value,omitempty"`
+	Effect            string `json:"effect,omitempty"`
+	TolerationSeconds *int   `json:"tolerationseconds,omitempty"`
+}
+
+// TopologySpreadConstraintParams spreads the pods evenly across topology domains, like zones
+type TopologySpreadConstraintParams struct {
+	TopologyKey       string `json:"topologykey,omitempty"`
+	MaxSkew           int    `json:"maxskew,omitempty"`
+	WhenUnsatisfiable string `json:"whenunsatisfiable,omitempty"`
+}
+
+// AntiAffinityParams sets whether pods of the same application are kept apart as a preference, a requirement or not at all
+type AntiAffinityParams struct {
+	Type        string `json:"type,omitempty"`
+	TopologyKey string `json:"topologykey,omitempty"`
+	Weight      int    `json:"weight,omitempty"`
+}
+
+// SecurityContextParams sets the security context of the pod and its containers; the restricted preset defaults all unset properties to
+// secure values, so it can be switched on for all applications via the credential defaults
+type SecurityContextParams struct {
+	Preset    string                         `json:"preset,omitempty"`
+	Pod       PodSecurityContextParams       `json:"pod,omitempty"`
+	Container ContainerSecurityContextParams `json:"container,omitempty"`
+}
+
+// PodSecurityContextParams sets the security context shared by all containers in the pod
+type PodSecurityContextParams struct {
+	RunAsNonRoot   *bool  `json:"runasnonroot,omitempty"`
+	RunAsUser      *int   `json:"runasuser,omitempty"`
+	RunAsGroup     *int   `json:"runasgroup,omitempty"`
+	FSGroup        *int   `json:"fsgroup,omitempty"`
+	SeccompProfile string `json:"seccompprofile,omitempty"`
+}
+
+// ContainerSecurityContextParams sets the security context of a single container; the user settings override the ones of the pod
+type ContainerSecurityContextParams struct {
+	RunAsNonRoot             *bool    `json:"runasnonroot,omitempty"`
+	RunAsUser                *int     `json:"runasuser,omitempty"`
+	RunAsGroup               *int     `json:"runasgroup,omitempty"`
+	ReadOnlyRootFilesystem   *bool    `json:"readonlyrootfilesystem,omitempty"`
+	AllowPrivilegeEscalation *bool    `json:"allowprivilegeescalation,omitempty"`
+	DropCapabilities         []string `json:"dropcapabilities,omitempty"`
+	AddCapabilities          []string `json:"addcapabilities,omitempty"`
+}
+
 // BabysitterParams monitor the canary release and does rollout or rollback
 type BabysitterParams struct {
 	PrometheusAlerts []string `json:"prometheusalerts,omitempty"`
@@ -191,6 +518,67 @@ type BabysitterParams struct {
 	PrometheusToken  string   `json:"prometheustoken,omitempty"`
 }
 
+// ApplyEnvironment merges the environments entry for releaseName from the raw stage params over the already unmarshalled params;
+// nested objects are merged, but unlike in the merge of the stage params over the credential defaults all lists are replaced
+func (p *Params) ApplyEnvironment(paramsJSON []byte, releaseName string) (applied bool, err error) {
+
+	// the environments aren't needed anymore once the one for this release is applied
+	p.Environments = nil
+
+	if releaseName == "" {
+		return false, nil
+	}
+
+	var stageParams struct {
+		Environments map[string]json.RawMessage `json:"environments"`
+	}
+	err = json.Unmarshal(paramsJSON, &stageParams)
+	if err != nil {
+		return false, err
+	}
+
+	environmentJSON, ok := stageParams.Environments[releaseName]
+	if !ok {
+		return false, nil
+	}
+
+	// unmarshalling the environment straight into p would merge list items by index, so overlay the json and decode it into fresh params
+	baseJSON, err := json.Marshal(p)
+	if err != nil {
+		return false, err
+	}
+	var base, environment interface{}
+	err = json.Unmarshal(baseJSON, &base)
+	if err != nil {
+		return false, err
+	}
+	err = json.Unmarshal(environmentJSON, &environment)
+	if err != nil {
+		return false, err
+	}
+	if environment == nil {
+		return true, nil
+	}
+
+	overlaidJSON, err := json.Marshal(overlayParamsValues(base, environment, reflect.TypeOf(Params{})))
+	if err != nil {
+		return false, err
+	}
+	var overlaid Params
+	err = json.Unmarshal(overlaidJSON, &overlaid)
+	if err != nil {
+		return false, err
+	}
+
+	if overlaid.Environments != nil {
+		return true, fmt.Errorf("Environment %v has nested environments, which are not supported", releaseName)
+	}
+
+	*p = overlaid
+
+	return true, nil
+}
+
 // SetDefaults fills in empty fields with convention-based defaults
 func (p *Params) SetDefaults(gitName, appLabel, buildVersion, releaseName, releaseAction string, estafetteLabels map[string]string) {
 
@@ -203,6 +591,11 @@ func (p *Params) SetDefaults(gitName, appLabel, buildVersion, releaseName, relea
 		p.Action = "deploy-simple"
 	}
 
+	// default to only warn about unknown properties
+	if p.UnknownProperties == "" {
+		p.UnknownProperties = "warn"
+	}
+
 	// default kind to deployment
 	if p.Kind == "" {
 		p.Kind = "deployment"
@@ -247,6 +640,11 @@ func (p *Params) SetDefaults(gitName, appLabel, buildVersion, releaseName, relea
 		p.Visibility = "private"
 	}
 
+	// default protocol to http1
+	if p.Protocol == "" {
+		p.Protocol = "http1"
+	}
+
 	// set cpu defaults
 	cpuRequestIsEmpty := p.Container.CPU.Request == ""
 	if cpuRequestIsEmpty {
@@ -281,6 +679,22 @@ func (p *Params) SetDefaults(gitName, appLabel, buildVersion, releaseName, relea
 		}
 	}
 
+	// init containers run with the resources of the application container unless set, since the pod reserves the largest of both anyway
+	for i := range p.InitContainers {
+		if p.InitContainers[i].CPU.Request == "" {
+			p.InitContainers[i].CPU.Request = p.Container.CPU.Request
+		}
+		if p.InitContainers[i].CPU.Limit == "" {
+			p.InitContainers[i].CPU.Limit = p.Container.CPU.Limit
+		}
+		if p.InitContainers[i].Memory.Request == "" {
+			p.InitContainers[i].Memory.Request = p.Container.Memory.Request
+		}
+		if p.InitContainers[i].Memory.Limit == "" {
+			p.InitContainers[i].Memory.Limit = p.Container.Memory.Limit
+		}
+	}
+
 	// set container port defaults
 	if p.Container.Port <= 0 {
 		p.Container.Port = 5000
@@ -309,6 +723,81 @@ func (p *Params) SetDefaults(gitName, appLabel, buildVersion, releaseName, relea
 		p.Autoscale.CPUPercentage = 80
 	}
 
+	// set pod disruption budget defaults
+	if p.PodDisruptionBudget.Enabled == nil {
+		trueValue := true
+		p.PodDisruptionBudget.Enabled = &trueValue
+	}
+	if p.PodDisruptionBudget.MinAvailable == "" && p.PodDisruptionBudget.MaxUnavailable == "" {
+		p.PodDisruptionBudget.MaxUnavailable = "1"
+	}
+
+	// set certificate defaults
+	if p.Certificate.Provider == "" {
+		p.Certificate.Provider = "letsencrypt"
+	}
+	if p.Certificate.IssuerKind == "" {
+		p.Certificate.IssuerKind = "ClusterIssuer"
+	}
+
+	// set dns defaults, proxying public hosts through cloudflare, but not internal ones
+	if p.DNS.Public.Provider == "" {
+		p.DNS.Public.Provider = "cloudflare"
+	}
+	if p.DNS.Public.Proxy == nil {
+		trueValue := true
+		p.DNS.Public.Proxy = &trueValue
+	}
+	if p.DNS.Internal.Provider == "" {
+		p.DNS.Internal.Provider = "cloudflare"
+	}
+	if p.DNS.Internal.Proxy == nil {
+		falseValue := false
+		p.DNS.Internal.Proxy = &falseValue
+	}
+
+	// set mesh defaults
+	if len(p.Mesh.GatewaySelector) == 0 {
+		p.Mesh.GatewaySelector = map[string]string{"istio": "ingressgateway"}
+	}
+	if p.Mesh.GatewayNamespace == "" {
+		p.Mesh.GatewayNamespace = "istio-system"
+	}
+	if p.Mesh.CanaryWeight == 0 {
+		p.Mesh.CanaryWeight = 10
+	}
+
+	// set network policy defaults
+	if len(p.NetworkPolicy.IngressControllerNamespaces) == 0 {
+		p.NetworkPolicy.IngressControllerNamespaces = []string{"ingress-nginx"}
+	}
+	if p.NetworkPolicy.PrometheusNamespace == "" {
+		p.NetworkPolicy.PrometheusNamespace = "monitoring"
+	}
+	if len(p.NetworkPolicy.GCELoadBalancerRanges) == 0 {
+		p.NetworkPolicy.GCELoadBalancerRanges = []string{"35.191.0.0/16", "130.211.0.0/22"}
+	}
+	for i := range p.NetworkPolicy.Egress {
+		for j := range p.NetworkPolicy.Egress[i].Ports {
+			if p.NetworkPolicy.Egress[i].Ports[j].Protocol == "" {
+				p.NetworkPolicy.Egress[i].Ports[j].Protocol = "TCP"
+			}
+		}
+	}
+
+	for i := range p.Autoscale.Metrics {
+		if p.Autoscale.Metrics[i].TargetType == "" {
+			if p.Autoscale.Metrics[i].Type == "object" {
+				p.Autoscale.Metrics[i].TargetType = "Value"
+			} else {
+				p.Autoscale.Metrics[i].TargetType = "AverageValue"
+			}
+		}
+		if p.Autoscale.Metrics[i].Type == "object" && p.Autoscale.Metrics[i].Object.APIVersion == "" {
+			p.Autoscale.Metrics[i].Object.APIVersion = "v1"
+		}
+	}
+
 	if p.Autoscale.Safety.PromQuery == "" {
 		p.Autoscale.Safety.PromQuery = fmt.Sprintf("sum(rate(nginx_http_requests_total{app='%v'}[5m])) by (app)", p.App)
 	}
@@ -337,8 +826,17 @@ func (p *Params) SetDefaults(gitName, appLabel, buildVersion, releaseName, relea
 		p.Request.ClientBodyBufferSize = "8k"
 	}
 
+	// grpc services get grpc health probes by default
+	defaultProbeType := "http"
+	if p.Protocol == "grpc" {
+		defaultProbeType = "grpc"
+	}
+
 	// set liveness probe defaults
-	if p.Container.LivenessProbe.Path == "" {
+	if p.Container.LivenessProbe.Type == "" {
+		p.Container.LivenessProbe.Type = defaultProbeType
+	}
+	if p.Container.LivenessProbe.Type == "http" && p.Container.LivenessProbe.Path == "" {
 		p.Container.LivenessProbe.Path = "/liveness"
 	}
 	if p.Container.LivenessProbe.Port <= 0 {
@@ -352,7 +850,10 @@ func (p *Params) SetDefaults(gitName, appLabel, buildVersion, releaseName, relea
 	}
 
 	// set readiness probe defaults
-	if p.Container.ReadinessProbe.Path == "" {
+	if p.Container.ReadinessProbe.Type == "" {
+		p.Container.ReadinessProbe.Type = defaultProbeType
+	}
+	if p.Container.ReadinessProbe.Type == "http" && p.Container.ReadinessProbe.Path == "" {
 		p.Container.ReadinessProbe.Path = "/readiness"
 	}
 	if p.Container.ReadinessProbe.Port <= 0 {
@@ -362,6 +863,38 @@ func (p *Params) SetDefaults(gitName, appLabel, buildVersion, releaseName, relea
 		p.Container.ReadinessProbe.TimeoutSeconds = 1
 	}
 
+	// set startup probe defaults; it's only used when set, and checks the same as the liveness probe, but gives the application up to 5
+	// minutes to start before the liveness probe takes over
+	if p.Container.StartupProbe != nil {
+		if p.Container.StartupProbe.Type == "" {
+			p.Container.StartupProbe.Type = p.Container.LivenessProbe.Type
+			if p.Container.StartupProbe.Path == "" {
+				p.Container.StartupProbe.Path = p.Container.LivenessProbe.Path
+			}
+			if p.Container.StartupProbe.Scheme == "" {
+				p.Container.StartupProbe.Scheme = p.Container.LivenessProbe.Scheme
+			}
+			if len(p.Container.StartupProbe.Command) == 0 {
+				p.Container.StartupProbe.Command = p.Container.LivenessProbe.Command
+			}
+			if p.Container.StartupProbe.Service == "" {
+				p.Container.StartupProbe.Service = p.Container.LivenessProbe.Service
+			}
+		}
+		if p.Container.StartupProbe.Port <= 0 {
+			p.Container.StartupProbe.Port = p.Container.LivenessProbe.Port
+		}
+		if p.Container.StartupProbe.TimeoutSeconds <= 0 {
+			p.Container.StartupProbe.TimeoutSeconds = 1
+		}
+		if p.Container.StartupProbe.PeriodSeconds <= 0 {
+			p.Container.StartupProbe.PeriodSeconds = 10
+		}
+		if p.Container.StartupProbe.FailureThreshold <= 0 {
+			p.Container.StartupProbe.FailureThreshold = 30
+		}
+	}
+
 	// set metrics defaults
 	if p.Container.Metrics.Path == "" {
 		p.Container.Metrics.Path = "/metrics"
@@ -384,9 +917,34 @@ func (p *Params) SetDefaults(gitName, appLabel, buildVersion, releaseName, relea
 		p.Container.Lifecycle.PrestopSleepSeconds = &defaultSleepValue
 	}
 
-	if p.InjectHTTPProxySidecar == nil {
+	// set security context defaults; without a preset no security context is set, leaving it to the image and cluster defaults
+	if p.SecurityContext.Preset == "" {
+		p.SecurityContext.Preset = "none"
+	}
+	if p.SecurityContext.Preset == "restricted" {
 		trueValue := true
-		p.InjectHTTPProxySidecar = &trueValue
+		falseValue := false
+		if p.SecurityContext.Pod.RunAsNonRoot == nil {
+			p.SecurityContext.Pod.RunAsNonRoot = &trueValue
+		}
+		if p.SecurityContext.Pod.SeccompProfile == "" {
+			p.SecurityContext.Pod.SeccompProfile = "RuntimeDefault"
+		}
+		if p.SecurityContext.Container.ReadOnlyRootFilesystem == nil {
+			p.SecurityContext.Container.ReadOnlyRootFilesystem = &trueValue
+		}
+		if p.SecurityContext.Container.AllowPrivilegeEscalation == nil {
+			p.SecurityContext.Container.AllowPrivilegeEscalation = &falseValue
+		}
+		if p.SecurityContext.Container.DropCapabilities == nil {
+			p.SecurityContext.Container.DropCapabilities = []string{"ALL"}
+		}
+	}
+
+	// the mesh provides tls between the gateway and the pods, so the openresty sidecar is only injected without it
+	if p.InjectHTTPProxySidecar == nil {
+		injectHTTPProxySidecar := p.Mesh.Type != "istio"
+		p.InjectHTTPProxySidecar = &injectHTTPProxySidecar
 	}
 
 	// Code for backwards-compatibility: in the parameters the sidecar can be specified both in the "sidecar" field, and also as an element in the "sidecars" collection.
@@ -400,8 +958,8 @@ func (p *Params) SetDefaults(gitName, appLabel, buildVersion, releaseName, relea
 		}
 	}
 
-	// If the openresty sidecar is not specified either in the "sidecar" field, nor in the "sidecars" collection (and this is not a Job), and injecting the proxy is not explicitly disabled, we inject one by default.
-	if *p.InjectHTTPProxySidecar && !legacyOpenrestySidecarSpecified && !openrestySidecarSpecifiedInList && p.Kind != "job" {
+	// If the openresty sidecar is not specified either in the "sidecar" field, nor in the "sidecars" collection (and this is not a Job or StatefulSet), and injecting the proxy is not explicitly disabled, we inject one by default.
+	if *p.InjectHTTPProxySidecar && !legacyOpenrestySidecarSpecified && !openrestySidecarSpecifiedInList && p.Kind != "job" && p.Kind != "statefulset" && p.Kind != "daemonset" {
 		openrestySidecar := SidecarParams{Type: "openresty"}
 
 		p.initializeSidecarDefaults(&openrestySidecar)
@@ -415,11 +973,22 @@ func (p *Params) SetDefaults(gitName, appLabel, buildVersion, releaseName, relea
 		p.initializeSidecarDefaults(p.Sidecars[i])
 	}
 
+	for i := range p.InitContainers {
+		p.initializeContainerSecurityContextDefaults(&p.InitContainers[i].SecurityContext)
+	}
+
 	// default basepath to /
 	if p.Basepath == "" {
 		p.Basepath = "/"
 	}
 
+	// routes to the application go to the same port as the basepath
+	for i := range p.Routes {
+		if p.Routes[i].Port == "" && p.Routes[i].Service == "" {
+			p.Routes[i].Port = "https"
+		}
+	}
+
 	// defaults for rollingupdate
 	if p.RollingUpdate.MaxSurge == "" {
 		p.RollingUpdate.MaxSurge = "25%"
@@ -459,14 +1028,67 @@ func (p *Params) SetDefaults(gitName, appLabel, buildVersion, releaseName, relea
 		}
 	}
 
+	if p.StatefulSet.Replicas == 0 {
+		p.StatefulSet.Replicas = 1
+	}
+	if p.StatefulSet.PodManagementPolicy == "" {
+		p.StatefulSet.PodManagementPolicy = "OrderedReady"
+	}
+	for i := range p.StatefulSet.VolumeClaimTemplates {
+		if len(p.StatefulSet.VolumeClaimTemplates[i].AccessModes) == 0 {
+			p.StatefulSet.VolumeClaimTemplates[i].AccessModes = []string{"ReadWriteOnce"}
+		}
+	}
+
+	if p.DaemonSet.UpdateStrategy == "" {
+		p.DaemonSet.UpdateStrategy = "RollingUpdate"
+	}
+	if p.DaemonSet.UpdateStrategy == "RollingUpdate" && p.DaemonSet.MaxUnavailable == "" {
+		p.DaemonSet.MaxUnavailable = "1"
+	}
+
 	if p.Kind == "cronjob" {
 		if p.ConcurrencyPolicy == "" {
 			p.ConcurrencyPolicy = "Allow"
 		}
 	}
+
+	// set scheduling defaults; pods of a job run one at a time, so they don't need to be kept apart by default
+	if p.Scheduling.AntiAffinity.Type == "" {
+		if p.Kind == "job" || p.Kind == "cronjob" {
+			p.Scheduling.AntiAffinity.Type = "none"
+		} else {
+			p.Scheduling.AntiAffinity.Type = "preferred"
+		}
+	}
+	if p.Scheduling.AntiAffinity.TopologyKey == "" {
+		p.Scheduling.AntiAffinity.TopologyKey = "kubernetes.io/hostname"
+	}
+	if p.Scheduling.AntiAffinity.Weight == 0 && p.Scheduling.AntiAffinity.Type == "preferred" {
+		p.Scheduling.AntiAffinity.Weight = 100
+	}
+	for i := range p.Scheduling.Tolerations {
+		if p.Scheduling.Tolerations[i].Operator == "" {
+			p.Scheduling.Tolerations[i].Operator = "Equal"
+		}
+	}
+	for i := range p.Scheduling.TopologySpreadConstraints {
+		if p.Scheduling.TopologySpreadConstraints[i].TopologyKey == "" {
+			p.Scheduling.TopologySpreadConstraints[i].TopologyKey = "topology.kubernetes.io/zone"
+		}
+		if p.Scheduling.TopologySpreadConstraints[i].MaxSkew == 0 {
+			p.Scheduling.TopologySpreadConstraints[i].MaxSkew = 1
+		}
+		if p.Scheduling.TopologySpreadConstraints[i].WhenUnsatisfiable == "" {
+			p.Scheduling.TopologySpreadConstraints[i].WhenUnsatisfiable = "ScheduleAnyway"
+		}
+	}
 }
 
 func (p *Params) initializeSidecarDefaults(sidecar *SidecarParams) {
+	// sidecars get the same container security context as the application, unless overridden per sidecar
+	p.initializeContainerSecurityContextDefaults(&sidecar.SecurityContext)
+
 	switch sidecar.Type {
 	case "openresty":
 		if sidecar.Image == "" {
@@ -516,18 +1138,40 @@ func (p *Params) initializeSidecarDefaults(sidecar *SidecarParams) {
 	}
 }
 
+// RestrictIngressAnnotationAllowlist takes the allowlist for ingress annotations from the credential defaults only, so a manifest can't
+// allow its own annotations; it returns a warning if the manifest tried to
+func (p *Params) RestrictIngressAnnotationAllowlist(defaults *Params) []ValidationError {
+
+	var allowedAnnotations []string
+	if defaults != nil {
+		allowedAnnotations = defaults.Ingress.AllowedAnnotations
+	}
+
+	warnings := []ValidationError{}
+	if strings.Join(p.Ingress.AllowedAnnotations, ",") != strings.Join(allowedAnnotations, ",") {
+		warnings = append(warnings, newValidationWarning("ingress.allowedannotations", "not-allowed", "Ingress allowed annotations can only be set in the credential defaults; the value set on this stage is ignored"))
+	}
+	p.Ingress.AllowedAnnotations = allowedAnnotations
+
+	return warnings
+}
+
 // ValidateRequiredProperties checks whether all needed properties are set
-func (p *Params) ValidateRequiredProperties() (bool, []error, []string) {
+func (p *Params) ValidateRequiredProperties() (bool, []ValidationError, []ValidationError) {
 
-	errors := []error{}
-	warnings := []string{}
+	errors := []ValidationError{}
+	warnings := []ValidationError{}
 
 	// validate app params
 	if p.App == "" {
-		errors = append(errors, fmt.Errorf("Application name is required; either define an app label or use app property on this stage"))
+		errors = append(errors, newValidationError("app", "required", "Application name is required; either define an app label or use app property on this stage"))
 	}
 	if p.Namespace == "" {
-		errors = append(errors, fmt.Errorf("Namespace is required; either use credentials with a defaultNamespace or set it via namespace property on this stage"))
+		errors = append(errors, newValidationError("namespace", "required", "Namespace is required; either use credentials with a defaultNamespace or set it via namespace property on this stage"))
+	}
+
+	if p.UnknownProperties != "warn" && p.UnknownProperties != "error" {
+		errors = append(errors, newValidationError("unknownproperties", "invalid-value", "Unknownproperties property is invalid; allowed values are warn or error"))
 	}
 
 	if p.Action == "rollback-canary" {
@@ -537,47 +1181,95 @@ func (p *Params) ValidateRequiredProperties() (bool, []error, []string) {
 
 	// validate container params
 	if p.Container.ImageRepository == "" {
-		errors = append(errors, fmt.Errorf("Image repository is required; set it via container.repository property on this stage"))
+		errors = append(errors, newValidationError("container.repository", "required", "Image repository is required; set it via container.repository property on this stage"))
 	}
 	if p.Container.ImageName == "" {
-		errors = append(errors, fmt.Errorf("Image name is required; set it via container.name property on this stage"))
+		errors = append(errors, newValidationError("container.name", "required", "Image name is required; set it via container.name property on this stage"))
 	}
 	if p.Container.ImageTag == "" {
-		errors = append(errors, fmt.Errorf("Image tag is required; set it via container.tag property on this stage"))
+		errors = append(errors, newValidationError("container.tag", "required", "Image tag is required; set it via container.tag property on this stage"))
 	}
 
 	// validate cpu params
 	if p.Container.CPU.Request == "" {
-		errors = append(errors, fmt.Errorf("Cpu request is required; set it via container.cpu.request property on this stage"))
+		errors = append(errors, newValidationError("container.cpu.request", "required", "Cpu request is required; set it via container.cpu.request property on this stage"))
 	}
 	if p.Container.CPU.Limit == "" {
-		errors = append(errors, fmt.Errorf("Cpu limit is required; set it via container.cpu.limit property on this stage"))
+		errors = append(errors, newValidationError("container.cpu.limit", "required", "Cpu limit is required; set it via container.cpu.limit property on this stage"))
 	}
 
 	// validate memory params
 	if p.Container.Memory.Request == "" {
-		errors = append(errors, fmt.Errorf("Memory request is required; set it via container.memory.request property on this stage"))
+		errors = append(errors, newValidationError("container.memory.request", "required", "Memory request is required; set it via container.memory.request property on this stage"))
 	}
 	if p.Container.Memory.Limit == "" {
-		errors = append(errors, fmt.Errorf("Memory limit is required; set it via container.memory.limit property on this stage"))
+		errors = append(errors, newValidationError("container.memory.limit", "required", "Memory limit is required; set it via container.memory.limit property on this stage"))
 	}
 
+	// validate cpu and memory values are valid quantities
+	errors = validateResourceQuantities(errors, "Cpu", "container.cpu", p.Container.CPU.Request, p.Container.CPU.Limit)
+	errors = validateResourceQuantities(errors, "Memory", "container.memory", p.Container.Memory.Request, p.Container.Memory.Limit)
+
+	// validate environment variables that reference secrets, configmaps or pod fields
+	errors = p.validateEnvironmentVariables(errors, "container.env", p.Container.EnvironmentVariables)
+
 	// defaults for rollingupdate
 	if p.RollingUpdate.MaxSurge == "" {
-		errors = append(errors, fmt.Errorf("Rollingupdate max surge is required; set it via rollingupdate.maxsurge property on this stage"))
+		errors = append(errors, newValidationError("rollingupdate.maxsurge", "required", "Rollingupdate max surge is required; set it via rollingupdate.maxsurge property on this stage"))
 	}
 	if p.RollingUpdate.MaxUnavailable == "" {
-		errors = append(errors, fmt.Errorf("Rollingupdate max unavailable is required; set it via rollingupdate.maxunavailable property on this stage"))
+		errors = append(errors, newValidationError("rollingupdate.maxunavailable", "required", "Rollingupdate max unavailable is required; set it via rollingupdate.maxunavailable property on this stage"))
+	}
+	if p.RollingUpdate.MaxSurge != "" && p.RollingUpdate.MaxUnavailable != "" {
+		maxSurge, _, maxSurgeErr := parseIntOrPercentage(p.RollingUpdate.MaxSurge)
+		if maxSurgeErr != nil {
+			errors = append(errors, newValidationError("rollingupdate.maxsurge", "invalid-value", "Rollingupdate max surge is invalid: %v; set an integer or percentage via rollingupdate.maxsurge property on this stage", maxSurgeErr))
+		}
+		maxUnavailable, _, maxUnavailableErr := parseIntOrPercentage(p.RollingUpdate.MaxUnavailable)
+		if maxUnavailableErr != nil {
+			errors = append(errors, newValidationError("rollingupdate.maxunavailable", "invalid-value", "Rollingupdate max unavailable is invalid: %v; set an integer or percentage via rollingupdate.maxunavailable property on this stage", maxUnavailableErr))
+		}
+		if maxSurgeErr == nil && maxUnavailableErr == nil && maxSurge == 0 && maxUnavailable == 0 {
+			errors = append(errors, newValidationError("rollingupdate", "invalid-value", "Rollingupdate max surge and max unavailable can't both be zero, otherwise the rolling update can't make progress; set either of them via rollingupdate.maxsurge or rollingupdate.maxunavailable property on this stage"))
+		}
+	}
+
+	// validate init containers, which apply to jobs as well
+	errors = p.validateInitContainers(errors)
+
+	// validate scheduling params, which apply to jobs as well
+	errors = p.validateScheduling(errors)
+
+	// validate security context params, which apply to jobs as well
+	errors = p.validateSecurityContext(errors)
+
+	// validate network policy params, which apply to jobs as well
+	errors = p.validateNetworkPolicy(errors)
+
+	if p.Kind == "statefulset" {
+		errors = p.validateStatefulSet(errors)
+
+		// statefulsets aren't exposed through an ingress or load balancer, so the params for incoming requests don't apply
+		return len(errors) == 0, errors, warnings
+	}
+
+	if p.Kind == "daemonset" {
+		errors, warnings = p.validateDaemonSet(errors, warnings)
+
+		// daemonsets run node agents that aren't exposed through a service, so the params for incoming requests don't apply
+		return len(errors) == 0, errors, warnings
 	}
 
 	if p.Kind == "job" || p.Kind == "cronjob" {
 		if p.Kind == "cronjob" {
 			if p.Schedule == "" {
-				errors = append(errors, fmt.Errorf("Schedule is required for a cronjob; set it via schedule property on this stage"))
+				errors = append(errors, newValidationError("schedule", "required", "Schedule is required for a cronjob; set it via schedule property on this stage"))
+			} else if err := validateCronSchedule(p.Schedule); err != nil {
+				errors = append(errors, newValidationError("schedule", "invalid-schedule", "Schedule '%v' is invalid: %v; set a valid cron schedule via schedule property on this stage", p.Schedule, err))
 			}
 
 			if p.ConcurrencyPolicy != "Allow" && p.ConcurrencyPolicy != "Forbid" && p.ConcurrencyPolicy != "Replace" {
-				errors = append(errors, fmt.Errorf("ConcurrencyPolicy is invalid; allowed values are Allow, Forbid or Replace"))
+				errors = append(errors, newValidationError("concurrencypolicy", "invalid-value", "ConcurrencyPolicy is invalid; allowed values are Allow, Forbid or Replace"))
 			}
 		}
 
@@ -587,186 +1279,955 @@ func (p *Params) ValidateRequiredProperties() (bool, []error, []string) {
 
 	// validate params with respect to incoming requests
 	if p.Visibility == "" || (p.Visibility != "private" && p.Visibility != "public" && p.Visibility != "iap" && p.Visibility != "public-whitelist") {
-		errors = append(errors, fmt.Errorf("Visibility property is required; set it via visibility property on this stage; allowed values are private, iap, public-whitelist or public"))
+		errors = append(errors, newValidationError("visibility", "invalid-value", "Visibility property is required; set it via visibility property on this stage; allowed values are private, iap, public-whitelist or public"))
+	}
+	if p.Protocol != "" && p.Protocol != "http1" && p.Protocol != "http2" && p.Protocol != "grpc" {
+		errors = append(errors, newValidationError("protocol", "invalid-value", "Protocol %v is invalid; allowed values are http1, http2 or grpc", p.Protocol))
 	}
 	if p.Visibility == "iap" && p.IapOauthCredentialsClientID == "" {
-		errors = append(errors, fmt.Errorf("With visibility 'iap' property iapOauthClientID is required; set it via iapOauthClientID property on this stage"))
+		errors = append(errors, newValidationError("iapOauthClientID", "required", "With visibility 'iap' property iapOauthClientID is required; set it via iapOauthClientID property on this stage"))
 	}
 	if p.Visibility == "iap" && p.IapOauthCredentialsClientSecret == "" {
-		errors = append(errors, fmt.Errorf("With visibility 'iap' property iapOauthClientSecret is required; set it via iapOauthClientSecret property on this stage"))
+		errors = append(errors, newValidationError("iapOauthClientSecret", "required", "With visibility 'iap' property iapOauthClientSecret is required; set it via iapOauthClientSecret property on this stage"))
 	}
 
 	if len(p.Hosts) == 0 {
-		errors = append(errors, fmt.Errorf("At least one host is required; set it via hosts array property on this stage"))
+		errors = append(errors, newValidationError("hosts", "required", "At least one host is required; set it via hosts array property on this stage"))
 	}
-	for _, host := range p.Hosts {
+	for i, host := range p.Hosts {
 		if len(host) > 253 {
-			errors = append(errors, fmt.Errorf("Host %v is longer than the allowed 253 characters, which is invalid for DNS; please shorten your host", host))
+			errors = append(errors, newValidationError(fmt.Sprintf("hosts[%v]", i), "invalid-host", "Host %v is longer than the allowed 253 characters, which is invalid for DNS; please shorten your host", host))
 			break
 		}
 
 		matchesInvalidChars, _ := regexp.MatchString("[^a-zA-Z0-9-.]", host)
 		if matchesInvalidChars {
-			errors = append(errors, fmt.Errorf("Host %v has invalid characters; only a-z, 0-9, - and . are allowed; please fix your host", host))
+			errors = append(errors, newValidationError(fmt.Sprintf("hosts[%v]", i), "invalid-host", "Host %v has invalid characters; only a-z, 0-9, - and . are allowed; please fix your host", host))
 		}
 
 		hostLabels := strings.Split(host, ".")
 		for _, label := range hostLabels {
 			if len(label) > 63 {
-				errors = append(errors, fmt.Errorf("Host %v has label %v - the parts between dots - that is longer than the allowed 63 characters, which is invalid for DNS; please shorten your host label", host, label))
+				errors = append(errors, newValidationError(fmt.Sprintf("hosts[%v]", i), "invalid-host", "Host %v has label %v - the parts between dots - that is longer than the allowed 63 characters, which is invalid for DNS; please shorten your host label", host, label))
 			}
 		}
 	}
 
-	for _, host := range p.InternalHosts {
+	for i, host := range p.InternalHosts {
 		if len(host) > 253 {
-			errors = append(errors, fmt.Errorf("Internal host %v is longer than the allowed 253 characters, which is invalid for DNS; please shorten your host", host))
+			errors = append(errors, newValidationError(fmt.Sprintf("internalhosts[%v]", i), "invalid-host", "Internal host %v is longer than the allowed 253 characters, which is invalid for DNS; please shorten your host", host))
 			break
 		}
 
 		matchesInvalidChars, _ := regexp.MatchString("[^a-zA-Z0-9-.]", host)
 		if matchesInvalidChars {
-			errors = append(errors, fmt.Errorf("Internal host %v has invalid characters; only a-z, 0-9, - and . are allowed; please fix your host", host))
+			errors = append(errors, newValidationError(fmt.Sprintf("internalhosts[%v]", i), "invalid-host", "Internal host %v has invalid characters; only a-z, 0-9, - and . are allowed; please fix your host", host))
 		}
 
 		hostLabels := strings.Split(host, ".")
 		for _, label := range hostLabels {
 			if len(label) > 63 {
-				errors = append(errors, fmt.Errorf("Internal host %v has label %v - the parts between dots - that is longer than the allowed 63 characters, which is invalid for DNS; please shorten your host label", host, label))
+				errors = append(errors, newValidationError(fmt.Sprintf("internalhosts[%v]", i), "invalid-host", "Internal host %v has label %v - the parts between dots - that is longer than the allowed 63 characters, which is invalid for DNS; please shorten your host label", host, label))
 			}
 		}
 	}
 
 	if p.Basepath == "" {
-		errors = append(errors, fmt.Errorf("Basepath property is required; set it via basepath property on this stage"))
+		errors = append(errors, newValidationError("basepath", "required", "Basepath property is required; set it via basepath property on this stage"))
 	}
+	errors, warnings = p.validateRoutes(errors, warnings)
+	errors, warnings = p.validateIngress(errors, warnings)
+	errors, warnings = p.validateCertificate(errors, warnings)
+	errors, warnings = p.validateDNS(errors, warnings)
+	errors, warnings = p.validateMesh(errors, warnings)
+
 	if p.Container.Port <= 0 {
-		errors = append(errors, fmt.Errorf("Container port must be larger than zero; set it via container.port property on this stage"))
+		errors = append(errors, newValidationError("container.port", "invalid-value", "Container port must be larger than zero; set it via container.port property on this stage"))
 	}
 
 	// validate autoscale params
 	if p.Autoscale.MinReplicas <= 0 {
-		errors = append(errors, fmt.Errorf("Autoscaling min replicas must be larger than zero; set it via autoscale.min property on this stage"))
+		errors = append(errors, newValidationError("autoscale.min", "invalid-value", "Autoscaling min replicas must be larger than zero; set it via autoscale.min property on this stage"))
 	}
 	if p.Autoscale.MaxReplicas <= 0 {
-		errors = append(errors, fmt.Errorf("Autoscaling max replicas must be larger than zero; set it via autoscale.max property on this stage"))
+		errors = append(errors, newValidationError("autoscale.max", "invalid-value", "Autoscaling max replicas must be larger than zero; set it via autoscale.max property on this stage"))
 	}
 	if p.Autoscale.CPUPercentage <= 0 {
-		errors = append(errors, fmt.Errorf("Autoscaling cpu percentage must be larger than zero; set it via autoscale.cpu property on this stage"))
-	}
-
-	// validate liveness params
-	if p.Container.LivenessProbe.Path == "" {
-		errors = append(errors, fmt.Errorf("Liveness path is required; set it via container.liveness.path property on this stage"))
-	}
-	if p.Container.LivenessProbe.Port <= 0 {
-		errors = append(errors, fmt.Errorf("Liveness port must be larger than zero; set it via container.liveness.port property on this stage"))
-	}
-	if p.Container.LivenessProbe.InitialDelaySeconds <= 0 {
-		errors = append(errors, fmt.Errorf("Liveness initial delay must be larger than zero; set it via container.liveness.delay property on this stage"))
-	}
-	if p.Container.LivenessProbe.TimeoutSeconds <= 0 {
-		errors = append(errors, fmt.Errorf("Liveness timeout must be larger than zero; set it via container.liveness.timeout property on this stage"))
+		errors = append(errors, newValidationError("autoscale.cpu", "invalid-value", "Autoscaling cpu percentage must be larger than zero; set it via autoscale.cpu property on this stage"))
 	}
+	errors, warnings = p.validateAutoscale(errors, warnings)
+	errors, warnings = p.validatePodDisruptionBudget(errors, warnings)
 
-	// validate readiness params
-	if p.Container.ReadinessProbe.Path == "" {
-		errors = append(errors, fmt.Errorf("Readiness path is required; set it via container.readiness.path property on this stage"))
-	}
-	if p.Container.ReadinessProbe.Port <= 0 {
-		errors = append(errors, fmt.Errorf("Readiness port must be larger than zero; set it via container.readiness.port property on this stage"))
-	}
-	if p.Container.ReadinessProbe.TimeoutSeconds <= 0 {
-		errors = append(errors, fmt.Errorf("Readiness timeout must be larger than zero; set it via container.readiness.timeout property on this stage"))
+	// validate probe params
+	errors = p.validateProbe(errors, "container.liveness", "Liveness", p.Container.LivenessProbe, true, false)
+	errors = p.validateProbe(errors, "container.readiness", "Readiness", p.Container.ReadinessProbe, false, true)
+	if p.Container.StartupProbe != nil {
+		errors = p.validateProbe(errors, "container.startup", "Startup", *p.Container.StartupProbe, false, false)
 	}
 
 	// validate metrics params
 	if p.Container.Metrics.Scrape == nil {
-		errors = append(errors, fmt.Errorf("Metrics scrape is required; set it via container.metrics.scrape property on this stage; allowed values are true or false"))
+		errors = append(errors, newValidationError("container.metrics.scrape", "required", "Metrics scrape is required; set it via container.metrics.scrape property on this stage; allowed values are true or false"))
 	}
 	if p.Container.Metrics.Scrape != nil && *p.Container.Metrics.Scrape {
 		if p.Container.Metrics.Path == "" {
-			errors = append(errors, fmt.Errorf("Metrics path is required; set it via container.metrics.path property on this stage"))
+			errors = append(errors, newValidationError("container.metrics.path", "required", "Metrics path is required; set it via container.metrics.path property on this stage"))
 		}
 		if p.Container.Metrics.Port <= 0 {
-			errors = append(errors, fmt.Errorf("Metrics port must be larger than zero; set it via container.metrics.port property on this stage"))
+			errors = append(errors, newValidationError("container.metrics.port", "invalid-value", "Metrics port must be larger than zero; set it via container.metrics.port property on this stage"))
 		}
 	}
 
 	// The "sidecar" field is deprecated, so it can be empty. But if it's specified, then we validate it.
 	if p.Sidecar.Type != "" && p.Sidecar.Type != "none" {
-		errors = p.validateSidecar(&p.Sidecar, errors)
-		warnings = append(warnings, "The sidecar field is deprecated, the sidecars list should be used instead.")
+		errors = p.validateSidecar(&p.Sidecar, "sidecar", errors)
+		warnings = append(warnings, newValidationWarning("sidecar", "deprecated", "The sidecar field is deprecated, the sidecars list should be used instead."))
 	}
 
 	// validate sidecars params
-	for _, sidecar := range p.Sidecars {
-		errors = p.validateSidecar(sidecar, errors)
+	for i, sidecar := range p.Sidecars {
+		errors = p.validateSidecar(sidecar, fmt.Sprintf("sidecars[%v]", i), errors)
 	}
 
 	return len(errors) == 0, errors, warnings
 }
 
-func (p *Params) validateSidecar(sidecar *SidecarParams, errors []error) []error {
+// ValidateUnknownProperties turns unknown properties into either errors or warnings, depending on the unknownproperties setting
+func (p *Params) ValidateUnknownProperties(unknownProperties []UnknownPropertyError) ([]ValidationError, []ValidationError) {
+
+	errors := []ValidationError{}
+	warnings := []ValidationError{}
+
+	for _, up := range unknownProperties {
+		if p.UnknownProperties == "error" {
+			errors = append(errors, newValidationError(up.Path, "unknown-property", up.Error()))
+		} else {
+			warnings = append(warnings, newValidationWarning(up.Path, "unknown-property", up.Error()))
+		}
+	}
+
+	return errors, warnings
+}
+
+func (p *Params) validateSidecar(sidecar *SidecarParams, path string, errors []ValidationError) []ValidationError {
 	switch sidecar.Type {
 	case "openresty":
 		break
 	case "cloudsqlproxy":
 		if sidecar.DbInstanceConnectionName == "" {
-			errors = append(errors, fmt.Errorf("The name of the DB instance used by this Cloud SQL Proxy is required; set it via sidecar.dbinstanceconnectionname property on this stage"))
+			errors = append(errors, newValidationError(path+".dbinstanceconnectionname", "required", "The name of the DB instance used by this Cloud SQL Proxy is required; set it via sidecar.dbinstanceconnectionname property on this stage"))
 		}
 		if sidecar.SQLProxyPort == 0 {
-			errors = append(errors, fmt.Errorf("The port on which the Cloud SQL Proxy listens is required; set it via sidecar.sqlproxyport property on this stage"))
+			errors = append(errors, newValidationError(path+".sqlproxyport", "required", "The port on which the Cloud SQL Proxy listens is required; set it via sidecar.sqlproxyport property on this stage"))
 		}
 	default:
-		errors = append(errors, fmt.Errorf("The sidecar type is incorrect; allowed values are openresty or cloudsqlproxy"))
+		errors = append(errors, newValidationError(path+".type", "invalid-value", "The sidecar type is incorrect; allowed values are openresty or cloudsqlproxy"))
 	}
 
 	if sidecar.Image == "" {
-		errors = append(errors, fmt.Errorf("Sidecar image is required; set it via sidecar.image property on this stage"))
+		errors = append(errors, newValidationError(path+".image", "required", "Sidecar image is required; set it via sidecar.image property on this stage"))
 	}
 
 	// validate sidecar cpu params
 	if sidecar.CPU.Request == "" {
-		errors = append(errors, fmt.Errorf("Sidecar cpu request is required; set it via sidecar.cpu.request property on this stage"))
+		errors = append(errors, newValidationError(path+".cpu.request", "required", "Sidecar cpu request is required; set it via sidecar.cpu.request property on this stage"))
 	}
 	if sidecar.CPU.Limit == "" {
-		errors = append(errors, fmt.Errorf("Sidecar cpu limit is required; set it via sidecar.cpu.limit property on this stage"))
+		errors = append(errors, newValidationError(path+".cpu.limit", "required", "Sidecar cpu limit is required; set it via sidecar.cpu.limit property on this stage"))
 	}
 
 	// validate sidecar memory params
 	if sidecar.Memory.Request == "" {
-		errors = append(errors, fmt.Errorf("Sidecar memory request is required; set it via sidecar.memory.request property on this stage"))
+		errors = append(errors, newValidationError(path+".memory.request", "required", "Sidecar memory request is required; set it via sidecar.memory.request property on this stage"))
 	}
 	if sidecar.Memory.Limit == "" {
-		errors = append(errors, fmt.Errorf("Sidecar memory limit is required; set it via sidecar.memory.limit property on this stage"))
+		errors = append(errors, newValidationError(path+".memory.limit", "required", "Sidecar memory limit is required; set it via sidecar.memory.limit property on this stage"))
 	}
 
+	// validate sidecar cpu and memory values are valid quantities
+	errors = validateResourceQuantities(errors, "Sidecar cpu", path+".cpu", sidecar.CPU.Request, sidecar.CPU.Limit)
+	errors = validateResourceQuantities(errors, "Sidecar memory", path+".memory", sidecar.Memory.Request, sidecar.Memory.Limit)
+
+	errors = p.validateEnvironmentVariables(errors, path+".env", sidecar.EnvironmentVariables)
+
 	return errors
 }
 
-// ReplaceOpenrestyTagWithDigest looks for a sidecar of type openresty and replaces the image tag with a digest
-func (p *Params) ReplaceOpenrestyTagWithDigest() {
+// validateResourceQuantities checks that request and limit are valid quantities and that the request doesn't exceed the limit; empty values are reported elsewhere
+func validateResourceQuantities(errors []ValidationError, resource, property, request, limit string) []ValidationError {
 
-	// see if there's a sidecar of type openresty
-	for _, s := range p.Sidecars {
-		if s.Type == "openresty" {
+	var requestValue, limitValue float64
+	var requestErr, limitErr error
 
-			logInfo("Replacing openresty sidecar image tag with digest...")
+	if request != "" {
+		requestValue, requestErr = parseQuantity(request)
+		if requestErr != nil {
+			errors = append(errors, newValidationError(property+".request", "invalid-quantity", "%v request is invalid: %v; set a value like 100m or 128Mi via %v.request property on this stage", resource, requestErr, property))
+		}
+	}
+	if limit != "" {
+		limitValue, limitErr = parseQuantity(limit)
+		if limitErr != nil {
+			errors = append(errors, newValidationError(property+".limit", "invalid-quantity", "%v limit is invalid: %v; set a value like 100m or 128Mi via %v.limit property on this stage", resource, limitErr, property))
+		}
+	}
 
-			if s.Image == "" {
-				return
-			}
+	if request != "" && limit != "" && requestErr == nil && limitErr == nil && requestValue > limitValue {
+		errors = append(errors, newValidationError(property, "request-exceeds-limit", "%v request %v is larger than limit %v; lower %v.request or raise %v.limit property on this stage", resource, request, limit, property, property))
+	}
 
-			imageDigestParts := strings.Split(s.Image, "@")
-			if len(imageDigestParts) > 1 {
-				// already uses a digest, skip replacement
-				return
-			}
+	return errors
+}
 
-			imageParts := strings.Split(s.Image, ":")
-			repository := imageParts[0]
-			tag := "latest"
+// validateEnvironmentVariables checks that environment variables set as object have exactly one valid secretKeyRef, configMapKeyRef, fieldRef or resourceFieldRef
+func (p *Params) validateEnvironmentVariables(errors []ValidationError, property string, environmentVariables map[string]interface{}) []ValidationError {
+
+	for _, name := range sortedKeys(environmentVariables) {
+		valueFrom, ok := environmentVariables[name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		path := joinPropertyPath(property, name)
+
+		if len(valueFrom) != 1 {
+			errors = append(errors, newValidationError(path, "invalid-value", "Environment variable %v should have either a literal value or exactly one of secretKeyRef, configMapKeyRef, fieldRef or resourceFieldRef; set it via %v property on this stage", name, path))
+			continue
+		}
+
+		for sourceType, source := range valueFrom {
+			requiredProperties, ok := environmentVariableSources[sourceType]
+			if !ok {
+				errors = append(errors, newValidationError(joinPropertyPath(path, sourceType), "invalid-value", "Environment variable %v has unsupported source %v; allowed sources are secretKeyRef, configMapKeyRef, fieldRef or resourceFieldRef", name, sourceType))
+				continue
+			}
+			sourceMap, ok := source.(map[string]interface{})
+			if !ok {
+				errors = append(errors, newValidationError(joinPropertyPath(path, sourceType), "invalid-value", "Environment variable %v has a %v that is not an object; set it via %v.%v property on this stage", name, sourceType, path, sourceType))
+				continue
+			}
+			for _, requiredProperty := range requiredProperties {
+				if value, ok := sourceMap[requiredProperty].(string); !ok || value == "" {
+					errors = append(errors, newValidationError(joinPropertyPath(path, sourceType+"."+requiredProperty), "required", "Environment variable %v requires %v.%v; set it via %v.%v.%v property on this stage", name, sourceType, requiredProperty, path, sourceType, requiredProperty))
+				}
+			}
+
+			// a secretKeyRef without name refers to the application secrets, so the key has to be one of them
+			if _, hasName := sourceMap["name"]; sourceType == "secretKeyRef" && !hasName {
+				if key, ok := sourceMap["key"].(string); ok && key != "" {
+					if _, ok := p.Secrets.Keys[key]; !ok {
+						errors = append(errors, newValidationError(joinPropertyPath(path, "secretKeyRef.key"), "invalid-value", "Environment variable %v refers to key %v of the application secrets, but there is no such key; add it via secrets.keys property or set secretKeyRef.name to use another secret", name, key))
+					}
+				}
+			}
+		}
+	}
+
+	return errors
+}
+
+// containerExposesPort checks whether port is either the main container port or one of the additional ports
+// validateProbe validates the liveness, readiness or startup probe at property; kubernetes only allows a success threshold above 1 for
+// readiness probes, which is what allowMultipleSuccesses is for
+func (p *Params) validateProbe(errors []ValidationError, property, name string, probe ProbeParams, requireInitialDelay, allowMultipleSuccesses bool) []ValidationError {
+
+	probeType := probe.Type
+	if probeType == "" {
+		probeType = "http"
+	}
+
+	switch probeType {
+	case "http", "tcp", "grpc":
+		if probeType == "http" && probe.Path == "" {
+			errors = append(errors, newValidationError(property+".path", "required", "%v path is required for an http probe; set it via %v.path property on this stage", name, property))
+		}
+		if probeType == "http" && probe.Scheme != "" && probe.Scheme != "HTTP" && probe.Scheme != "HTTPS" {
+			errors = append(errors, newValidationError(property+".scheme", "invalid-value", "%v scheme %v is invalid; allowed values are HTTP or HTTPS", name, probe.Scheme))
+		}
+		if probe.Port <= 0 {
+			errors = append(errors, newValidationError(property+".port", "invalid-value", "%v port must be larger than zero; set it via %v.port property on this stage", name, property))
+		} else if !p.containerExposesPort(probe.Port) {
+			errors = append(errors, newValidationError(property+".port", "port-not-exposed", "%v port %v is not exposed by the container; set it to container.port or one of the container.additionalports via %v.port property on this stage", name, probe.Port, property))
+		}
+	case "exec":
+		if len(probe.Command) == 0 {
+			errors = append(errors, newValidationError(property+".command", "required", "%v command is required for an exec probe; set it via %v.command property on this stage", name, property))
+		}
+	default:
+		errors = append(errors, newValidationError(property+".type", "invalid-value", "%v type %v is invalid; allowed values are http, tcp, exec or grpc", name, probe.Type))
+	}
+
+	if requireInitialDelay && probe.InitialDelaySeconds <= 0 {
+		errors = append(errors, newValidationError(property+".delay", "invalid-value", "%v initial delay must be larger than zero; set it via %v.delay property on this stage", name, property))
+	} else if probe.InitialDelaySeconds < 0 {
+		errors = append(errors, newValidationError(property+".delay", "invalid-value", "%v initial delay can't be negative; set it via %v.delay property on this stage", name, property))
+	}
+	if probe.TimeoutSeconds <= 0 {
+		errors = append(errors, newValidationError(property+".timeout", "invalid-value", "%v timeout must be larger than zero; set it via %v.timeout property on this stage", name, property))
+	}
+	if probe.PeriodSeconds < 0 {
+		errors = append(errors, newValidationError(property+".period", "invalid-value", "%v period can't be negative; set it via %v.period property on this stage", name, property))
+	} else if probe.PeriodSeconds > 0 && probe.TimeoutSeconds > probe.PeriodSeconds {
+		errors = append(errors, newValidationError(property+".timeout", "invalid-value", "%v timeout of %v seconds is longer than its period of %v seconds; lower it via %v.timeout property on this stage", name, probe.TimeoutSeconds, probe.PeriodSeconds, property))
+	}
+	if probe.FailureThreshold < 0 {
+		errors = append(errors, newValidationError(property+".failurethreshold", "invalid-value", "%v failure threshold can't be negative; set it via %v.failurethreshold property on this stage", name, property))
+	}
+	if probe.SuccessThreshold < 0 {
+		errors = append(errors, newValidationError(property+".successthreshold", "invalid-value", "%v success threshold can't be negative; set it via %v.successthreshold property on this stage", name, property))
+	} else if probe.SuccessThreshold > 1 && !allowMultipleSuccesses {
+		errors = append(errors, newValidationError(property+".successthreshold", "invalid-value", "%v success threshold must be 1; only a readiness probe can require more than one success", name))
+	}
+
+	return errors
+}
+
+func (p *Params) initializeContainerSecurityContextDefaults(securityContext *ContainerSecurityContextParams) {
+	if securityContext.RunAsNonRoot == nil {
+		securityContext.RunAsNonRoot = p.SecurityContext.Container.RunAsNonRoot
+	}
+	if securityContext.RunAsUser == nil {
+		securityContext.RunAsUser = p.SecurityContext.Container.RunAsUser
+	}
+	if securityContext.RunAsGroup == nil {
+		securityContext.RunAsGroup = p.SecurityContext.Container.RunAsGroup
+	}
+	if securityContext.ReadOnlyRootFilesystem == nil {
+		securityContext.ReadOnlyRootFilesystem = p.SecurityContext.Container.ReadOnlyRootFilesystem
+	}
+	if securityContext.AllowPrivilegeEscalation == nil {
+		securityContext.AllowPrivilegeEscalation = p.SecurityContext.Container.AllowPrivilegeEscalation
+	}
+	if securityContext.DropCapabilities == nil {
+		securityContext.DropCapabilities = p.SecurityContext.Container.DropCapabilities
+	}
+	if securityContext.AddCapabilities == nil {
+		securityContext.AddCapabilities = p.SecurityContext.Container.AddCapabilities
+	}
+}
+
+func (p *Params) validateSecurityContext(errors []ValidationError) []ValidationError {
+
+	if p.SecurityContext.Preset != "" && p.SecurityContext.Preset != "restricted" && p.SecurityContext.Preset != "none" {
+		errors = append(errors, newValidationError("securitycontext.preset", "invalid-value", "Security context preset %v is invalid; allowed values are restricted or none", p.SecurityContext.Preset))
+	}
+
+	pod := p.SecurityContext.Pod
+	if pod.SeccompProfile != "" && pod.SeccompProfile != "RuntimeDefault" && pod.SeccompProfile != "Unconfined" {
+		errors = append(errors, newValidationError("securitycontext.pod.seccompprofile", "invalid-value", "Security context seccomp profile %v is invalid; allowed values are RuntimeDefault or Unconfined", pod.SeccompProfile))
+	}
+	errors = validateSecurityContextUser(errors, "securitycontext.pod", pod.RunAsNonRoot, pod.RunAsUser, pod.RunAsGroup)
+	if pod.FSGroup != nil && *pod.FSGroup < 0 {
+		errors = append(errors, newValidationError("securitycontext.pod.fsgroup", "invalid-value", "Security context fs group can't be negative; set it via securitycontext.pod.fsgroup property on this stage"))
+	}
+
+	errors = validateSecurityContextUser(errors, "securitycontext.container", p.SecurityContext.Container.RunAsNonRoot, p.SecurityContext.Container.RunAsUser, p.SecurityContext.Container.RunAsGroup)
+	for i, sidecar := range p.Sidecars {
+		property := fmt.Sprintf("sidecars[%v].securitycontext", i)
+		errors = validateSecurityContextUser(errors, property, sidecar.SecurityContext.RunAsNonRoot, sidecar.SecurityContext.RunAsUser, sidecar.SecurityContext.RunAsGroup)
+	}
+
+	return errors
+}
+
+func validateSecurityContextUser(errors []ValidationError, property string, runAsNonRoot *bool, runAsUser, runAsGroup *int) []ValidationError {
+	if runAsUser != nil && *runAsUser < 0 {
+		errors = append(errors, newValidationError(property+".runasuser", "invalid-value", "Security context user can't be negative; set it via %v.runasuser property on this stage", property))
+	}
+	if runAsGroup != nil && *runAsGroup < 0 {
+		errors = append(errors, newValidationError(property+".runasgroup", "invalid-value", "Security context group can't be negative; set it via %v.runasgroup property on this stage", property))
+	}
+	if runAsNonRoot != nil && *runAsNonRoot && runAsUser != nil && *runAsUser == 0 {
+		errors = append(errors, newValidationError(property+".runasuser", "invalid-value", "Security context user 0 is root, which conflicts with runasnonroot; set a non-zero user via %v.runasuser property on this stage", property))
+	}
+	return errors
+}
+
+func (p *Params) validateAutoscale(errors, warnings []ValidationError) ([]ValidationError, []ValidationError) {
+
+	if p.Autoscale.MinReplicas > 0 && p.Autoscale.MaxReplicas > 0 && p.Autoscale.MinReplicas > p.Autoscale.MaxReplicas {
+		errors = append(errors, newValidationError("autoscale.min", "invalid-value", "Autoscaling min replicas %v can't be larger than max replicas %v; set them via autoscale.min and autoscale.max properties on this stage", p.Autoscale.MinReplicas, p.Autoscale.MaxReplicas))
+	}
+	if p.Autoscale.MemoryPercentage < 0 {
+		errors = append(errors, newValidationError("autoscale.memory", "invalid-value", "Autoscaling memory percentage can't be negative; set it via autoscale.memory property on this stage or leave it empty to not scale on memory"))
+	}
+
+	for i, metric := range p.Autoscale.Metrics {
+		property := fmt.Sprintf("autoscale.metrics[%v]", i)
+
+		if metric.Type != "pods" && metric.Type != "object" && metric.Type != "external" {
+			errors = append(errors, newValidationError(property+".type", "invalid-value", "Autoscaling metric type %v is invalid; allowed values are pods, object or external", metric.Type))
+		}
+		if metric.Name == "" {
+			errors = append(errors, newValidationError(property+".name", "required", "Autoscaling metric name is required; set it via %v.name property on this stage", property))
+		}
+		if metric.Target == "" {
+			errors = append(errors, newValidationError(property+".target", "required", "Autoscaling metric target is required; set it via %v.target property on this stage", property))
+		} else if target, err := parseQuantity(metric.Target); err != nil || target <= 0 {
+			errors = append(errors, newValidationError(property+".target", "invalid-value", "Autoscaling metric target %v is not a valid quantity larger than zero; set it via %v.target property on this stage", metric.Target, property))
+		}
+		if metric.TargetType != "Value" && metric.TargetType != "AverageValue" {
+			errors = append(errors, newValidationError(property+".targettype", "invalid-value", "Autoscaling metric target type %v is invalid; allowed values are Value or AverageValue", metric.TargetType))
+		} else if metric.Type == "pods" && metric.TargetType != "AverageValue" {
+			errors = append(errors, newValidationError(property+".targettype", "invalid-value", "Autoscaling pods metrics are averaged over the pods, so the only allowed target type is AverageValue"))
+		}
+		if metric.Type == "object" && (metric.Object.Kind == "" || metric.Object.Name == "") {
+			errors = append(errors, newValidationError(property+".object", "required", "Autoscaling object metric requires the kind and name of the object it describes; set them via %v.object.kind and %v.object.name properties on this stage", property, property))
+		}
+	}
+
+	errors = validateAutoscaleScalingRules(errors, "autoscale.behavior.scaleup", p.Autoscale.Behavior.ScaleUp)
+	errors = validateAutoscaleScalingRules(errors, "autoscale.behavior.scaledown", p.Autoscale.Behavior.ScaleDown)
+
+	// the estafette-hpa-scaler safety net raises min replicas based on the request rate, and lowers it again by at most the scale down ratio
+	if p.Autoscale.Safety.Enabled {
+		if p.Autoscale.Safety.Ratio <= 0 {
+			errors = append(errors, newValidationError("autoscale.safety.ratio", "invalid-value", "Autoscaling safety ratio must be larger than zero; set the number of requests per replica via autoscale.safety.ratio property on this stage"))
+		}
+		if p.Autoscale.Safety.ScaleDownRatio <= 0 || p.Autoscale.Safety.ScaleDownRatio > 1 {
+			errors = append(errors, newValidationError("autoscale.safety.scaledownratio", "invalid-value", "Autoscaling safety scale down ratio must be larger than zero and at most 1; set it via autoscale.safety.scaledownratio property on this stage"))
+		}
+		if p.Autoscale.MinReplicas > 0 && p.Autoscale.MinReplicas == p.Autoscale.MaxReplicas {
+			warnings = append(warnings, newValidationWarning("autoscale.safety.enabled", "ineffective", "Autoscaling safety can't change min replicas when it equals max replicas; raise autoscale.max or disable autoscale.safety"))
+		}
+		if p.Autoscale.Behavior.ScaleDown != nil && p.Autoscale.Behavior.ScaleDown.SelectPolicy == "Disabled" {
+			warnings = append(warnings, newValidationWarning("autoscale.behavior.scaledown.selectpolicy", "ineffective", "With scale down disabled the replicas never drop after autoscaling safety lowers min replicas again; enable scale down or disable autoscale.safety"))
+		}
+	}
+
+	return errors, warnings
+}
+
+func (p *Params) validatePodDisruptionBudget(errors, warnings []ValidationError) ([]ValidationError, []ValidationError) {
+
+	if p.PodDisruptionBudget.Enabled != nil && !*p.PodDisruptionBudget.Enabled {
+		return errors, warnings
+	}
+
+	if p.PodDisruptionBudget.MinAvailable != "" && p.PodDisruptionBudget.MaxUnavailable != "" {
+		errors = append(errors, newValidationError("pdb", "invalid-value", "Pdb can have either min available or max unavailable, not both; remove pdb.minavailable or pdb.maxunavailable property from this stage"))
+		return errors, warnings
+	}
+
+	if p.PodDisruptionBudget.MinAvailable != "" {
+		minAvailable, isPercentage, err := parseIntOrPercentage(p.PodDisruptionBudget.MinAvailable)
+		if err != nil {
+			errors = append(errors, newValidationError("pdb.minavailable", "invalid-value", "Pdb min available is invalid: %v; set an integer or percentage via pdb.minavailable property on this stage", err))
+		} else if (isPercentage && minAvailable >= 100) || (!isPercentage && p.Autoscale.MinReplicas > 0 && minAvailable >= p.Autoscale.MinReplicas) {
+			warnings = append(warnings, newValidationWarning("pdb.minavailable", "blocks-disruptions", "Pdb min available %v doesn't allow any pod to be evicted at min replicas, which blocks node upgrades; lower pdb.minavailable or raise autoscale.min", p.PodDisruptionBudget.MinAvailable))
+		}
+	}
+
+	if p.PodDisruptionBudget.MaxUnavailable != "" {
+		maxUnavailable, _, err := parseIntOrPercentage(p.PodDisruptionBudget.MaxUnavailable)
+		if err != nil {
+			errors = append(errors, newValidationError("pdb.maxunavailable", "invalid-value", "Pdb max unavailable is invalid: %v; set an integer or percentage via pdb.maxunavailable property on this stage", err))
+		} else if maxUnavailable == 0 {
+			warnings = append(warnings, newValidationWarning("pdb.maxunavailable", "blocks-disruptions", "Pdb max unavailable 0 doesn't allow any pod to be evicted, which blocks node upgrades; raise pdb.maxunavailable or disable the pdb via pdb.enabled"))
+		}
+	}
+
+	return errors, warnings
+}
+
+func validateAutoscaleScalingRules(errors []ValidationError, property string, rules *AutoscaleScalingRulesParams) []ValidationError {
+
+	if rules == nil {
+		return errors
+	}
+
+	if rules.StabilizationWindowSeconds != nil && (*rules.StabilizationWindowSeconds < 0 || *rules.StabilizationWindowSeconds > 3600) {
+		errors = append(errors, newValidationError(property+".stabilizationwindowseconds", "invalid-value", "Autoscaling stabilization window must be between 0 and 3600 seconds; set it via %v.stabilizationwindowseconds property on this stage", property))
+	}
+	if rules.SelectPolicy != "" && rules.SelectPolicy != "Max" && rules.SelectPolicy != "Min" && rules.SelectPolicy != "Disabled" {
+		errors = append(errors, newValidationError(property+".selectpolicy", "invalid-value", "Autoscaling select policy %v is invalid; allowed values are Max, Min or Disabled", rules.SelectPolicy))
+	}
+	for i, policy := range rules.Policies {
+		policyProperty := fmt.Sprintf("%v.policies[%v]", property, i)
+		if policy.Type != "Pods" && policy.Type != "Percent" {
+			errors = append(errors, newValidationError(policyProperty+".type", "invalid-value", "Autoscaling policy type %v is invalid; allowed values are Pods or Percent", policy.Type))
+		}
+		if policy.Value <= 0 {
+			errors = append(errors, newValidationError(policyProperty+".value", "invalid-value", "Autoscaling policy value must be larger than zero; set it via %v.value property on this stage", policyProperty))
+		}
+		if policy.PeriodSeconds <= 0 || policy.PeriodSeconds > 1800 {
+			errors = append(errors, newValidationError(policyProperty+".periodseconds", "invalid-value", "Autoscaling policy period must be between 1 and 1800 seconds; set it via %v.periodseconds property on this stage", policyProperty))
+		}
+	}
+
+	return errors
+}
+
+func (p *Params) validateInitContainers(errors []ValidationError) []ValidationError {
+
+	names := map[string]bool{}
+	for i, initContainer := range p.InitContainers {
+		property := fmt.Sprintf("initcontainers[%v]", i)
+
+		if initContainer.Name == "" {
+			errors = append(errors, newValidationError(property+".name", "required", "Init container name is required; set it via %v.name property on this stage", property))
+		} else if !containerNameRegex.MatchString(initContainer.Name) {
+			errors = append(errors, newValidationError(property+".name", "invalid-value", "Init container name %v is invalid; only lowercase a-z, 0-9 and - are allowed, starting and ending with a letter or digit", initContainer.Name))
+		} else if names[initContainer.Name] || initContainer.Name == p.App {
+			errors = append(errors, newValidationError(property+".name", "invalid-value", "Init container name %v is already used by another container in the pod; set a unique name via %v.name property on this stage", initContainer.Name, property))
+		}
+		names[initContainer.Name] = true
+
+		errors = validateResourceQuantities(errors, "Init container cpu", property+".cpu", initContainer.CPU.Request, initContainer.CPU.Limit)
+		errors = validateResourceQuantities(errors, "Init container memory", property+".memory", initContainer.Memory.Request, initContainer.Memory.Limit)
+
+		errors = p.validateEnvironmentVariables(errors, property+".env", initContainer.EnvironmentVariables)
+
+		errors = validateSecurityContextUser(errors, property+".securitycontext", initContainer.SecurityContext.RunAsNonRoot, initContainer.SecurityContext.RunAsUser, initContainer.SecurityContext.RunAsGroup)
+	}
+
+	return errors
+}
+
+func (p *Params) validateScheduling(errors []ValidationError) []ValidationError {
+
+	for key := range p.Scheduling.NodeSelector {
+		if key == "" {
+			errors = append(errors, newValidationError("scheduling.nodeselector", "invalid-value", "Scheduling node selector has an empty label key; set label keys and values via scheduling.nodeselector property on this stage"))
+		}
+	}
+
+	for i, toleration := range p.Scheduling.Tolerations {
+		property := fmt.Sprintf("scheduling.tolerations[%v]", i)
+		switch toleration.Operator {
+		case "Equal":
+			if toleration.Key == "" {
+				errors = append(errors, newValidationError(property+".key", "required", "Scheduling toleration key is required for operator Equal; set it via %v.key property on this stage", property))
+			}
+		case "Exists":
+			if toleration.Value != "" {
+				errors = append(errors, newValidationError(property+".value", "invalid-value", "Scheduling toleration value must be empty for operator Exists; remove %v.value property from this stage", property))
+			}
+		default:
+			errors = append(errors, newValidationError(property+".operator", "invalid-value", "Scheduling toleration operator %v is invalid; allowed values are Equal or Exists", toleration.Operator))
+		}
+		if toleration.Effect != "" && toleration.Effect != "NoSchedule" && toleration.Effect != "PreferNoSchedule" && toleration.Effect != "NoExecute" {
+			errors = append(errors, newValidationError(property+".effect", "invalid-value", "Scheduling toleration effect %v is invalid; allowed values are NoSchedule, PreferNoSchedule or NoExecute, or leave it empty to match all effects", toleration.Effect))
+		}
+		if toleration.TolerationSeconds != nil && toleration.Effect != "NoExecute" {
+			errors = append(errors, newValidationError(property+".tolerationseconds", "invalid-value", "Scheduling toleration seconds only apply to effect NoExecute; set %v.effect to NoExecute or remove %v.tolerationseconds", property, property))
+		}
+		if toleration.TolerationSeconds != nil && *toleration.TolerationSeconds < 0 {
+			errors = append(errors, newValidationError(property+".tolerationseconds", "invalid-value", "Scheduling toleration seconds can't be negative; set it via %v.tolerationseconds property on this stage", property))
+		}
+	}
+
+	for i, constraint := range p.Scheduling.TopologySpreadConstraints {
+		property := fmt.Sprintf("scheduling.topologyspreadconstraints[%v]", i)
+		if constraint.MaxSkew <= 0 {
+			errors = append(errors, newValidationError(property+".maxskew", "invalid-value", "Scheduling topology spread max skew must be larger than zero; set it via %v.maxskew property on this stage", property))
+		}
+		if constraint.WhenUnsatisfiable != "DoNotSchedule" && constraint.WhenUnsatisfiable != "ScheduleAnyway" {
+			errors = append(errors, newValidationError(property+".whenunsatisfiable", "invalid-value", "Scheduling topology spread whenunsatisfiable %v is invalid; allowed values are DoNotSchedule or ScheduleAnyway", constraint.WhenUnsatisfiable))
+		}
+	}
+
+	switch p.Scheduling.AntiAffinity.Type {
+	case "preferred":
+		if p.Scheduling.AntiAffinity.Weight < 1 || p.Scheduling.AntiAffinity.Weight > 100 {
+			errors = append(errors, newValidationError("scheduling.antiaffinity.weight", "invalid-value", "Scheduling anti-affinity weight must be between 1 and 100; set it via scheduling.antiaffinity.weight property on this stage"))
+		}
+	case "required", "none", "":
+	default:
+		errors = append(errors, newValidationError("scheduling.antiaffinity.type", "invalid-value", "Scheduling anti-affinity type %v is invalid; allowed values are preferred, required or none", p.Scheduling.AntiAffinity.Type))
+	}
+
+	return errors
+}
+
+func (p *Params) validateRoutes(errors, warnings []ValidationError) ([]ValidationError, []ValidationError) {
+
+	if len(p.Routes) > 0 && p.Visibility == "public" && len(p.InternalHosts) == 0 {
+		warnings = append(warnings, newValidationWarning("routes", "unused-value", "Routes only apply to ingresses, but visibility public uses a load balancer service without ingress; remove routes or add internalhosts"))
+	}
+
+	routedPaths := map[string]bool{}
+	for i, route := range p.Routes {
+		property := fmt.Sprintf("routes[%v]", i)
+
+		if route.Path == "" {
+			errors = append(errors, newValidationError(property+".path", "required", "Route path is required; set it via %v.path property on this stage", property))
+		} else if !strings.HasPrefix(route.Path, "/") {
+			errors = append(errors, newValidationError(property+".path", "invalid-value", "Route path %v has to start with a /; set it via %v.path property on this stage", route.Path, property))
+		}
+
+		for j, host := range route.Hosts {
+			if !stringArrayContains(p.Hosts, host) && !stringArrayContains(p.InternalHosts, host) {
+				errors = append(errors, newValidationError(fmt.Sprintf("%v.hosts[%v]", property, j), "invalid-host", "Route host %v is not in hosts or internalhosts; add it there or remove it from %v.hosts", host, property))
+			}
+		}
+
+		routeHosts := route.Hosts
+		if len(routeHosts) == 0 {
+			routeHosts = append(append([]string{}, p.Hosts...), p.InternalHosts...)
+		}
+		for _, host := range routeHosts {
+			if routedPaths[host+route.Path] {
+				errors = append(errors, newValidationError(property+".path", "duplicate-value", "Route path %v is routed more than once for host %v; remove one of the routes", route.Path, host))
+			}
+			routedPaths[host+route.Path] = true
+		}
+
+		if route.Service != "" {
+			if route.Port == "" {
+				errors = append(errors, newValidationError(property+".port", "required", "Route port is required when routing to service %v; set it via %v.port property on this stage", route.Service, property))
+			}
+		} else if route.Port != "http" && route.Port != "https" {
+			port := p.findAdditionalPort(route.Port)
+			if port == nil {
+				errors = append(errors, newValidationError(property+".port", "invalid-value", "Route port %v is not a port of the service; use https, http or the name of one of the container.additionalports", route.Port))
+			} else if port.Visibility != p.Visibility {
+				errors = append(errors, newValidationError(property+".port", "invalid-value", "Route port %v has visibility %v and is therefore not added to the service with visibility %v; set the port's visibility to %v", route.Port, port.Visibility, p.Visibility, p.Visibility))
+			}
+		}
+
+		usesNginxIngress := p.Visibility == "private" || p.Visibility == "public-whitelist" || len(p.InternalHosts) > 0
+		if usesNginxIngress && route.Port != "" && route.Port != "https" {
+			warnings = append(warnings, newValidationWarning(property+".port", "backend-protocol", "Route port %v gets proxied to with https like the other routes, because the nginx ingress backend protocol applies to the whole ingress; make sure the port serves https", route.Port))
+		}
+	}
+
+	return errors, warnings
+}
+
+func (p *Params) validateIngress(errors, warnings []ValidationError) ([]ValidationError, []ValidationError) {
+
+	cors := p.Ingress.CORS
+	if cors.Enabled {
+		if cors.AllowOrigin == "*" && cors.AllowCredentials != nil && *cors.AllowCredentials {
+			errors = append(errors, newValidationError("ingress.cors.alloworigin", "invalid-value", "Ingress cors allow origin * can't be combined with allow credentials, browsers reject it; set the allowed origins via ingress.cors.alloworigin property on this stage"))
+		}
+		if cors.MaxAge < 0 {
+			errors = append(errors, newValidationError("ingress.cors.maxage", "invalid-value", "Ingress cors max age can't be negative; set it via ingress.cors.maxage property on this stage"))
+		}
+	}
+
+	rateLimit := p.Ingress.RateLimit
+	if rateLimit.RPS < 0 || rateLimit.RPM < 0 || rateLimit.Connections < 0 || rateLimit.BurstMultiplier < 0 {
+		errors = append(errors, newValidationError("ingress.ratelimit", "invalid-value", "Ingress rate limits can't be negative; set them via ingress.ratelimit property on this stage"))
+	}
+	if rateLimit.BurstMultiplier > 0 && rateLimit.RPS == 0 && rateLimit.RPM == 0 {
+		errors = append(errors, newValidationError("ingress.ratelimit.burstmultiplier", "invalid-value", "Ingress rate limit burst multiplier only applies to a limit per second or minute; set ingress.ratelimit.rps or ingress.ratelimit.rpm as well"))
+	}
+	for i, cidr := range rateLimit.Whitelist {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			errors = append(errors, newValidationError(fmt.Sprintf("ingress.ratelimit.whitelist[%v]", i), "invalid-value", "Ingress rate limit whitelist entry %v is not a valid cidr; set it via ingress.ratelimit.whitelist property on this stage", cidr))
+		}
+	}
+
+	switch p.Ingress.Auth.Type {
+	case "":
+	case "basic":
+		if p.Ingress.Auth.Secret == "" {
+			errors = append(errors, newValidationError("ingress.auth.secret", "required", "Ingress basic auth needs a secret with an htpasswd file under key auth; set its name via ingress.auth.secret property on this stage"))
+		}
+	case "external":
+		if p.Ingress.Auth.URL == "" {
+			errors = append(errors, newValidationError("ingress.auth.url", "required", "Ingress external auth needs the url of the authentication service; set it via ingress.auth.url property on this stage"))
+		} else if !strings.HasPrefix(p.Ingress.Auth.URL, "http://") && !strings.HasPrefix(p.Ingress.Auth.URL, "https://") {
+			errors = append(errors, newValidationError("ingress.auth.url", "invalid-value", "Ingress external auth url %v has to start with http:// or https://", p.Ingress.Auth.URL))
+		}
+	default:
+		errors = append(errors, newValidationError("ingress.auth.type", "invalid-value", "Ingress auth type %v is invalid; allowed values are basic or external", p.Ingress.Auth.Type))
+	}
+
+	for i, code := range p.Ingress.CustomErrors {
+		if code < 400 || code > 599 {
+			errors = append(errors, newValidationError(fmt.Sprintf("ingress.customerrors[%v]", i), "invalid-value", "Ingress custom error %v is not an http error status code; set codes between 400 and 599 via ingress.customerrors property on this stage", code))
+		}
+	}
+
+	if p.Ingress.ServerSnippet != "" && !isIngressAnnotationAllowed(p.Ingress.AllowedAnnotations, "nginx.ingress.kubernetes.io/server-snippet") {
+		errors = append(errors, newValidationError("ingress.serversnippet", "not-allowed", "Ingress server snippets are not allowed; the credential defaults need nginx.ingress.kubernetes.io/server-snippet in ingress.allowedannotations"))
+	}
+
+	annotationKeys := make([]string, 0, len(p.Ingress.Annotations))
+	for key := range p.Ingress.Annotations {
+		annotationKeys = append(annotationKeys, key)
+	}
+	sort.Strings(annotationKeys)
+	for _, key := range annotationKeys {
+		if !isIngressAnnotationAllowed(p.Ingress.AllowedAnnotations, key) {
+			errors = append(errors, newValidationError(fmt.Sprintf("ingress.annotations.%v", key), "not-allowed", "Ingress annotation %v is not allowed; only annotations in ingress.allowedannotations of the credential defaults can be set", key))
+		}
+	}
+
+	usesNginxIngress := p.Visibility == "private" || p.Visibility == "public-whitelist" || len(p.InternalHosts) > 0
+	if !usesNginxIngress && p.Ingress.hasNginxFeatures() {
+		warnings = append(warnings, newValidationWarning("ingress", "unused-value", "Ingress cors, rate limits, auth, snippets, https redirect and custom errors are only supported by the nginx ingress used for visibility private and public-whitelist and for internalhosts; they're ignored for visibility %v", p.Visibility))
+	}
+
+	return errors, warnings
+}
+
+func (p *Params) validateCertificate(errors, warnings []ValidationError) ([]ValidationError, []ValidationError) {
+
+	switch p.Certificate.Provider {
+	case "", "letsencrypt":
+	case "cert-manager":
+		if p.Certificate.Issuer == "" {
+			errors = append(errors, newValidationError("certificate.issuer", "required", "Certificate provider cert-manager needs the name of the issuer to request the certificate from; set it via certificate.issuer property on this stage"))
+		}
+		if p.Certificate.IssuerKind != "ClusterIssuer" && p.Certificate.IssuerKind != "Issuer" {
+			errors = append(errors, newValidationError("certificate.issuerkind", "invalid-value", "Certificate issuer kind %v is invalid; allowed values are ClusterIssuer or Issuer", p.Certificate.IssuerKind))
+		}
+	case "managed":
+		if p.Visibility != "iap" {
+			errors = append(errors, newValidationError("certificate.provider", "invalid-value", "Certificate provider managed is only supported by the gce ingress used for visibility iap; use letsencrypt, cert-manager or secret for visibility %v", p.Visibility))
+		}
+	case "secret":
+		if p.Certificate.SecretName == "" {
+			errors = append(errors, newValidationError("certificate.secretname", "required", "Certificate provider secret needs the name of an existing tls secret in namespace %v; set it via certificate.secretname property on this stage", p.Namespace))
+		}
+	default:
+		errors = append(errors, newValidationError("certificate.provider", "invalid-value", "Certificate provider %v is invalid; allowed values are letsencrypt, cert-manager, managed or secret", p.Certificate.Provider))
+	}
+
+	if p.Certificate.Issuer != "" && p.Certificate.Provider != "cert-manager" {
+		warnings = append(warnings, newValidationWarning("certificate.issuer", "unused-value", "Certificate issuer only applies to certificate provider cert-manager; it's ignored for provider %v", p.Certificate.Provider))
+	}
+	if p.Certificate.SecretName != "" && p.Certificate.Provider != "secret" && p.Certificate.Provider != "managed" {
+		warnings = append(warnings, newValidationWarning("certificate.secretname", "unused-value", "Certificate secret name only applies to certificate provider secret or managed; it's ignored for provider %v", p.Certificate.Provider))
+	}
+
+	return errors, warnings
+}
+
+func (p *Params) validateDNS(errors, warnings []ValidationError) ([]ValidationError, []ValidationError) {
+
+	for _, group := range []struct {
+		property string
+		dns      DNSProviderParams
+	}{{"dns.public", p.DNS.Public}, {"dns.internal", p.DNS.Internal}} {
+		switch group.dns.Provider {
+		case "", "cloudflare":
+		case "google-cloud-dns", "external-dns", "none":
+			if group.dns.Proxy != nil && *group.dns.Proxy {
+				warnings = append(warnings, newValidationWarning(group.property+".proxy", "unused-value", "Dns proxy only applies to provider cloudflare; it's ignored for provider %v", group.dns.Provider))
+			}
+		default:
+			errors = append(errors, newValidationError(group.property+".provider", "invalid-value", "Dns provider %v is invalid; allowed values are cloudflare, google-cloud-dns, external-dns or none", group.dns.Provider))
+		}
+	}
+
+	proxiedByCloudflare := (p.DNS.Public.Provider == "" || p.DNS.Public.Provider == "cloudflare") && (p.DNS.Public.Proxy == nil || *p.DNS.Public.Proxy)
+	if p.Visibility == "public" && !proxiedByCloudflare {
+		warnings = append(warnings, newValidationWarning("trustedips", "restricted-access", "With visibility public the load balancer only accepts traffic from trustedips, which default to cloudflare's ip ranges; set trustedips to allow clients that don't come through the cloudflare proxy"))
+	}
+
+	return errors, warnings
+}
+
+func (p *Params) validateMesh(errors, warnings []ValidationError) ([]ValidationError, []ValidationError) {
+
+	switch p.Mesh.Type {
+	case "":
+		if p.Mesh.Gateway != "" {
+			warnings = append(warnings, newValidationWarning("mesh.gateway", "unused-value", "Mesh gateway only applies to mesh type istio; it's ignored without mesh"))
+		}
+		if p.Request.Retries != nil || p.Request.RetryOn != "" {
+			warnings = append(warnings, newValidationWarning("request.retries", "unused-value", "Request retries only apply to mesh type istio; they're ignored by the ingress"))
+		}
+		return errors, warnings
+	case "istio":
+	default:
+		errors = append(errors, newValidationError("mesh.type", "invalid-value", "Mesh type %v is invalid; the only allowed value is istio", p.Mesh.Type))
+		return errors, warnings
+	}
+
+	if p.Visibility == "iap" || p.Visibility == "public-whitelist" {
+		errors = append(errors, newValidationError("visibility", "invalid-value", "Visibility %v is not supported with mesh type istio, since traffic goes through the istio ingress gateway; use private or public", p.Visibility))
+	}
+	if p.Mesh.CanaryWeight < 1 || p.Mesh.CanaryWeight > 100 {
+		errors = append(errors, newValidationError("mesh.canaryweight", "invalid-value", "Mesh canary weight %v is invalid; set a percentage between 1 and 100 via mesh.canaryweight property on this stage", p.Mesh.CanaryWeight))
+	}
+	if p.Request.Retries != nil && *p.Request.Retries < 0 {
+		errors = append(errors, newValidationError("request.retries", "invalid-value", "Request retries can't be negative; set it via request.retries property on this stage"))
+	}
+
+	for i, route := range p.Routes {
+		if _, err := strconv.Atoi(route.Port); route.Service != "" && err != nil {
+			errors = append(errors, newValidationError(fmt.Sprintf("routes[%v].port", i), "invalid-value", "Route port %v has to be a port number when routing to service %v with mesh type istio", route.Port, route.Service))
+		}
+	}
+
+	if p.Sidecar.Type == "openresty" {
+		warnings = append(warnings, newValidationWarning("sidecar.type", "unused-value", "The openresty sidecar isn't needed with mesh type istio, since the mesh provides tls; remove it or set sidecar.type to none"))
+	}
+	for i, sidecar := range p.Sidecars {
+		if sidecar != nil && sidecar.Type == "openresty" {
+			warnings = append(warnings, newValidationWarning(fmt.Sprintf("sidecars[%v].type", i), "unused-value", "The openresty sidecar isn't needed with mesh type istio, since the mesh provides tls; remove it from sidecars"))
+		}
+	}
+
+	return errors, warnings
+}
+
+func (p *Params) validateStatefulSet(errors []ValidationError) []ValidationError {
+
+	if p.Action != "deploy-simple" {
+		errors = append(errors, newValidationError("action", "invalid-value", "Action %v is not supported for kind statefulset; use deploy-simple and statefulset.partition for a gradual rollout", p.Action))
+	}
+	if p.Container.Port <= 0 {
+		errors = append(errors, newValidationError("container.port", "invalid-value", "Container port must be larger than zero, it's exposed through the headless service; set it via container.port property on this stage"))
+	}
+	if p.StatefulSet.Replicas <= 0 {
+		errors = append(errors, newValidationError("statefulset.replicas", "invalid-value", "Statefulset replicas must be larger than zero; set it via statefulset.replicas property on this stage"))
+	}
+	if p.StatefulSet.PodManagementPolicy != "OrderedReady" && p.StatefulSet.PodManagementPolicy != "Parallel" {
+		errors = append(errors, newValidationError("statefulset.podmanagementpolicy", "invalid-value", "Statefulset pod management policy %v is invalid; allowed values are OrderedReady or Parallel", p.StatefulSet.PodManagementPolicy))
+	}
+	if p.StatefulSet.Partition != nil && (*p.StatefulSet.Partition < 0 || *p.StatefulSet.Partition > p.StatefulSet.Replicas) {
+		errors = append(errors, newValidationError("statefulset.partition", "invalid-value", "Statefulset partition %v is invalid; set an ordinal between 0 and the number of replicas via statefulset.partition property on this stage", *p.StatefulSet.Partition))
+	}
+
+	names := map[string]bool{}
+	for i, claim := range p.StatefulSet.VolumeClaimTemplates {
+		property := fmt.Sprintf("statefulset.volumeclaimtemplates[%v]", i)
+		if !containerNameRegex.MatchString(claim.Name) {
+			errors = append(errors, newValidationError(property+".name", "invalid-value", "Volume claim template name '%v' is invalid; it has to be a valid dns label, like data", claim.Name))
+		} else if names[claim.Name] {
+			errors = append(errors, newValidationError(property+".name", "duplicate-value", "Volume claim template name %v is used more than once; give each volume claim template a unique name", claim.Name))
+		}
+		names[claim.Name] = true
+		if claim.MountPath == "" {
+			errors = append(errors, newValidationError(property+".mountpath", "required", "Volume claim template mount path is required; set it via %v.mountpath property on this stage", property))
+		}
+		if claim.Size == "" {
+			errors = append(errors, newValidationError(property+".size", "required", "Volume claim template size is required; set it via %v.size property on this stage", property))
+		} else if _, err := parseQuantity(claim.Size); err != nil {
+			errors = append(errors, newValidationError(property+".size", "invalid-value", "Volume claim template size %v is invalid: %v; set a quantity like 10Gi via %v.size property on this stage", claim.Size, err, property))
+		}
+		for _, accessMode := range claim.AccessModes {
+			if accessMode != "ReadWriteOnce" && accessMode != "ReadOnlyMany" && accessMode != "ReadWriteMany" && accessMode != "ReadWriteOncePod" {
+				errors = append(errors, newValidationError(property+".accessmodes", "invalid-value", "Volume claim template access mode %v is invalid; allowed values are ReadWriteOnce, ReadOnlyMany, ReadWriteMany or ReadWriteOncePod", accessMode))
+			}
+		}
+	}
+
+	// the openresty sidecar terminates tls for the ingress, which a statefulset doesn't get, so its certificate isn't rendered either
+	if p.Sidecar.Type == "openresty" {
+		errors = append(errors, newValidationError("sidecar.type", "invalid-value", "The openresty sidecar is not supported for kind statefulset, since it isn't exposed through an ingress; remove it or set sidecar.type to none"))
+	}
+	for i, sidecar := range p.Sidecars {
+		if sidecar != nil && sidecar.Type == "openresty" {
+			errors = append(errors, newValidationError(fmt.Sprintf("sidecars[%v].type", i), "invalid-value", "The openresty sidecar is not supported for kind statefulset, since it isn't exposed through an ingress; remove it from sidecars"))
+		}
+	}
+
+	return errors
+}
+
+// hasNginxFeatures returns whether any of the features that are translated into nginx ingress annotations is set
+func (ip *IngressParams) hasNginxFeatures() bool {
+	return ip.CORS.Enabled || ip.RateLimit.RPS > 0 || ip.RateLimit.RPM > 0 || ip.RateLimit.Connections > 0 || ip.Auth.Type != "" ||
+		ip.ServerSnippet != "" || ip.HTTPSRedirect != nil || len(ip.CustomErrors) > 0
+}
+
+// isIngressAnnotationAllowed returns whether the annotation key is in the allowlist, either literally or by a prefix ending with *
+func isIngressAnnotationAllowed(allowedAnnotations []string, key string) bool {
+	for _, allowed := range allowedAnnotations {
+		if allowed == key || (strings.HasSuffix(allowed, "*") && strings.HasPrefix(key, strings.TrimSuffix(allowed, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// findAdditionalPort returns the additional port with the given name, or nil if there's none
+func (p *Params) findAdditionalPort(name string) *AdditionalPortParams {
+	for _, ap := range p.Container.AdditionalPorts {
+		if ap != nil && ap.Name == name {
+			return ap
+		}
+	}
+	return nil
+}
+
+func (p *Params) validateNetworkPolicy(errors []ValidationError) []ValidationError {
+
+	if !p.NetworkPolicy.Enabled {
+		return errors
+	}
+
+	for i, cidr := range p.NetworkPolicy.GCELoadBalancerRanges {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			errors = append(errors, newValidationError(fmt.Sprintf("networkpolicy.gceloadbalancerranges[%v]", i), "invalid-value", "Network policy gce load balancer range %v is not a valid cidr; set it via networkpolicy.gceloadbalancerranges property on this stage", cidr))
+		}
+	}
+
+	for i, client := range p.NetworkPolicy.AllowedClients {
+		property := fmt.Sprintf("networkpolicy.allowedclients[%v]", i)
+		if client.App == "" && client.Namespace == "" {
+			errors = append(errors, newValidationError(property, "required", "Network policy allowed client needs an app, a namespace or both; set it via %v.app or %v.namespace property on this stage", property, property))
+		}
+	}
+
+	for i, egress := range p.NetworkPolicy.Egress {
+		property := fmt.Sprintf("networkpolicy.egress[%v]", i)
+		if egress.CIDR == "" && egress.App == "" && egress.Namespace == "" {
+			errors = append(errors, newValidationError(property, "required", "Network policy egress destination needs an app, a namespace or a cidr; set it via %v.app, %v.namespace or %v.cidr property on this stage", property, property, property))
+		}
+		if egress.CIDR != "" {
+			if egress.App != "" || egress.Namespace != "" {
+				errors = append(errors, newValidationError(property+".cidr", "invalid-value", "Network policy egress cidr can't be combined with app or namespace; use a separate entry in networkpolicy.egress for each"))
+			}
+			if _, _, err := net.ParseCIDR(egress.CIDR); err != nil {
+				errors = append(errors, newValidationError(property+".cidr", "invalid-value", "Network policy egress cidr %v is invalid; set a range like 10.0.0.0/8 via %v.cidr property on this stage", egress.CIDR, property))
+			}
+		}
+		for j, port := range egress.Ports {
+			portProperty := fmt.Sprintf("%v.ports[%v]", property, j)
+			if port.Port < 1 || port.Port > 65535 {
+				errors = append(errors, newValidationError(portProperty+".port", "invalid-value", "Network policy egress port must be between 1 and 65535; set it via %v.port property on this stage", portProperty))
+			}
+			if port.Protocol != "TCP" && port.Protocol != "UDP" {
+				errors = append(errors, newValidationError(portProperty+".protocol", "invalid-value", "Network policy egress protocol %v is invalid; allowed values are TCP or UDP", port.Protocol))
+			}
+		}
+	}
+
+	return errors
+}
+
+func (p *Params) containerExposesPort(port int) bool {
+	if port == p.Container.Port {
+		return true
+	}
+	for _, ap := range p.Container.AdditionalPorts {
+		if ap.Port == port {
+			return true
+		}
+	}
+	return false
+}
+
+// ReplaceOpenrestyTagWithDigest looks for a sidecar of type openresty and replaces the image tag with a digest
+func (p *Params) ReplaceOpenrestyTagWithDigest() {
+
+	// see if there's a sidecar of type openresty
+	for _, s := range p.Sidecars {
+		if s.Type == "openresty" {
+
+			logInfo("Replacing openresty sidecar image tag with digest...")
+
+			if s.Image == "" {
+				return
+			}
+
+			imageDigestParts := strings.Split(s.Image, "@")
+			if len(imageDigestParts) > 1 {
+				// already uses a digest, skip replacement
+				return
+			}
+
+			imageParts := strings.Split(s.Image, ":")
+			repository := imageParts[0]
+			tag := "latest"
 			if len(imageParts) > 1 {
 				tag = imageParts[1]
 			}
@@ -807,3 +2268,66 @@ func (p *Params) ReplaceOpenrestyTagWithDigest() {
 		}
 	}
 }
+
+func (p *Params) validateDaemonSet(errors, warnings []ValidationError) ([]ValidationError, []ValidationError) {
+
+	if p.Action != "deploy-simple" {
+		errors = append(errors, newValidationError("action", "invalid-value", "Action %v is not supported for kind daemonset; use deploy-simple and daemonset.maxunavailable to control the rollout", p.Action))
+	}
+	if p.Container.Port <= 0 {
+		errors = append(errors, newValidationError("container.port", "invalid-value", "Container port must be larger than zero, it's used by the probes; set it via container.port property on this stage"))
+	}
+
+	switch p.DaemonSet.UpdateStrategy {
+	case "RollingUpdate":
+		if p.DaemonSet.MaxUnavailable != "" {
+			maxUnavailable, _, err := parseIntOrPercentage(p.DaemonSet.MaxUnavailable)
+			if err != nil {
+				errors = append(errors, newValidationError("daemonset.maxunavailable", "invalid-value", "Daemonset max unavailable is invalid: %v; set an integer or percentage via daemonset.maxunavailable property on this stage", err))
+			} else if maxUnavailable == 0 {
+				errors = append(errors, newValidationError("daemonset.maxunavailable", "invalid-value", "Daemonset max unavailable can't be 0, since a pod has to be stopped before its replacement can start on the same node; set it via daemonset.maxunavailable property on this stage"))
+			}
+		}
+	case "OnDelete":
+		if p.DaemonSet.MaxUnavailable != "" {
+			warnings = append(warnings, newValidationWarning("daemonset.maxunavailable", "unused-value", "Daemonset max unavailable %v is ignored, since update strategy OnDelete only replaces pods when they're deleted", p.DaemonSet.MaxUnavailable))
+		}
+	default:
+		errors = append(errors, newValidationError("daemonset.updatestrategy", "invalid-value", "Daemonset update strategy %v is invalid; allowed values are RollingUpdate or OnDelete", p.DaemonSet.UpdateStrategy))
+	}
+
+	names := map[string]bool{}
+	for i, volume := range p.DaemonSet.HostPathVolumes {
+		property := fmt.Sprintf("daemonset.hostpathvolumes[%v]", i)
+		if !containerNameRegex.MatchString(volume.Name) {
+			errors = append(errors, newValidationError(property+".name", "invalid-value", "Host path volume name '%v' is invalid; it has to be a valid dns label, like var-log", volume.Name))
+		} else if names[volume.Name] {
+			errors = append(errors, newValidationError(property+".name", "duplicate-value", "Host path volume name %v is used more than once; give each host path volume a unique name", volume.Name))
+		}
+		names[volume.Name] = true
+
+		if !strings.HasPrefix(volume.HostPath, "/") {
+			errors = append(errors, newValidationError(property+".hostpath", "invalid-value", "Host path '%v' is invalid; set an absolute path on the node via %v.hostpath property on this stage", volume.HostPath, property))
+		}
+		if volume.MountPath == "" {
+			errors = append(errors, newValidationError(property+".mountpath", "required", "Host path volume mount path is required; set it via %v.mountpath property on this stage", property))
+		}
+		switch volume.Type {
+		case "", "DirectoryOrCreate", "Directory", "FileOrCreate", "File", "Socket", "CharDevice", "BlockDevice":
+		default:
+			errors = append(errors, newValidationError(property+".type", "invalid-value", "Host path volume type %v is invalid; allowed values are DirectoryOrCreate, Directory, FileOrCreate, File, Socket, CharDevice or BlockDevice", volume.Type))
+		}
+	}
+
+	// the openresty sidecar terminates tls for the ingress, which a daemonset doesn't get
+	if p.Sidecar.Type == "openresty" {
+		errors = append(errors, newValidationError("sidecar.type", "invalid-value", "The openresty sidecar is not supported for kind daemonset, since it isn't exposed through an ingress; remove it or set sidecar.type to none"))
+	}
+	for i, sidecar := range p.Sidecars {
+		if sidecar != nil && sidecar.Type == "openresty" {
+			errors = append(errors, newValidationError(fmt.Sprintf("sidecars[%v].type", i), "invalid-value", "The openresty sidecar is not supported for kind daemonset, since it isn't exposed through an ingress; remove it from sidecars"))
+		}
+	}
+
+	return errors, warnings
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// UnknownPropertyError describes a property that is set but doesn't map onto any known field
+type UnknownPropertyError struct {
+	Path       string
+	Suggestion string
+}
+
+func (e UnknownPropertyError) Error() string {
+	if e.Suggestion != "" {
+		return fmt.Sprintf("Property %v is unknown and will be ignored; did you mean %v?", e.Path, e.Suggestion)
+	}
+	return fmt.Sprintf("Property %v is unknown and will be ignored", e.Path)
+}
+
+// decodeStrict unmarshals data into target like json.Unmarshal, but also returns all properties that don't map onto a field of target;
+// ignoredKeys are top-level keys that are handled elsewhere and shouldn't be reported
+func decodeStrict(data []byte, target interface{}, rootPath string, ignoredKeys ...string) ([]UnknownPropertyError, error) {
+
+	var raw interface{}
+	err := json.Unmarshal(data, &raw)
+	if err != nil {
+		return nil, err
+	}
+
+	if rawMap, ok := raw.(map[string]interface{}); ok && len(ignoredKeys) > 0 {
+		filteredMap := make(map[string]interface{}, len(rawMap))
+		for k, v := range rawMap {
+			if !stringArrayContainsFold(ignoredKeys, k) {
+				filteredMap[k] = v
+			}
+		}
+		raw = filteredMap
+	}
+
+	unknownProperties := findUnknownProperties(raw, reflect.TypeOf(target), rootPath)
+
+	err = json.Unmarshal(data, target)
+	if err != nil {
+		return unknownProperties, err
+	}
+
+	return unknownProperties, nil
+}
+
+func findUnknownProperties(value interface{}, t reflect.Type, path string) (unknownProperties []UnknownPropertyError) {
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		valueMap, ok := value.(map[string]interface{})
+		if !ok {
+			return
+		}
+
+		fields := jsonFields(t)
+		fieldNames := make([]string, 0, len(fields))
+		for name := range fields {
+			fieldNames = append(fieldNames, name)
+		}
+
+		for _, key := range sortedKeys(valueMap) {
+			keyPath := joinPropertyPath(path, key)
+
+			field, ok := fields[key]
+			if !ok {
+				// encoding/json matches keys case-insensitively, so do the same
+				for name, f := range fields {
+					if strings.EqualFold(name, key) {
+						field, ok = f, true
+						break
+					}
+				}
+			}
+
+			if !ok {
+				unknownProperty := UnknownPropertyError{Path: keyPath}
+				if suggestion := closestMatch(key, fieldNames); suggestion != "" {
+					unknownProperty.Suggestion = joinPropertyPath(path, suggestion)
+				}
+				unknownProperties = append(unknownProperties, unknownProperty)
+				continue
+			}
+
+			unknownProperties = append(unknownProperties, findUnknownProperties(valueMap[key], field.Type, keyPath)...)
+		}
+
+	case reflect.Slice, reflect.Array:
+		valueSlice, ok := value.([]interface{})
+		if !ok {
+			return
+		}
+		for i, v := range valueSlice {
+			unknownProperties = append(unknownProperties, findUnknownProperties(v, t.Elem(), fmt.Sprintf("%v[%v]", path, i))...)
+		}
+
+	case reflect.Map:
+		// only maps with structured values can contain unknown properties, free-form maps like env or labels can't
+		valueMap, ok := value.(map[string]interface{})
+		if !ok || t.Elem().Kind() == reflect.Interface {
+			return
+		}
+		for _, key := range sortedKeys(valueMap) {
+			unknownProperties = append(unknownProperties, findUnknownProperties(valueMap[key], t.Elem(), joinPropertyPath(path, key))...)
+		}
+	}
+
+	return
+}
+
+// jsonFields returns the exported fields of a struct type by the name they have in json
+func jsonFields(t reflect.Type) map[string]reflect.StructField {
+	fields := map[string]reflect.StructField{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field
+	}
+	return fields
+}
+
+func joinPropertyPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func stringArrayContains(array []string, search string) bool {
+	for _, v := range array {
+		if v == search {
+			return true
+		}
+	}
+	return false
+}
+
+func stringArrayContainsFold(array []string, search string) bool {
+	for _, v := range array {
+		if strings.EqualFold(v, search) {
+			return true
+		}
+	}
+	return false
+}
+
+// closestMatch returns the candidate with the smallest edit distance to value, as long as it's close enough to be a likely typo
+func closestMatch(value string, candidates []string) string {
+
+	sort.Strings(candidates)
+
+	bestMatch := ""
+	bestDistance := -1
+	for _, c := range candidates {
+		distance := levenshteinDistance(strings.ToLower(value), strings.ToLower(c))
+		if bestDistance == -1 || distance < bestDistance {
+			bestMatch = c
+			bestDistance = distance
+		}
+	}
+
+	// allow roughly one typo per 3 characters, but at least 2
+	maxDistance := len(value) / 3
+	if maxDistance < 2 {
+		maxDistance = 2
+	}
+	if bestDistance == -1 || bestDistance > maxDistance {
+		return ""
+	}
+
+	return bestMatch
+}
+
+func levenshteinDistance(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+
+	previous := make([]int, len(br)+1)
+	current := make([]int, len(br)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ar); i++ {
+		current[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(minInt(previous[j]+1, current[j-1]+1), previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(br)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeStrict(t *testing.T) {
+
+	t.Run("ReturnsNoUnknownPropertiesIfAllPropertiesAreKnown", func(t *testing.T) {
+
+		paramsJSON := `{"app":"myapp","autoscale":{"min":2,"max":10},"container":{"liveness":{"path":"/live"}},"sidecars":[{"type":"cloudsqlproxy","sqlproxyport":5432}]}`
+		var params Params
+
+		// act
+		unknownProperties, err := decodeStrict([]byte(paramsJSON), &params, "")
+
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(unknownProperties))
+		assert.Equal(t, "myapp", params.App)
+		assert.Equal(t, 2, params.Autoscale.MinReplicas)
+	})
+
+	t.Run("ReturnsUnknownNestedPropertyWithPathAndSuggestion", func(t *testing.T) {
+
+		paramsJSON := `{"autoscale":{"mim":2}}`
+		var params Params
+
+		// act
+		unknownProperties, err := decodeStrict([]byte(paramsJSON), &params, "")
+
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(unknownProperties))
+		assert.Equal(t, "autoscale.mim", unknownProperties[0].Path)
+		assert.Equal(t, "autoscale.min", unknownProperties[0].Suggestion)
+	})
+
+	t.Run("ReturnsUnknownPropertyInsideListWithIndexInPath", func(t *testing.T) {
+
+		paramsJSON := `{"sidecars":[{"type":"openresty"},{"type":"cloudsqlproxy","sqlproxyprot":5432}]}`
+		var params Params
+
+		// act
+		unknownProperties, err := decodeStrict([]byte(paramsJSON), &params, "")
+
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(unknownProperties))
+		assert.Equal(t, "sidecars[1].sqlproxyprot", unknownProperties[0].Path)
+		assert.Equal(t, "sidecars[1].sqlproxyport", unknownProperties[0].Suggestion)
+	})
+
+	t.Run("ReturnsUnknownPropertyWithoutSuggestionIfNothingIsClose", func(t *testing.T) {
+
+		paramsJSON := `{"container":{"somethingcompletelydifferent":true}}`
+		var params Params
+
+		// act
+		unknownProperties, err := decodeStrict([]byte(paramsJSON), &params, "")
+
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(unknownProperties))
+		assert.Equal(t, "container.somethingcompletelydifferent", unknownProperties[0].Path)
+		assert.Equal(t, "", unknownProperties[0].Suggestion)
+	})
+
+	t.Run("PrefixesPathsWithRootPath", func(t *testing.T) {
+
+		paramsJSON := `{"container":{"livenss":{"path":"/live"}}}`
+		var params Params
+
+		// act
+		unknownProperties, err := decodeStrict([]byte(paramsJSON), &params, "defaults")
+
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(unknownProperties))
+		assert.Equal(t, "defaults.container.livenss", unknownProperties[0].Path)
+		assert.Equal(t, "defaults.container.liveness", unknownProperties[0].Suggestion)
+	})
+
+	t.Run("AcceptsPropertiesThatOnlyDifferInCasing", func(t *testing.T) {
+
+		paramsJSON := `{"iapoauthclientid":"abc"}`
+		var params Params
+
+		// act
+		unknownProperties, err := decodeStrict([]byte(paramsJSON), &params, "")
+
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(unknownProperties))
+		assert.Equal(t, "abc", params.IapOauthCredentialsClientID)
+	})
+
+	t.Run("DoesNotInspectFreeFormMaps", func(t *testing.T) {
+
+		paramsJSON := `{"labels":{"team":"myteam"},"container":{"env":{"MY_ENV":"value"}},"volumemounts":[{"name":"myvolume","volume":{"secret":{"secretName":"mysecret"}}}]}`
+		var params Params
+
+		// act
+		unknownProperties, err := decodeStrict([]byte(paramsJSON), &params, "")
+
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(unknownProperties))
+	})
+
+	t.Run("IgnoresIgnoredKeys", func(t *testing.T) {
+
+		paramsJSON := `{"credentials":"gke-production","app":"myapp"}`
+		var params Params
+
+		// act
+		unknownProperties, err := decodeStrict([]byte(paramsJSON), &params, "", "credentials")
+
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(unknownProperties))
+	})
+
+	t.Run("ReturnsUnknownPropertyInsideEnvironmentWithEnvironmentInPath", func(t *testing.T) {
+
+		paramsJSON := `{"environments":{"production":{"autoscale":{"mim":2}}}}`
+		var params Params
+
+		// act
+		unknownProperties, err := decodeStrict([]byte(paramsJSON), &params, "")
+
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(unknownProperties))
+		assert.Equal(t, "environments.production.autoscale.mim", unknownProperties[0].Path)
+		assert.Equal(t, "environments.production.autoscale.min", unknownProperties[0].Suggestion)
+	})
+
+	t.Run("ReturnsErrorForInvalidJSON", func(t *testing.T) {
+
+		var params Params
+
+		// act
+		_, err := decodeStrict([]byte(`{"app":`), &params, "")
+
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,321 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	paramsSourceStage      = "stage"
+	paramsSourceConvention = "convention default"
+
+	paramsExplainMask = "'***'"
+)
+
+var (
+	// properties of which the values are masked in the explain output, since it ends up in the build log
+	paramsExplainMaskedPaths = []string{
+		"secrets",
+		"credentials",
+		"iapOauthClientSecret",
+		"babysitter.prometheustoken",
+	}
+)
+
+// ParamsSources keeps track of where the value of each params property came from, keyed by property path
+type ParamsSources map[string]string
+
+// NewParamsSources marks all properties of the merged params as coming from the stage, except for the applied credential defaults
+func NewParamsSources(mergedParamsJSON []byte, appliedDefaults []string, credentialName string) (ParamsSources, error) {
+
+	var merged interface{}
+	err := json.Unmarshal(mergedParamsJSON, &merged)
+	if err != nil {
+		return nil, err
+	}
+
+	// the stage keys are matched case-insensitively, so use the json names of the marshalled params the explain output is written from
+	merged = canonicalizeParamsValue(merged, reflect.TypeOf(Params{}))
+
+	sources := ParamsSources{}
+	for _, path := range leafPaths(merged, "") {
+		sources[path] = paramsSourceStage
+	}
+	for _, path := range appliedDefaults {
+		sources.set(path, fmt.Sprintf("defaults of credential %v", credentialName))
+	}
+
+	return sources, nil
+}
+
+// ApplyEnvironment marks the properties set in the environments entry for releaseName as coming from that entry
+func (s ParamsSources) ApplyEnvironment(paramsJSON []byte, releaseName string) error {
+
+	var stageParams struct {
+		Environments map[string]interface{} `json:"environments"`
+	}
+	err := json.Unmarshal(paramsJSON, &stageParams)
+	if err != nil {
+		return err
+	}
+
+	environment, ok := stageParams.Environments[releaseName]
+	if !ok || releaseName == "" {
+		return nil
+	}
+
+	for _, path := range leafPaths(canonicalizeParamsValue(environment, reflect.TypeOf(Params{})), "") {
+		s.set(path, fmt.Sprintf("stage environments.%v", releaseName))
+	}
+
+	return nil
+}
+
+// ApplyConventions marks the properties that were changed by SetDefaults as convention defaults, or as coming from the estafette environment
+// variable the convention is based on; before holds the flattened params from before SetDefaults, since it changes maps and sidecars in place
+func (s ParamsSources) ApplyConventions(before map[string]interface{}, params Params, appLabel, releaseAction string, estafetteLabels map[string]string) error {
+
+	after, err := flattenParams(params)
+	if err != nil {
+		return err
+	}
+
+	for path, value := range after {
+		if beforeValue, ok := before[path]; ok && reflect.DeepEqual(beforeValue, value) {
+			continue
+		}
+
+		source := paramsSourceConvention
+		switch {
+		case path == "action" && releaseAction != "":
+			source = "environment variable ESTAFETTE_RELEASE_ACTION"
+		case path == "app" && appLabel != "":
+			source = "environment variable ESTAFETTE_LABEL_APP"
+		case path == "app":
+			source = "environment variable ESTAFETTE_GIT_NAME"
+		case path == "container.tag":
+			source = "environment variable ESTAFETTE_BUILD_VERSION"
+		case strings.HasPrefix(path, "labels.") && path != "labels.app":
+			if _, ok := estafetteLabels[strings.TrimPrefix(path, "labels.")]; ok {
+				source = fmt.Sprintf("environment variable ESTAFETTE_LABEL_%v", strings.ToUpper(strings.TrimPrefix(path, "labels.")))
+			}
+		}
+
+		s.set(path, source)
+	}
+
+	return nil
+}
+
+// Lookup returns the source of the property at path, or of the closest parent that has a source
+func (s ParamsSources) Lookup(path string) string {
+	for path != "" {
+		if source, ok := s[path]; ok {
+			return source
+		}
+		path = parentPropertyPath(path)
+	}
+	return ""
+}
+
+// set replaces the source of path and of all properties nested inside it
+func (s ParamsSources) set(path, source string) {
+	for p := range s {
+		if strings.HasPrefix(p, path+".") || strings.HasPrefix(p, path+"[") {
+			delete(s, p)
+		}
+	}
+	s[path] = source
+}
+
+func parentPropertyPath(path string) string {
+	i := strings.LastIndexAny(path, ".[")
+	if i == -1 {
+		return ""
+	}
+	return path[:i]
+}
+
+// flattenParams returns the json value of every property of params by its full path, including list indices
+func flattenParams(params Params) (map[string]interface{}, error) {
+
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	err = json.Unmarshal(data, &value)
+	if err != nil {
+		return nil, err
+	}
+
+	flattened := map[string]interface{}{}
+	flattenParamsValue(value, "", flattened)
+
+	return flattened, nil
+}
+
+func flattenParamsValue(value interface{}, path string, flattened map[string]interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			flattened[path] = v
+		}
+		for k, item := range v {
+			flattenParamsValue(item, joinPropertyPath(path, k), flattened)
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			flattened[path] = v
+		}
+		for i, item := range v {
+			flattenParamsValue(item, fmt.Sprintf("%v[%v]", path, i), flattened)
+		}
+	default:
+		flattened[path] = v
+	}
+}
+
+// explainParams writes params as yaml, with the source of each value as a comment behind it
+func explainParams(w io.Writer, params Params, sources ParamsSources) error {
+
+	data, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	var value map[string]interface{}
+	err = json.Unmarshal(data, &value)
+	if err != nil {
+		return err
+	}
+
+	pruneEmptyObjects(value, "", sources)
+
+	if params.BuildVersion != "" {
+		fmt.Fprintf(w, "# build version %v comes from environment variable ESTAFETTE_BUILD_VERSION\n", params.BuildVersion)
+	}
+
+	return writeExplainedObject(w, value, "", "", "", sources)
+}
+
+func writeExplainedObject(w io.Writer, value map[string]interface{}, path, indent, firstIndent string, sources ParamsSources) error {
+
+	keys := make([]string, 0, len(value))
+	for k := range value {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for i, k := range keys {
+		lineIndent := indent
+		if i == 0 {
+			lineIndent = firstIndent
+		}
+		err := writeExplainedValue(w, k+":", value[k], joinPropertyPath(path, k), lineIndent, indent, sources)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeExplainedValue(w io.Writer, prefix string, value interface{}, path, lineIndent, indent string, sources ParamsSources) error {
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			fmt.Fprintf(w, "%v%v {}%v\n", lineIndent, prefix, explainComment(sources.Lookup(path)))
+			return nil
+		}
+		if strings.HasPrefix(prefix, "-") {
+			return writeExplainedObject(w, v, path, indent+"  ", lineIndent+"- ", sources)
+		}
+		fmt.Fprintf(w, "%v%v\n", lineIndent, prefix)
+		return writeExplainedObject(w, v, path, indent+"  ", indent+"  ", sources)
+
+	case []interface{}:
+		if len(v) == 0 {
+			fmt.Fprintf(w, "%v%v []%v\n", lineIndent, prefix, explainComment(sources.Lookup(path)))
+			return nil
+		}
+		fmt.Fprintf(w, "%v%v\n", lineIndent, prefix)
+		for i, item := range v {
+			err := writeExplainedValue(w, "-", item, fmt.Sprintf("%v[%v]", path, i), indent, indent, sources)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+
+	default:
+		scalar, err := explainScalar(v)
+		if err != nil {
+			return err
+		}
+		if isMaskedParamsPath(path) {
+			scalar = paramsExplainMask
+		}
+		fmt.Fprintf(w, "%v%v %v%v\n", lineIndent, prefix, scalar, explainComment(sources.Lookup(path)))
+		return nil
+	}
+}
+
+// pruneEmptyObjects removes objects that end up empty, unless they're explicitly set; structs are never omitted when empty, which would
+// otherwise clutter the output
+func pruneEmptyObjects(value map[string]interface{}, path string, sources ParamsSources) {
+	for k, v := range value {
+		keyPath := joinPropertyPath(path, k)
+		switch item := v.(type) {
+		case map[string]interface{}:
+			pruneEmptyObjects(item, keyPath, sources)
+			if _, ok := sources[keyPath]; len(item) == 0 && !ok {
+				delete(value, k)
+			}
+		case []interface{}:
+			for i, listItem := range item {
+				if listItemMap, ok := listItem.(map[string]interface{}); ok {
+					pruneEmptyObjects(listItemMap, fmt.Sprintf("%v[%v]", keyPath, i), sources)
+				}
+			}
+		}
+	}
+}
+
+func explainScalar(value interface{}) (string, error) {
+
+	// multi-line strings are written as json strings, which are valid yaml and fit on a single line
+	if s, ok := value.(string); ok && strings.Contains(s, "\n") {
+		data, err := json.Marshal(s)
+		return string(data), err
+	}
+
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(data), "\n"), nil
+}
+
+// isMaskedParamsPath returns whether path is one of the paramsExplainMaskedPaths or nested inside one of them
+func isMaskedParamsPath(path string) bool {
+	for _, maskedPath := range paramsExplainMaskedPaths {
+		if path == maskedPath || strings.HasPrefix(path, maskedPath+".") || strings.HasPrefix(path, maskedPath+"[") {
+			return true
+		}
+	}
+	return false
+}
+
+func explainComment(source string) string {
+	if source == "" {
+		return ""
+	}
+	return "  # " + source
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParamsSources(t *testing.T) {
+
+	t.Run("MarksMergedPropertiesAsStageUnlessTheyAreAppliedDefaults", func(t *testing.T) {
+
+		mergedParamsJSON := `{"autoscale":{"min":5,"max":20},"hosts":["gke.example.com"]}`
+
+		// act
+		sources, err := NewParamsSources([]byte(mergedParamsJSON), []string{"autoscale.min"}, "gke-production")
+
+		assert.Nil(t, err)
+		assert.Equal(t, "defaults of credential gke-production", sources.Lookup("autoscale.min"))
+		assert.Equal(t, "stage", sources.Lookup("autoscale.max"))
+		assert.Equal(t, "stage", sources.Lookup("hosts[0]"))
+	})
+
+	t.Run("MarksListItemsFromDefaultsByIndex", func(t *testing.T) {
+
+		mergedParamsJSON := `{"trustedips":["1.2.3.4/32","10.0.0.0/8"]}`
+
+		// act
+		sources, err := NewParamsSources([]byte(mergedParamsJSON), []string{"trustedips[0]"}, "gke-production")
+
+		assert.Nil(t, err)
+		assert.Equal(t, "defaults of credential gke-production", sources.Lookup("trustedips[0]"))
+		assert.Equal(t, "stage", sources.Lookup("trustedips[1]"))
+	})
+
+	t.Run("MarksEnvironmentPropertiesAsEnvironment", func(t *testing.T) {
+
+		sources, _ := NewParamsSources([]byte(`{"sidecars":[{"type":"openresty","image":"openresty:1"}]}`), []string{"sidecars[0].image"}, "gke-production")
+		paramsJSON := `{"environments":{"production":{"sidecars":[{"type":"openresty"}]}}}`
+
+		// act
+		err := sources.ApplyEnvironment([]byte(paramsJSON), "production")
+
+		assert.Nil(t, err)
+		assert.Equal(t, "stage environments.production", sources.Lookup("sidecars[0].type"))
+		assert.Equal(t, "stage environments.production", sources.Lookup("sidecars[0].image"))
+	})
+
+	t.Run("IgnoresEnvironmentsForOtherReleases", func(t *testing.T) {
+
+		sources, _ := NewParamsSources([]byte(`{"autoscale":{"max":20}}`), []string{}, "gke-production")
+		paramsJSON := `{"environments":{"staging":{"autoscale":{"max":5}}}}`
+
+		// act
+		err := sources.ApplyEnvironment([]byte(paramsJSON), "production")
+
+		assert.Nil(t, err)
+		assert.Equal(t, "stage", sources.Lookup("autoscale.max"))
+	})
+
+	t.Run("MarksPropertiesChangedBySetDefaultsAsConventionOrEnvironmentVariable", func(t *testing.T) {
+
+		params := Params{Autoscale: AutoscaleParams{MaxReplicas: 20}}
+		sources, _ := NewParamsSources([]byte(`{"autoscale":{"max":20}}`), []string{}, "gke-production")
+		before, _ := flattenParams(params)
+		params.SetDefaults("mygitname", "", "1.0.3", "production", "deploy-canary", map[string]string{"team": "myteam"})
+
+		// act
+		err := sources.ApplyConventions(before, params, "", "deploy-canary", map[string]string{"team": "myteam"})
+
+		assert.Nil(t, err)
+		assert.Equal(t, "stage", sources.Lookup("autoscale.max"))
+		assert.Equal(t, "convention default", sources.Lookup("autoscale.min"))
+		assert.Equal(t, "environment variable ESTAFETTE_GIT_NAME", sources.Lookup("app"))
+		assert.Equal(t, "environment variable ESTAFETTE_BUILD_VERSION", sources.Lookup("container.tag"))
+		assert.Equal(t, "environment variable ESTAFETTE_RELEASE_ACTION", sources.Lookup("action"))
+		assert.Equal(t, "environment variable ESTAFETTE_LABEL_TEAM", sources.Lookup("labels.team"))
+		assert.Equal(t, "convention default", sources.Lookup("sidecars[0].image"))
+	})
+
+	t.Run("MarksPropertiesByJSONNameIfStageUsesDifferentCase", func(t *testing.T) {
+
+		mergedParamsJSON := `{"Container":{"Port":8080},"Sidecars":[{"Type":"openresty"}]}`
+
+		// act
+		sources, err := NewParamsSources([]byte(mergedParamsJSON), []string{}, "gke-production")
+
+		assert.Nil(t, err)
+		assert.Equal(t, "stage", sources["container.port"])
+		assert.Equal(t, "stage", sources["sidecars"])
+		assert.Equal(t, "", sources.Lookup("Container.Port"))
+	})
+
+	t.Run("MarksEnvironmentPropertiesByJSONNameIfEnvironmentUsesDifferentCase", func(t *testing.T) {
+
+		sources, _ := NewParamsSources([]byte(`{"autoscale":{"max":20}}`), []string{}, "gke-production")
+		paramsJSON := `{"environments":{"production":{"AutoScale":{"Max":5}}}}`
+
+		// act
+		err := sources.ApplyEnvironment([]byte(paramsJSON), "production")
+
+		assert.Nil(t, err)
+		assert.Equal(t, "stage environments.production", sources["autoscale.max"])
+	})
+}
+
+func TestExplainParams(t *testing.T) {
+
+	t.Run("WritesYamlWithSourceAsComment", func(t *testing.T) {
+
+		params := Params{
+			App:             "myapp",
+			BuildVersion:    "1.0.3",
+			Autoscale:       AutoscaleParams{MinReplicas: 5},
+			TrustedIPRanges: []string{"1.2.3.4/32"},
+			Sidecars: []*SidecarParams{
+				&SidecarParams{Type: "openresty", CPU: CPUParams{Request: "10m"}},
+			},
+		}
+		sources := ParamsSources{
+			"app":             "environment variable ESTAFETTE_GIT_NAME",
+			"autoscale.min":   "defaults of credential gke-production",
+			"trustedips":      "stage",
+			"sidecars":        "stage",
+			"sidecars[0].cpu": "convention default",
+		}
+		var buffer bytes.Buffer
+
+		// act
+		err := explainParams(&buffer, params, sources)
+
+		assert.Nil(t, err)
+		assert.Equal(t, `# build version 1.0.3 comes from environment variable ESTAFETTE_BUILD_VERSION
+app: myapp  # environment variable ESTAFETTE_GIT_NAME
+autoscale:
+  min: 5  # defaults of credential gke-production
+sidecars:
+- cpu:
+    request: 10m  # convention default
+  type: openresty  # stage
+trustedips:
+- 1.2.3.4/32  # stage
+`, buffer.String())
+	})
+
+	t.Run("WritesMultiLineStringsAsJSONString", func(t *testing.T) {
+
+		params := Params{
+			Configs: ConfigsParams{InlineFiles: map[string]string{"config.yaml": "a: b\nc: d"}},
+		}
+		var buffer bytes.Buffer
+
+		// act
+		err := explainParams(&buffer, params, ParamsSources{"configs.inline": "stage"})
+
+		assert.Nil(t, err)
+		assert.Equal(t, "configs:\n  inline:\n    config.yaml: \"a: b\\nc: d\"  # stage\n", buffer.String())
+	})
+
+	t.Run("MasksSecretValues", func(t *testing.T) {
+
+		params := Params{
+			IapOauthCredentialsClientSecret: "supersecret",
+			Secrets:                         SecretsParams{Keys: map[string]interface{}{"secret-file-1.json": "c29tZSBzZWNyZXQgdmFsdWU="}},
+			Babysitter:                      BabysitterParams{PrometheusToken: "mytoken"},
+		}
+		var buffer bytes.Buffer
+
+		// act
+		err := explainParams(&buffer, params, ParamsSources{"secrets": "stage", "iapOauthClientSecret": "stage", "babysitter": "stage"})
+
+		assert.Nil(t, err)
+		assert.NotContains(t, buffer.String(), "supersecret")
+		assert.NotContains(t, buffer.String(), "c29tZSBzZWNyZXQgdmFsdWU=")
+		assert.NotContains(t, buffer.String(), "mytoken")
+		assert.Contains(t, buffer.String(), "secret-file-1.json: '***'  # stage")
+	})
+}
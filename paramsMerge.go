@@ -0,0 +1,331 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var (
+	// lists of which the stage items are appended to the items from the credential defaults, instead of replacing them
+	paramsMergeAppendedLists = []string{
+		"trustedips",
+	}
+
+	// lists of which items are matched on the value of a property; matching items are merged, other stage items are appended
+	paramsMergeKeyedLists = map[string]string{
+		"sidecars":                  "type",
+		"volumemounts":              "name",
+		"container.additionalports": "name",
+		"initcontainers":            "name",
+		"autoscale.metrics":         "name",
+	}
+
+	// objects that are replaced as a whole, because merging them could combine mutually exclusive properties
+	paramsMergeReplacedObjects = []string{
+		"volumemounts[].volume",
+	}
+)
+
+// mergeParamsJSON deep-merges the stage params over the credential defaults, following these rules:
+//   - objects and maps are merged property by property, with the stage value winning
+//   - lists are replaced by the stage list, except for the ones in paramsMergeAppendedLists which are appended
+//   - items of lists in paramsMergeKeyedLists are merged by key, so setting one property of the openresty sidecar keeps the others from the defaults
+//
+// It returns the merged params and the paths of the defaults that ended up in them, so it's clear which ones were applied
+func mergeParamsJSON(defaultsJSON, paramsJSON []byte) (json.RawMessage, []string, error) {
+
+	var defaults, params interface{}
+	err := json.Unmarshal(defaultsJSON, &defaults)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = json.Unmarshal(paramsJSON, &params)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	appliedDefaults := []string{}
+	merged := mergeParamsValues(defaults, params, reflect.TypeOf(Params{}), "", "", &appliedDefaults)
+
+	mergedJSON, err := json.Marshal(merged)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return mergedJSON, appliedDefaults, nil
+}
+
+// mergeParamsValues merges override over base; t is the type the value unmarshals into, path is the actual path used for reporting and
+// rulePath is the path with [] instead of indices, used to look up merge rules
+func mergeParamsValues(base, override interface{}, t reflect.Type, path, rulePath string, appliedDefaults *[]string) interface{} {
+
+	if base == nil {
+		return override
+	}
+	if override == nil {
+		*appliedDefaults = append(*appliedDefaults, leafPaths(base, path)...)
+		return base
+	}
+
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	baseMap, baseIsMap := base.(map[string]interface{})
+	overrideMap, overrideIsMap := override.(map[string]interface{})
+	if baseIsMap && overrideIsMap && t != nil && (t.Kind() == reflect.Struct || t.Kind() == reflect.Map) && !stringArrayContains(paramsMergeReplacedObjects, rulePath) {
+		return mergeParamsObjects(baseMap, overrideMap, t, path, rulePath, appliedDefaults)
+	}
+
+	baseSlice, baseIsSlice := base.([]interface{})
+	overrideSlice, overrideIsSlice := override.([]interface{})
+	if baseIsSlice && overrideIsSlice && t != nil {
+		if stringArrayContains(paramsMergeAppendedLists, rulePath) {
+			for i := range baseSlice {
+				*appliedDefaults = append(*appliedDefaults, fmt.Sprintf("%v[%v]", path, i))
+			}
+			return append(append([]interface{}{}, baseSlice...), overrideSlice...)
+		}
+		if key, ok := paramsMergeKeyedLists[rulePath]; ok {
+			return mergeParamsKeyedLists(baseSlice, overrideSlice, key, t.Elem(), path, rulePath, appliedDefaults)
+		}
+	}
+
+	return override
+}
+
+func mergeParamsObjects(base, override map[string]interface{}, t reflect.Type, path, rulePath string, appliedDefaults *[]string) map[string]interface{} {
+
+	// encoding/json matches struct properties case-insensitively, so use the json name for both to prevent them ending up side by side
+	base = canonicalizeParamsKeys(base, t)
+	override = canonicalizeParamsKeys(override, t)
+
+	var fields map[string]reflect.StructField
+	if t.Kind() == reflect.Struct {
+		fields = jsonFields(t)
+	}
+
+	merged := map[string]interface{}{}
+	for k, v := range override {
+		merged[k] = v
+	}
+
+	for _, k := range sortedKeys(base) {
+		var elemType reflect.Type
+		if t.Kind() == reflect.Map {
+			elemType = t.Elem()
+		} else if field, ok := fields[k]; ok {
+			elemType = field.Type
+		}
+		if elemType != nil && elemType.Kind() == reflect.Interface {
+			// free-form values are replaced as a whole
+			elemType = nil
+		}
+
+		merged[k] = mergeParamsValues(base[k], override[k], elemType, joinPropertyPath(path, k), joinPropertyPath(rulePath, k), appliedDefaults)
+	}
+
+	return merged
+}
+
+func mergeParamsKeyedLists(base, override []interface{}, key string, elemType reflect.Type, path, rulePath string, appliedDefaults *[]string) []interface{} {
+
+	merged := []interface{}{}
+	mergedOverrides := map[int]bool{}
+
+	for _, baseItem := range base {
+		i := len(merged)
+		itemPath := fmt.Sprintf("%v[%v]", path, i)
+
+		overrideIndex := findParamsListItemByKey(override, key, baseItem, mergedOverrides)
+		if overrideIndex == -1 {
+			*appliedDefaults = append(*appliedDefaults, leafPaths(baseItem, itemPath)...)
+			merged = append(merged, baseItem)
+			continue
+		}
+
+		mergedOverrides[overrideIndex] = true
+		merged = append(merged, mergeParamsValues(baseItem, override[overrideIndex], elemType, itemPath, rulePath+"[]", appliedDefaults))
+	}
+
+	for i, overrideItem := range override {
+		if !mergedOverrides[i] {
+			merged = append(merged, overrideItem)
+		}
+	}
+
+	return merged
+}
+
+// findParamsListItemByKey returns the index of the first item in list that isn't skipped and has the same value for key as item, or -1 if there is none
+func findParamsListItemByKey(list []interface{}, key string, item interface{}, skip map[int]bool) int {
+
+	itemMap, ok := item.(map[string]interface{})
+	if !ok {
+		return -1
+	}
+	itemValue, ok := getParamsValueFold(itemMap, key)
+	if !ok || itemValue == nil || itemValue == "" {
+		return -1
+	}
+
+	for i, listItem := range list {
+		if skip[i] {
+			continue
+		}
+		if listItemMap, ok := listItem.(map[string]interface{}); ok {
+			if listItemValue, ok := getParamsValueFold(listItemMap, key); ok && reflect.DeepEqual(itemValue, listItemValue) {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+func getParamsValueFold(m map[string]interface{}, key string) (interface{}, bool) {
+	for k, v := range m {
+		if strings.EqualFold(k, key) {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// overlayParamsValues overlays override over base for applying an environment; objects and maps are merged property by property, but
+// unlike in mergeParamsValues all lists are replaced as a whole
+func overlayParamsValues(base, override interface{}, t reflect.Type) interface{} {
+
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	baseMap, baseIsMap := base.(map[string]interface{})
+	overrideMap, overrideIsMap := override.(map[string]interface{})
+	if !baseIsMap || !overrideIsMap || t == nil || (t.Kind() != reflect.Struct && t.Kind() != reflect.Map) {
+		return override
+	}
+
+	baseMap = canonicalizeParamsKeys(baseMap, t)
+	overrideMap = canonicalizeParamsKeys(overrideMap, t)
+
+	var fields map[string]reflect.StructField
+	if t.Kind() == reflect.Struct {
+		fields = jsonFields(t)
+	}
+
+	overlaid := map[string]interface{}{}
+	for k, v := range baseMap {
+		overlaid[k] = v
+	}
+	for _, k := range sortedKeys(overrideMap) {
+		var elemType reflect.Type
+		if t.Kind() == reflect.Map {
+			elemType = t.Elem()
+		} else if field, ok := fields[k]; ok {
+			elemType = field.Type
+		}
+		if elemType != nil && elemType.Kind() == reflect.Interface {
+			// free-form values are replaced as a whole
+			elemType = nil
+		}
+
+		if baseValue, ok := overlaid[k]; ok {
+			overlaid[k] = overlayParamsValues(baseValue, overrideMap[k], elemType)
+		} else {
+			overlaid[k] = overrideMap[k]
+		}
+	}
+
+	return overlaid
+}
+
+// canonicalizeParamsValue renames the keys of all objects in value that map onto a struct to the json name of the field they unmarshal
+// into, so paths derived from it match the ones of the marshalled params
+func canonicalizeParamsValue(value interface{}, t reflect.Type) interface{} {
+
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil {
+		return value
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if t.Kind() != reflect.Struct && t.Kind() != reflect.Map {
+			return v
+		}
+		v = canonicalizeParamsKeys(v, t)
+
+		var fields map[string]reflect.StructField
+		if t.Kind() == reflect.Struct {
+			fields = jsonFields(t)
+		}
+		for k, item := range v {
+			var elemType reflect.Type
+			if t.Kind() == reflect.Map {
+				elemType = t.Elem()
+			} else if field, ok := fields[k]; ok {
+				elemType = field.Type
+			}
+			if elemType != nil && elemType.Kind() != reflect.Interface {
+				v[k] = canonicalizeParamsValue(item, elemType)
+			}
+		}
+		return v
+
+	case []interface{}:
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			return v
+		}
+		for i, item := range v {
+			v[i] = canonicalizeParamsValue(item, t.Elem())
+		}
+		return v
+	}
+
+	return value
+}
+
+// canonicalizeParamsKeys renames the keys of an object that maps onto a struct to the json name of the field they unmarshal into
+func canonicalizeParamsKeys(value map[string]interface{}, t reflect.Type) map[string]interface{} {
+
+	if t.Kind() != reflect.Struct {
+		return value
+	}
+
+	fields := jsonFields(t)
+	canonicalized := make(map[string]interface{}, len(value))
+	for _, k := range sortedKeys(value) {
+		name := k
+		if _, ok := fields[k]; !ok {
+			for fieldName := range fields {
+				if strings.EqualFold(fieldName, k) {
+					name = fieldName
+					break
+				}
+			}
+		}
+		canonicalized[name] = value[k]
+	}
+
+	return canonicalized
+}
+
+// leafPaths returns the paths of all non-object values below path, with lists reported as a whole; the defaults merge uses it to report
+// which defaults were applied and the explain output to attribute each property to the stage, credential defaults or an environment
+func leafPaths(value interface{}, path string) []string {
+	valueMap, ok := value.(map[string]interface{})
+	if !ok || len(valueMap) == 0 {
+		return []string{path}
+	}
+
+	paths := []string{}
+	for _, k := range sortedKeys(valueMap) {
+		paths = append(paths, leafPaths(valueMap[k], joinPropertyPath(path, k))...)
+	}
+	return paths
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeParamsJSON(t *testing.T) {
+
+	t.Run("MergesNestedObjects", func(t *testing.T) {
+
+		defaultsJSON := `{"autoscale":{"min":3,"max":50},"container":{"cpu":{"request":"100m","limit":"200m"}}}`
+		paramsJSON := `{"autoscale":{"max":10}}`
+
+		// act
+		mergedJSON, _, err := mergeParamsJSON([]byte(defaultsJSON), []byte(paramsJSON))
+
+		assert.Nil(t, err)
+		var params Params
+		json.Unmarshal(mergedJSON, &params)
+		assert.Equal(t, 3, params.Autoscale.MinReplicas)
+		assert.Equal(t, 10, params.Autoscale.MaxReplicas)
+		assert.Equal(t, "200m", params.Container.CPU.Limit)
+	})
+
+	t.Run("MergesMaps", func(t *testing.T) {
+
+		defaultsJSON := `{"labels":{"team":"platform","language":"golang"},"container":{"env":{"JAEGER_SAMPLER_TYPE":"probabilistic"}}}`
+		paramsJSON := `{"labels":{"team":"myteam"},"container":{"env":{"MY_ENV":"value"}}}`
+
+		// act
+		mergedJSON, _, err := mergeParamsJSON([]byte(defaultsJSON), []byte(paramsJSON))
+
+		assert.Nil(t, err)
+		var params Params
+		json.Unmarshal(mergedJSON, &params)
+		assert.Equal(t, map[string]string{"team": "myteam", "language": "golang"}, params.Labels)
+		assert.Equal(t, "probabilistic", params.Container.EnvironmentVariables["JAEGER_SAMPLER_TYPE"])
+		assert.Equal(t, "value", params.Container.EnvironmentVariables["MY_ENV"])
+	})
+
+	t.Run("ReplacesLists", func(t *testing.T) {
+
+		defaultsJSON := `{"whitelist":["10.0.0.0/8"],"hosts":["default.example.com"]}`
+		paramsJSON := `{"whitelist":["192.168.0.0/16"]}`
+
+		// act
+		mergedJSON, _, err := mergeParamsJSON([]byte(defaultsJSON), []byte(paramsJSON))
+
+		assert.Nil(t, err)
+		var params Params
+		json.Unmarshal(mergedJSON, &params)
+		assert.Equal(t, []string{"192.168.0.0/16"}, params.WhitelistedIPS)
+		assert.Equal(t, []string{"default.example.com"}, params.Hosts)
+	})
+
+	t.Run("AppendsTrustedIPs", func(t *testing.T) {
+
+		defaultsJSON := `{"trustedips":["103.21.244.0/22"]}`
+		paramsJSON := `{"trustedips":["10.0.0.0/8"]}`
+
+		// act
+		mergedJSON, appliedDefaults, err := mergeParamsJSON([]byte(defaultsJSON), []byte(paramsJSON))
+
+		assert.Nil(t, err)
+		var params Params
+		json.Unmarshal(mergedJSON, &params)
+		assert.Equal(t, []string{"103.21.244.0/22", "10.0.0.0/8"}, params.TrustedIPRanges)
+		assert.Equal(t, []string{"trustedips[0]"}, appliedDefaults)
+	})
+
+	t.Run("MergesSidecarsByType", func(t *testing.T) {
+
+		defaultsJSON := `{"sidecars":[{"type":"openresty","image":"estafette/openresty-sidecar:1.13","cpu":{"request":"10m"}},{"type":"cloudsqlproxy","image":"gcr.io/cloudsql-docker/gce-proxy:1.16"}]}`
+		paramsJSON := `{"sidecars":[{"type":"openresty","cpu":{"request":"50m"}}]}`
+
+		// act
+		mergedJSON, _, err := mergeParamsJSON([]byte(defaultsJSON), []byte(paramsJSON))
+
+		assert.Nil(t, err)
+		var params Params
+		json.Unmarshal(mergedJSON, &params)
+		assert.Equal(t, 2, len(params.Sidecars))
+		assert.Equal(t, "openresty", params.Sidecars[0].Type)
+		assert.Equal(t, "estafette/openresty-sidecar:1.13", params.Sidecars[0].Image)
+		assert.Equal(t, "50m", params.Sidecars[0].CPU.Request)
+		assert.Equal(t, "cloudsqlproxy", params.Sidecars[1].Type)
+	})
+
+	t.Run("AppendsSidecarsWithTypeNotInDefaults", func(t *testing.T) {
+
+		defaultsJSON := `{"sidecars":[{"type":"openresty","image":"estafette/openresty-sidecar:1.13"}]}`
+		paramsJSON := `{"sidecars":[{"type":"cloudsqlproxy","dbinstanceconnectionname":"project:region:db"}]}`
+
+		// act
+		mergedJSON, _, err := mergeParamsJSON([]byte(defaultsJSON), []byte(paramsJSON))
+
+		assert.Nil(t, err)
+		var params Params
+		json.Unmarshal(mergedJSON, &params)
+		assert.Equal(t, 2, len(params.Sidecars))
+		assert.Equal(t, "openresty", params.Sidecars[0].Type)
+		assert.Equal(t, "cloudsqlproxy", params.Sidecars[1].Type)
+		assert.Equal(t, "project:region:db", params.Sidecars[1].DbInstanceConnectionName)
+	})
+
+	t.Run("MergesSidecarOnlyOnceIfDefaultsHaveTheSameTypeTwice", func(t *testing.T) {
+
+		defaultsJSON := `{"sidecars":[{"type":"cloudsqlproxy","sqlproxyport":5043},{"type":"cloudsqlproxy","sqlproxyport":5044}]}`
+		paramsJSON := `{"sidecars":[{"type":"cloudsqlproxy","dbinstanceconnectionname":"project:region:db"}]}`
+
+		// act
+		mergedJSON, _, err := mergeParamsJSON([]byte(defaultsJSON), []byte(paramsJSON))
+
+		assert.Nil(t, err)
+		var params Params
+		json.Unmarshal(mergedJSON, &params)
+		assert.Equal(t, 2, len(params.Sidecars))
+		assert.Equal(t, "project:region:db", params.Sidecars[0].DbInstanceConnectionName)
+		assert.Equal(t, "", params.Sidecars[1].DbInstanceConnectionName)
+	})
+
+	t.Run("MergesVolumeMountsByNameButReplacesTheirVolume", func(t *testing.T) {
+
+		defaultsJSON := `{"volumemounts":[{"name":"client-certs","mountpath":"/client-certs","volume":{"secret":{"secretName":"client-certs"}}}]}`
+		paramsJSON := `{"volumemounts":[{"name":"client-certs","volume":{"configMap":{"name":"client-certs"}}}]}`
+
+		// act
+		mergedJSON, _, err := mergeParamsJSON([]byte(defaultsJSON), []byte(paramsJSON))
+
+		assert.Nil(t, err)
+		var params Params
+		json.Unmarshal(mergedJSON, &params)
+		assert.Equal(t, 1, len(params.VolumeMounts))
+		assert.Equal(t, "/client-certs", params.VolumeMounts[0].MountPath)
+		assert.Equal(t, 1, len(params.VolumeMounts[0].Volume))
+		assert.NotNil(t, params.VolumeMounts[0].Volume["configMap"])
+	})
+
+	t.Run("MergesInitContainersByName", func(t *testing.T) {
+
+		defaultsJSON := `{"initcontainers":[{"name":"wait-for-proxy","image":"busybox:1.27.2","command":["sleep","5"]}]}`
+		paramsJSON := `{"initcontainers":[{"name":"wait-for-proxy","command":["sleep","10"]},{"name":"migrate","command":["/app/migrate"]}]}`
+
+		// act
+		mergedJSON, _, err := mergeParamsJSON([]byte(defaultsJSON), []byte(paramsJSON))
+
+		assert.Nil(t, err)
+		var params Params
+		json.Unmarshal(mergedJSON, &params)
+		assert.Equal(t, 2, len(params.InitContainers))
+		assert.Equal(t, "busybox:1.27.2", params.InitContainers[0].Image)
+		assert.Equal(t, []string{"sleep", "10"}, params.InitContainers[0].Command)
+		assert.Equal(t, "migrate", params.InitContainers[1].Name)
+	})
+
+	t.Run("MergesPropertiesThatOnlyDifferInCasing", func(t *testing.T) {
+
+		defaultsJSON := `{"iapoauthclientid":"default-id"}`
+		paramsJSON := `{"iapOauthClientID":"my-id"}`
+
+		// act
+		mergedJSON, appliedDefaults, err := mergeParamsJSON([]byte(defaultsJSON), []byte(paramsJSON))
+
+		assert.Nil(t, err)
+		var params Params
+		json.Unmarshal(mergedJSON, &params)
+		assert.Equal(t, "my-id", params.IapOauthCredentialsClientID)
+		assert.Equal(t, 0, len(appliedDefaults))
+	})
+
+	t.Run("ReturnsPathsOfAppliedDefaults", func(t *testing.T) {
+
+		defaultsJSON := `{"autoscale":{"min":3,"max":50},"hosts":["default.example.com"],"sidecars":[{"type":"openresty","image":"estafette/openresty-sidecar:1.13","cpu":{"request":"10m"}}]}`
+		paramsJSON := `{"autoscale":{"max":10},"sidecars":[{"type":"openresty","image":"estafette/openresty-sidecar:1.15"}]}`
+
+		// act
+		_, appliedDefaults, err := mergeParamsJSON([]byte(defaultsJSON), []byte(paramsJSON))
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"autoscale.min", "hosts", "sidecars[0].cpu.request"}, appliedDefaults)
+	})
+
+	t.Run("KeepsPropertiesUnknownToParams", func(t *testing.T) {
+
+		defaultsJSON := `{"visibility":"private"}`
+		paramsJSON := `{"credentials":"gke-production"}`
+
+		// act
+		mergedJSON, _, err := mergeParamsJSON([]byte(defaultsJSON), []byte(paramsJSON))
+
+		assert.Nil(t, err)
+		var credentialsParam CredentialsParam
+		json.Unmarshal(mergedJSON, &credentialsParam)
+		assert.Equal(t, "gke-production", credentialsParam.Credentials)
+	})
+
+	t.Run("ReturnsErrorForInvalidJSON", func(t *testing.T) {
+
+		// act
+		_, _, err := mergeParamsJSON([]byte(`{}`), []byte(`{"app":`))
+
+		assert.NotNil(t, err)
+	})
+}
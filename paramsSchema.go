@@ -0,0 +1,333 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// JSONSchema is the subset of JSON Schema (draft-07) used to describe the custom properties of this extension
+type JSONSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	ID                   string                 `json:"$id,omitempty"`
+	Ref                  string                 `json:"$ref,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	AdditionalProperties interface{}            `json:"additionalProperties,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	Enum                 []interface{}          `json:"enum,omitempty"`
+	Default              interface{}            `json:"default,omitempty"`
+}
+
+var (
+	// allowed values for properties, keyed by their path in the schema; list items are denoted by []
+	paramsSchemaEnums = map[string][]interface{}{
+		"action":                                 {"deploy-simple", "deploy-canary", "deploy-stable", "rollback-canary", "deploy-babysit"},
+		"kind":                                   {"deployment", "job", "cronjob", "statefulset", "daemonset"},
+		"visibility":                             {"private", "iap", "public-whitelist", "public"},
+		"protocol":                               {"http1", "http2", "grpc"},
+		"concurrencypolicy":                      {"Allow", "Forbid", "Replace"},
+		"unknownproperties":                      {"warn", "error"},
+		"sidecar.type":                           {"openresty", "cloudsqlproxy", "none"},
+		"sidecars[].type":                        {"openresty", "cloudsqlproxy"},
+		"container.additionalports[].protocol":   {"TCP", "UDP"},
+		"container.liveness.type":                {"http", "tcp", "exec", "grpc"},
+		"container.liveness.scheme":              {"HTTP", "HTTPS"},
+		"container.readiness.type":               {"http", "tcp", "exec", "grpc"},
+		"container.readiness.scheme":             {"HTTP", "HTTPS"},
+		"container.startup.type":                 {"http", "tcp", "exec", "grpc"},
+		"container.startup.scheme":               {"HTTP", "HTTPS"},
+		"container.additionalports[].visibility": {"private", "iap", "public-whitelist", "public"},
+		"scheduling.tolerations[].operator":      {"Equal", "Exists"},
+		"scheduling.tolerations[].effect":        {"NoSchedule", "PreferNoSchedule", "NoExecute"},
+		"scheduling.topologyspreadconstraints[].whenunsatisfiable": {"DoNotSchedule", "ScheduleAnyway"},
+		"autoscale.metrics[].type":                                 {"pods", "object", "external"},
+		"autoscale.metrics[].targettype":                           {"Value", "AverageValue"},
+		"autoscale.behavior.scaleup.selectpolicy":                  {"Max", "Min", "Disabled"},
+		"autoscale.behavior.scaleup.policies[].type":               {"Pods", "Percent"},
+		"autoscale.behavior.scaledown.selectpolicy":                {"Max", "Min", "Disabled"},
+		"autoscale.behavior.scaledown.policies[].type":             {"Pods", "Percent"},
+		"securitycontext.preset":                                   {"restricted", "none"},
+		"securitycontext.pod.seccompprofile":                       {"RuntimeDefault", "Unconfined"},
+		"scheduling.antiaffinity.type":                             {"preferred", "required", "none"},
+		"networkpolicy.egress[].ports[].protocol":                  {"TCP", "UDP"},
+		"ingress.auth.type":                                        {"basic", "external"},
+		"certificate.provider":                                     {"letsencrypt", "cert-manager", "managed", "secret"},
+		"certificate.issuerkind":                                   {"ClusterIssuer", "Issuer"},
+		"dns.public.provider":                                      {"cloudflare", "google-cloud-dns", "external-dns", "none"},
+		"dns.internal.provider":                                    {"cloudflare", "google-cloud-dns", "external-dns", "none"},
+		"mesh.type":                                                {"", "istio"},
+		"statefulset.podmanagementpolicy":                          {"OrderedReady", "Parallel"},
+		"daemonset.updatestrategy":                                 {"RollingUpdate", "OnDelete"},
+		"daemonset.hostpathvolumes[].type":                         {"DirectoryOrCreate", "Directory", "FileOrCreate", "File", "Socket", "CharDevice", "BlockDevice"},
+	}
+
+	// properties for which the default depends on other properties or the environment, so no static default can be documented
+	paramsSchemaDynamicDefaults = []string{
+		"labels",
+		"googleCloudCredentialsApp",
+		"autoscale.safety.promquery",
+		"container.liveness.type",
+		"container.liveness.port",
+		"container.readiness.type",
+		"container.readiness.port",
+		"container.metrics.port",
+		"scheduling.antiaffinity.type",
+		"injecthttpproxysidecar",
+	}
+)
+
+// generateParamsSchema describes the Params type as json schema, including allowed values and the convention-based defaults from SetDefaults
+func generateParamsSchema() *JSONSchema {
+
+	schema := generateSchemaForType(reflect.TypeOf(Params{}), "", getParamsSchemaDefaults())
+	schema.Schema = "http://json-schema.org/draft-07/schema#"
+	schema.ID = "https://github.com/estafette/estafette-extension-gke/params.schema.json"
+	schema.Title = "estafette-extension-gke"
+	schema.Description = "Custom properties for the estafette/extension-gke stage in an .estafette.yaml manifest"
+
+	// the credentials property is handled by CredentialsParam instead of Params
+	schema.Properties["credentials"] = &JSONSchema{
+		Type:        "string",
+		Description: "Name of the kubernetes-engine credentials to use; defaults to gke-<release name>",
+	}
+
+	return schema
+}
+
+func generateSchemaForType(t reflect.Type, path string, defaults map[string]interface{}) *JSONSchema {
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	// params nested in params, like environments, refer to the root schema
+	if t == reflect.TypeOf(Params{}) && path != "" {
+		return &JSONSchema{Ref: "#"}
+	}
+
+	schema := &JSONSchema{}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		schema.Type = "object"
+		schema.Properties = map[string]*JSONSchema{}
+		schema.AdditionalProperties = false
+		for name, field := range jsonFields(t) {
+			fieldPath := joinPropertyPath(path, name)
+			fieldSchema := generateSchemaForType(field.Type, fieldPath, defaults)
+			if jsonTagHasOption(field, "string") {
+				// values are encoded as json strings
+				fieldSchema.Type = "string"
+			}
+			schema.Properties[name] = fieldSchema
+		}
+
+	case reflect.Slice, reflect.Array:
+		schema.Type = "array"
+		schema.Items = generateSchemaForType(t.Elem(), path+"[]", defaults)
+
+	case reflect.Map:
+		schema.Type = "object"
+		if t.Elem().Kind() != reflect.Interface {
+			schema.AdditionalProperties = generateSchemaForType(t.Elem(), joinPropertyPath(path, "*"), defaults)
+		}
+
+	case reflect.String:
+		schema.Type = "string"
+
+	case reflect.Bool:
+		schema.Type = "boolean"
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		schema.Type = "integer"
+
+	case reflect.Float32, reflect.Float64:
+		schema.Type = "number"
+	}
+
+	if enum, ok := paramsSchemaEnums[path]; ok {
+		schema.Enum = enum
+	}
+	if defaultValue, ok := defaults[path]; ok {
+		schema.Default = defaultValue
+	}
+
+	return schema
+}
+
+func jsonTagHasOption(field reflect.StructField, option string) bool {
+	for _, o := range strings.Split(field.Tag.Get("json"), ",")[1:] {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+// getParamsSchemaDefaults runs SetDefaults on empty params and returns the resulting values by property path
+func getParamsSchemaDefaults() map[string]interface{} {
+
+	defaults := map[string]interface{}{}
+
+	// some defaults only apply for a specific kind, so collect the defaults for all of them
+	for _, kind := range []string{"", "cronjob"} {
+		params := Params{Kind: kind}
+		params.SetDefaults("", "", "", "", "", map[string]string{})
+
+		data, err := json.Marshal(params)
+		if err != nil {
+			continue
+		}
+		var values map[string]interface{}
+		err = json.Unmarshal(data, &values)
+		if err != nil {
+			continue
+		}
+
+		collectSchemaDefaults(values, "", defaults)
+	}
+
+	for _, path := range paramsSchemaDynamicDefaults {
+		delete(defaults, path)
+	}
+
+	return defaults
+}
+
+func collectSchemaDefaults(values map[string]interface{}, path string, defaults map[string]interface{}) {
+	for key, value := range values {
+		keyPath := joinPropertyPath(path, key)
+		if nestedValues, ok := value.(map[string]interface{}); ok {
+			collectSchemaDefaults(nestedValues, keyPath, defaults)
+			continue
+		}
+		if _, ok := value.([]interface{}); ok && keyPath == "sidecars" {
+			// the injected openresty sidecar is not a default for the list as a whole
+			continue
+		}
+		if _, ok := defaults[keyPath]; !ok {
+			defaults[keyPath] = value
+		}
+	}
+}
+
+// validateAgainstSchema checks types and allowed values; unknown properties are reported by decodeStrict instead, since it can suggest the intended property
+func validateAgainstSchema(schema *JSONSchema, value interface{}, path string) (violations []ValidationError) {
+
+	if schema == nil || value == nil {
+		return
+	}
+
+	if schema.Ref == "#" {
+		schema = generateParamsSchema()
+	}
+
+	switch schema.Type {
+	case "object":
+		valueMap, ok := value.(map[string]interface{})
+		if !ok {
+			return append(violations, newValidationError(path, "schema-violation", "Property %v should be an object but is %v", path, jsonTypeOf(value)))
+		}
+		for _, key := range sortedKeys(valueMap) {
+			propertySchema := lookupPropertySchema(schema, key)
+			if propertySchema == nil {
+				continue
+			}
+			violations = append(violations, validateAgainstSchema(propertySchema, valueMap[key], joinPropertyPath(path, key))...)
+		}
+
+	case "array":
+		valueSlice, ok := value.([]interface{})
+		if !ok {
+			return append(violations, newValidationError(path, "schema-violation", "Property %v should be an array but is %v", path, jsonTypeOf(value)))
+		}
+		for i, v := range valueSlice {
+			violations = append(violations, validateAgainstSchema(schema.Items, v, fmt.Sprintf("%v[%v]", path, i))...)
+		}
+
+	case "string", "boolean", "number":
+		if jsonTypeOf(value) != schema.Type && !(schema.Type == "number" && jsonTypeOf(value) == "integer") {
+			return append(violations, newValidationError(path, "schema-violation", "Property %v should be a %v but is %v", path, schema.Type, jsonTypeOf(value)))
+		}
+
+	case "integer":
+		if jsonTypeOf(value) != "integer" {
+			return append(violations, newValidationError(path, "schema-violation", "Property %v should be an integer but is %v", path, jsonTypeOf(value)))
+		}
+	}
+
+	if len(schema.Enum) > 0 {
+		for _, e := range schema.Enum {
+			if reflect.DeepEqual(e, value) {
+				return
+			}
+		}
+		allowedValues := make([]string, len(schema.Enum))
+		for i, e := range schema.Enum {
+			allowedValues[i] = fmt.Sprintf("%v", e)
+		}
+		violations = append(violations, newValidationError(path, "schema-violation", "Property %v has value %v; allowed values are %v", path, value, strings.Join(allowedValues, ", ")))
+	}
+
+	return
+}
+
+func lookupPropertySchema(schema *JSONSchema, key string) *JSONSchema {
+	if propertySchema, ok := schema.Properties[key]; ok {
+		return propertySchema
+	}
+	// encoding/json matches keys case-insensitively, so do the same
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if strings.EqualFold(name, key) {
+			return schema.Properties[name]
+		}
+	}
+	if additionalPropertiesSchema, ok := schema.AdditionalProperties.(*JSONSchema); ok {
+		return additionalPropertiesSchema
+	}
+	return nil
+}
+
+func jsonTypeOf(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return "unknown"
+}
+
+// ValidateParamsJSON validates raw custom properties against the json schema for Params
+func ValidateParamsJSON(data []byte, rootPath string) ([]ValidationError, error) {
+
+	var value interface{}
+	err := json.Unmarshal(data, &value)
+	if err != nil {
+		return nil, err
+	}
+
+	return validateAgainstSchema(generateParamsSchema(), value, rootPath), nil
+}
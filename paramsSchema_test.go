@@ -0,0 +1,169 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateParamsSchema(t *testing.T) {
+
+	t.Run("DescribesNestedPropertiesByJSONName", func(t *testing.T) {
+
+		// act
+		schema := generateParamsSchema()
+
+		assert.Equal(t, "object", schema.Type)
+		assert.Equal(t, "integer", schema.Properties["autoscale"].Properties["min"].Type)
+		assert.Equal(t, "string", schema.Properties["sidecars"].Items.Properties["dbinstanceconnectionname"].Type)
+		assert.Equal(t, "boolean", schema.Properties["container"].Properties["metrics"].Properties["scrape"].Type)
+	})
+
+	t.Run("DoesNotAllowAdditionalPropertiesOnStructs", func(t *testing.T) {
+
+		// act
+		schema := generateParamsSchema()
+
+		assert.Equal(t, false, schema.AdditionalProperties)
+		assert.Equal(t, false, schema.Properties["container"].AdditionalProperties)
+	})
+
+	t.Run("RefersToRootSchemaForEnvironments", func(t *testing.T) {
+
+		// act
+		schema := generateParamsSchema()
+
+		assert.Equal(t, "object", schema.Properties["environments"].Type)
+		assert.Equal(t, "#", schema.Properties["environments"].AdditionalProperties.(*JSONSchema).Ref)
+	})
+
+	t.Run("IncludesCredentialsProperty", func(t *testing.T) {
+
+		// act
+		schema := generateParamsSchema()
+
+		assert.Equal(t, "string", schema.Properties["credentials"].Type)
+	})
+
+	t.Run("DescribesStringEncodedNumbersAsString", func(t *testing.T) {
+
+		// act
+		schema := generateParamsSchema()
+
+		assert.Equal(t, "string", schema.Properties["autoscale"].Properties["safety"].Properties["ratio"].Type)
+	})
+
+	t.Run("IncludesEnums", func(t *testing.T) {
+
+		// act
+		schema := generateParamsSchema()
+
+		assert.Equal(t, []interface{}{"private", "iap", "public-whitelist", "public"}, schema.Properties["visibility"].Enum)
+		assert.Equal(t, []interface{}{"openresty", "cloudsqlproxy"}, schema.Properties["sidecars"].Items.Properties["type"].Enum)
+	})
+
+	t.Run("IncludesDefaultsFromSetDefaults", func(t *testing.T) {
+
+		// act
+		schema := generateParamsSchema()
+
+		assert.Equal(t, "deployment", schema.Properties["kind"].Default)
+		assert.Equal(t, float64(3), schema.Properties["autoscale"].Properties["min"].Default)
+		assert.Equal(t, "/liveness", schema.Properties["container"].Properties["liveness"].Properties["path"].Default)
+		assert.Equal(t, "Allow", schema.Properties["concurrencypolicy"].Default)
+	})
+
+	t.Run("OmitsDefaultsThatDependOnOtherProperties", func(t *testing.T) {
+
+		// act
+		schema := generateParamsSchema()
+
+		assert.Nil(t, schema.Properties["autoscale"].Properties["safety"].Properties["promquery"].Default)
+		assert.Nil(t, schema.Properties["container"].Properties["liveness"].Properties["port"].Default)
+	})
+}
+
+func TestValidateParamsJSON(t *testing.T) {
+
+	t.Run("ReturnsNoViolationsForValidParams", func(t *testing.T) {
+
+		paramsJSON := `{"app":"myapp","visibility":"public","autoscale":{"min":2,"safety":{"ratio":"1.5"}},"container":{"env":{"MY_ENV":"value"}},"labels":{"team":"myteam"}}`
+
+		// act
+		violations, err := ValidateParamsJSON([]byte(paramsJSON), "")
+
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(violations))
+	})
+
+	t.Run("ReturnsViolationForWrongType", func(t *testing.T) {
+
+		paramsJSON := `{"autoscale":{"min":"two"}}`
+
+		// act
+		violations, err := ValidateParamsJSON([]byte(paramsJSON), "")
+
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(violations))
+		assert.Equal(t, "autoscale.min", violations[0].Path)
+		assert.Equal(t, "Property autoscale.min should be an integer but is string", violations[0].Error())
+	})
+
+	t.Run("ReturnsViolationForValueNotInEnum", func(t *testing.T) {
+
+		paramsJSON := `{"sidecars":[{"type":"openresty"},{"type":"envoy"}]}`
+
+		// act
+		violations, err := ValidateParamsJSON([]byte(paramsJSON), "defaults")
+
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(violations))
+		assert.Equal(t, "defaults.sidecars[1].type", violations[0].Path)
+	})
+
+	t.Run("AcceptsEmptyMeshTypeToTurnOffTheMesh", func(t *testing.T) {
+
+		paramsJSON := `{"mesh":{"type":""}}`
+
+		// act
+		violations, err := ValidateParamsJSON([]byte(paramsJSON), "")
+
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(violations))
+	})
+
+	t.Run("ReturnsViolationForNonIntegerNumber", func(t *testing.T) {
+
+		paramsJSON := `{"container":{"port":80.5}}`
+
+		// act
+		violations, err := ValidateParamsJSON([]byte(paramsJSON), "")
+
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(violations))
+		assert.Equal(t, "container.port", violations[0].Path)
+	})
+
+	t.Run("ValidatesEnvironmentsAgainstRootSchema", func(t *testing.T) {
+
+		paramsJSON := `{"environments":{"production":{"visibility":"secret"}}}`
+
+		// act
+		violations, err := ValidateParamsJSON([]byte(paramsJSON), "")
+
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(violations))
+		assert.Equal(t, "environments.production.visibility", violations[0].Path)
+	})
+
+	t.Run("IgnoresUnknownProperties", func(t *testing.T) {
+
+		paramsJSON := `{"autoscale":{"mim":2}}`
+
+		// act
+		violations, err := ValidateParamsJSON([]byte(paramsJSON), "")
+
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(violations))
+	})
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"strings"
 	"testing"
 
@@ -11,9 +12,10 @@ var (
 	trueValue   = true
 	falseValue  = false
 	validParams = Params{
-		Action:    "deploy-simple",
-		App:       "myapp",
-		Namespace: "mynamespace",
+		Action:            "deploy-simple",
+		App:               "myapp",
+		Namespace:         "mynamespace",
+		UnknownProperties: "warn",
 		Autoscale: AutoscaleParams{
 			MinReplicas:   3,
 			MaxReplicas:   100,
@@ -1187,6 +1189,88 @@ func TestSetDefaults(t *testing.T) {
 		assert.Equal(t, 8082, params.Container.ReadinessProbe.Port)
 	})
 
+	t.Run("DefaultsProbeTypesToHttp", func(t *testing.T) {
+
+		params := Params{}
+
+		// act
+		params.SetDefaults("", "", "", "", "", map[string]string{})
+
+		assert.Equal(t, "http", params.Container.LivenessProbe.Type)
+		assert.Equal(t, "http", params.Container.ReadinessProbe.Type)
+	})
+
+	t.Run("DoesNotDefaultProbePathIfTypeIsNotHttp", func(t *testing.T) {
+
+		params := Params{
+			Container: ContainerParams{
+				LivenessProbe: ProbeParams{
+					Type: "tcp",
+				},
+			},
+		}
+
+		// act
+		params.SetDefaults("", "", "", "", "", map[string]string{})
+
+		assert.Equal(t, "", params.Container.LivenessProbe.Path)
+	})
+
+	t.Run("DoesNotSetStartupProbeIfNotSet", func(t *testing.T) {
+
+		params := Params{}
+
+		// act
+		params.SetDefaults("", "", "", "", "", map[string]string{})
+
+		assert.Nil(t, params.Container.StartupProbe)
+	})
+
+	t.Run("DefaultsStartupProbeToLivenessProbeWithFailureThreshold30IfSet", func(t *testing.T) {
+
+		params := Params{
+			Container: ContainerParams{
+				Port: 8080,
+				LivenessProbe: ProbeParams{
+					Path: "/healthz",
+				},
+				StartupProbe: &ProbeParams{},
+			},
+		}
+
+		// act
+		params.SetDefaults("", "", "", "", "", map[string]string{})
+
+		assert.Equal(t, "http", params.Container.StartupProbe.Type)
+		assert.Equal(t, "/healthz", params.Container.StartupProbe.Path)
+		assert.Equal(t, 8080, params.Container.StartupProbe.Port)
+		assert.Equal(t, 1, params.Container.StartupProbe.TimeoutSeconds)
+		assert.Equal(t, 10, params.Container.StartupProbe.PeriodSeconds)
+		assert.Equal(t, 30, params.Container.StartupProbe.FailureThreshold)
+	})
+
+	t.Run("KeepsStartupProbeTypeAndPropertiesIfSet", func(t *testing.T) {
+
+		params := Params{
+			Container: ContainerParams{
+				Port: 8080,
+				StartupProbe: &ProbeParams{
+					Type:             "exec",
+					Command:          []string{"cat", "/tmp/started"},
+					FailureThreshold: 60,
+				},
+			},
+		}
+
+		// act
+		params.SetDefaults("", "", "", "", "", map[string]string{})
+
+		assert.Equal(t, "exec", params.Container.StartupProbe.Type)
+		assert.Equal(t, "", params.Container.StartupProbe.Path)
+		assert.Equal(t, []string{"cat", "/tmp/started"}, params.Container.StartupProbe.Command)
+		assert.Equal(t, 60, params.Container.StartupProbe.FailureThreshold)
+	})
+
 	t.Run("DefaultsMetricsPathToMetricsIfEmpty", func(t *testing.T) {
 
 		params := Params{
@@ -2087,256 +2171,397 @@ func TestSetDefaults(t *testing.T) {
 
 		assert.Equal(t, "job", params.Kind)
 	})
-}
-
-func TestValidateRequiredProperties(t *testing.T) {
 
-	t.Run("ReturnsFalseIfAppIsNotSet", func(t *testing.T) {
+	t.Run("DefaultsUnknownPropertiesToWarn", func(t *testing.T) {
 
-		params := validParams
-		params.App = ""
+		params := Params{}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		params.SetDefaults("", "", "", "", "", map[string]string{})
 
-		assert.False(t, valid)
-		assert.True(t, len(errors) > 0)
+		assert.Equal(t, "warn", params.UnknownProperties)
 	})
 
-	t.Run("ReturnsTrueIfAppIsSet", func(t *testing.T) {
+	t.Run("KeepsUnknownPropertiesIfSet", func(t *testing.T) {
 
-		params := validParams
-		params.App = "myapp"
+		params := Params{
+			UnknownProperties: "error",
+		}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		params.SetDefaults("", "", "", "", "", map[string]string{})
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.Equal(t, "error", params.UnknownProperties)
 	})
 
-	t.Run("ReturnsFalseIfNamespaceIsNotSet", func(t *testing.T) {
+	t.Run("DefaultsToPreferredHostnameAntiAffinityForDeployments", func(t *testing.T) {
 
-		params := validParams
-		params.Namespace = ""
+		params := Params{}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		params.SetDefaults("", "", "", "", "", map[string]string{})
 
-		assert.False(t, valid)
-		assert.True(t, len(errors) > 0)
+		assert.Equal(t, "preferred", params.Scheduling.AntiAffinity.Type)
+		assert.Equal(t, "kubernetes.io/hostname", params.Scheduling.AntiAffinity.TopologyKey)
+		assert.Equal(t, 100, params.Scheduling.AntiAffinity.Weight)
 	})
 
-	t.Run("ReturnsTrueIfNamespaceIsSet", func(t *testing.T) {
+	t.Run("DefaultsToNoAntiAffinityForJobs", func(t *testing.T) {
 
-		params := validParams
-		params.Namespace = "mynamespace"
+		params := Params{
+			Kind: "cronjob",
+		}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		params.SetDefaults("", "", "", "", "", map[string]string{})
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.Equal(t, "none", params.Scheduling.AntiAffinity.Type)
+		assert.Equal(t, 0, params.Scheduling.AntiAffinity.Weight)
 	})
 
-	t.Run("ReturnsFalseIfImageRepositoryIsNotSet", func(t *testing.T) {
+	t.Run("DefaultsTolerationOperatorAndTopologySpreadAcrossZones", func(t *testing.T) {
 
-		params := validParams
-		params.Container.ImageRepository = ""
+		params := Params{
+			Scheduling: SchedulingParams{
+				Tolerations:               []TolerationParams{{Key: "dedicated", Value: "myapp", Effect: "NoSchedule"}},
+				TopologySpreadConstraints: []TopologySpreadConstraintParams{{}},
+			},
+		}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		params.SetDefaults("", "", "", "", "", map[string]string{})
 
-		assert.False(t, valid)
-		assert.True(t, len(errors) > 0)
+		assert.Equal(t, "Equal", params.Scheduling.Tolerations[0].Operator)
+		assert.Equal(t, "topology.kubernetes.io/zone", params.Scheduling.TopologySpreadConstraints[0].TopologyKey)
+		assert.Equal(t, 1, params.Scheduling.TopologySpreadConstraints[0].MaxSkew)
+		assert.Equal(t, "ScheduleAnyway", params.Scheduling.TopologySpreadConstraints[0].WhenUnsatisfiable)
 	})
 
-	t.Run("ReturnsTrueIfImageRepositoryIsSet", func(t *testing.T) {
+	t.Run("DefaultsToNoSecurityContext", func(t *testing.T) {
 
-		params := validParams
-		params.Container.ImageRepository = "myrepository"
+		params := Params{}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		params.SetDefaults("", "", "", "", "", map[string]string{})
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.Equal(t, "none", params.SecurityContext.Preset)
+		assert.Nil(t, params.SecurityContext.Pod.RunAsNonRoot)
+		assert.Nil(t, params.SecurityContext.Container.ReadOnlyRootFilesystem)
+		assert.Nil(t, params.SecurityContext.Container.DropCapabilities)
 	})
 
-	t.Run("ReturnsFalseIfImageNameIsNotSet", func(t *testing.T) {
+	t.Run("DefaultsSecurityContextToSecureValuesForRestrictedPreset", func(t *testing.T) {
 
-		params := validParams
-		params.Container.ImageName = ""
+		params := Params{
+			SecurityContext: SecurityContextParams{
+				Preset: "restricted",
+			},
+		}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		params.SetDefaults("", "", "", "", "", map[string]string{})
 
-		assert.False(t, valid)
-		assert.True(t, len(errors) > 0)
+		assert.True(t, *params.SecurityContext.Pod.RunAsNonRoot)
+		assert.Equal(t, "RuntimeDefault", params.SecurityContext.Pod.SeccompProfile)
+		assert.True(t, *params.SecurityContext.Container.ReadOnlyRootFilesystem)
+		assert.False(t, *params.SecurityContext.Container.AllowPrivilegeEscalation)
+		assert.Equal(t, []string{"ALL"}, params.SecurityContext.Container.DropCapabilities)
 	})
 
-	t.Run("ReturnsTrueIfImageNameIsSet", func(t *testing.T) {
+	t.Run("KeepsSecurityContextOverridesForRestrictedPreset", func(t *testing.T) {
 
-		params := validParams
-		params.Container.ImageName = "myimage"
+		falseValue := false
+		user := 1000
+		params := Params{
+			SecurityContext: SecurityContextParams{
+				Preset: "restricted",
+				Pod: PodSecurityContextParams{
+					RunAsUser: &user,
+				},
+				Container: ContainerSecurityContextParams{
+					ReadOnlyRootFilesystem: &falseValue,
+					DropCapabilities:       []string{"NET_RAW"},
+				},
+			},
+		}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		params.SetDefaults("", "", "", "", "", map[string]string{})
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.Equal(t, 1000, *params.SecurityContext.Pod.RunAsUser)
+		assert.False(t, *params.SecurityContext.Container.ReadOnlyRootFilesystem)
+		assert.Equal(t, []string{"NET_RAW"}, params.SecurityContext.Container.DropCapabilities)
 	})
 
-	t.Run("ReturnsFalseIfImageTagIsNotSet", func(t *testing.T) {
+	t.Run("DefaultsSidecarSecurityContextToContainerSecurityContext", func(t *testing.T) {
 
-		params := validParams
-		params.Container.ImageTag = ""
+		trueValue := true
+		falseValue := false
+		params := Params{
+			SecurityContext: SecurityContextParams{
+				Preset: "restricted",
+			},
+			Sidecars: []*SidecarParams{
+				{
+					Type: "cloudsqlproxy",
+					SecurityContext: ContainerSecurityContextParams{
+						RunAsNonRoot:           &falseValue,
+						ReadOnlyRootFilesystem: &falseValue,
+					},
+				},
+			},
+		}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		params.SetDefaults("", "", "", "", "", map[string]string{})
 
-		assert.False(t, valid)
-		assert.True(t, len(errors) > 0)
+		assert.Equal(t, 2, len(params.Sidecars))
+		assert.False(t, *params.Sidecars[0].SecurityContext.RunAsNonRoot)
+		assert.False(t, *params.Sidecars[0].SecurityContext.ReadOnlyRootFilesystem)
+		assert.False(t, *params.Sidecars[0].SecurityContext.AllowPrivilegeEscalation)
+		assert.Equal(t, "openresty", params.Sidecars[1].Type)
+		assert.Equal(t, &trueValue, params.Sidecars[1].SecurityContext.ReadOnlyRootFilesystem)
+		assert.Equal(t, []string{"ALL"}, params.Sidecars[1].SecurityContext.DropCapabilities)
 	})
 
-	t.Run("ReturnsTrueIfImageTagIsSet", func(t *testing.T) {
+	t.Run("DefaultsInitContainerResourcesToContainerResources", func(t *testing.T) {
 
-		params := validParams
-		params.Container.ImageTag = "1.0.0"
+		params := Params{
+			Container: ContainerParams{
+				CPU: CPUParams{Request: "200m", Limit: "400m"},
+			},
+			InitContainers: []InitContainerParams{
+				{Name: "migrate", Memory: MemoryParams{Request: "64Mi", Limit: "64Mi"}},
+			},
+		}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		params.SetDefaults("", "", "", "", "", map[string]string{})
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.Equal(t, "200m", params.InitContainers[0].CPU.Request)
+		assert.Equal(t, "400m", params.InitContainers[0].CPU.Limit)
+		assert.Equal(t, "64Mi", params.InitContainers[0].Memory.Request)
+		assert.Equal(t, "64Mi", params.InitContainers[0].Memory.Limit)
 	})
 
-	t.Run("ReturnsFalseIfVisibilityIsNotSet", func(t *testing.T) {
+	t.Run("DefaultsAutoscaleMetricTargetTypeByMetricType", func(t *testing.T) {
 
-		params := validParams
-		params.Visibility = ""
+		params := Params{
+			Autoscale: AutoscaleParams{
+				Metrics: []AutoscaleMetricParams{
+					{Type: "pods", Name: "http_requests_per_second", Target: "100"},
+					{Type: "object", Name: "requests_per_second", Target: "2k", Object: AutoscaleMetricObjectParams{Kind: "Service", Name: "myapp"}},
+					{Type: "external", Name: "pubsub.googleapis.com|subscription|num_undelivered_messages", Target: "50"},
+				},
+			},
+		}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		params.SetDefaults("", "", "", "", "", map[string]string{})
 
-		assert.False(t, valid)
-		assert.True(t, len(errors) > 0)
+		assert.Equal(t, "AverageValue", params.Autoscale.Metrics[0].TargetType)
+		assert.Equal(t, "Value", params.Autoscale.Metrics[1].TargetType)
+		assert.Equal(t, "v1", params.Autoscale.Metrics[1].Object.APIVersion)
+		assert.Equal(t, "AverageValue", params.Autoscale.Metrics[2].TargetType)
 	})
 
-	t.Run("ReturnsFalseIfVisibilityIsSetToUnsupportedValue", func(t *testing.T) {
+	t.Run("DefaultsPodDisruptionBudgetToMaxUnavailableOne", func(t *testing.T) {
 
-		params := validParams
-		params.Visibility = "everywhere"
+		params := Params{}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		params.SetDefaults("", "", "", "", "", map[string]string{})
 
-		assert.False(t, valid)
-		assert.True(t, len(errors) > 0)
+		assert.True(t, *params.PodDisruptionBudget.Enabled)
+		assert.Equal(t, "1", params.PodDisruptionBudget.MaxUnavailable)
+		assert.Equal(t, "", params.PodDisruptionBudget.MinAvailable)
 	})
 
-	t.Run("ReturnsTrueIfVisibilityIsSetToPublic", func(t *testing.T) {
+	t.Run("KeepsPodDisruptionBudgetMinAvailableWithoutDefaultingMaxUnavailable", func(t *testing.T) {
 
-		params := validParams
-		params.Visibility = "public"
+		params := Params{
+			PodDisruptionBudget: PodDisruptionBudgetParams{
+				MinAvailable: "50%",
+			},
+		}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		params.SetDefaults("", "", "", "", "", map[string]string{})
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.Equal(t, "50%", params.PodDisruptionBudget.MinAvailable)
+		assert.Equal(t, "", params.PodDisruptionBudget.MaxUnavailable)
 	})
 
-	t.Run("ReturnsTrueIfVisibilityIsSetToPrivate", func(t *testing.T) {
+	t.Run("DefaultsNetworkPolicySources", func(t *testing.T) {
 
-		params := validParams
-		params.Visibility = "private"
+		params := Params{
+			NetworkPolicy: NetworkPolicyParams{
+				Egress: []NetworkPolicyEgressParams{
+					{CIDR: "10.0.0.0/8", Ports: []NetworkPolicyPortParams{{Port: 5432}}},
+				},
+			},
+		}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		params.SetDefaults("", "", "", "", "", map[string]string{})
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.False(t, params.NetworkPolicy.Enabled)
+		assert.Equal(t, []string{"ingress-nginx"}, params.NetworkPolicy.IngressControllerNamespaces)
+		assert.Equal(t, "monitoring", params.NetworkPolicy.PrometheusNamespace)
+		assert.Equal(t, []string{"35.191.0.0/16", "130.211.0.0/22"}, params.NetworkPolicy.GCELoadBalancerRanges)
+		assert.Equal(t, "TCP", params.NetworkPolicy.Egress[0].Ports[0].Protocol)
 	})
 
-	t.Run("ReturnsTrueIfVisibilityIsSetToIAP", func(t *testing.T) {
+	t.Run("DefaultsRoutePortToHttpsIfNoServiceIsSet", func(t *testing.T) {
 
-		params := validParams
-		params.Visibility = "iap"
-		params.IapOauthCredentialsClientID = "123123"
-		params.IapOauthCredentialsClientSecret = "somesecret"
+		params := Params{
+			Routes: []RouteParams{
+				{Path: "/api"},
+				{Path: "/static", Service: "static-bucket"},
+			},
+		}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		params.SetDefaults("", "", "", "", "", map[string]string{})
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.Equal(t, "https", params.Routes[0].Port)
+		assert.Equal(t, "", params.Routes[1].Port)
 	})
 
-	t.Run("ReturnsTrueIfVisibilityIsSetToPublicWhitelist", func(t *testing.T) {
+	t.Run("DefaultsProbeTypesToGrpcIfProtocolIsGrpc", func(t *testing.T) {
 
-		params := validParams
-		params.Visibility = "public-whitelist"
+		params := Params{
+			Protocol: "grpc",
+		}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		params.SetDefaults("", "", "", "", "", map[string]string{})
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.Equal(t, "grpc", params.Container.LivenessProbe.Type)
+		assert.Equal(t, "grpc", params.Container.ReadinessProbe.Type)
+		assert.Equal(t, "", params.Container.LivenessProbe.Path)
 	})
 
-	t.Run("ReturnsFalseIfCpuRequestIsNotSet", func(t *testing.T) {
+	t.Run("DefaultsProtocolToHttp1", func(t *testing.T) {
 
-		params := validParams
-		params.Container.CPU.Request = ""
+		params := Params{}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		params.SetDefaults("", "", "", "", "", map[string]string{})
 
-		assert.False(t, valid)
-		assert.True(t, len(errors) > 0)
+		assert.Equal(t, "http1", params.Protocol)
+		assert.Equal(t, "http", params.Container.LivenessProbe.Type)
 	})
 
-	t.Run("ReturnsTrueIfCpuRequestIsSet", func(t *testing.T) {
+	t.Run("DefaultsCertificateProviderToLetsencrypt", func(t *testing.T) {
 
-		params := validParams
-		params.Container.CPU.Request = "100m"
+		params := Params{}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		params.SetDefaults("", "", "", "", "", map[string]string{})
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.Equal(t, "letsencrypt", params.Certificate.Provider)
+		assert.Equal(t, "ClusterIssuer", params.Certificate.IssuerKind)
 	})
 
-	t.Run("ReturnsFalseIfCpuLimitIsNotSet", func(t *testing.T) {
+	t.Run("DefaultsDNSToCloudflareProxiedForPublicAndUnproxiedForInternalHosts", func(t *testing.T) {
 
-		params := validParams
-		params.Container.CPU.Limit = ""
+		params := Params{}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		params.SetDefaults("", "", "", "", "", map[string]string{})
 
-		assert.False(t, valid)
-		assert.True(t, len(errors) > 0)
+		assert.Equal(t, "cloudflare", params.DNS.Public.Provider)
+		assert.True(t, *params.DNS.Public.Proxy)
+		assert.Equal(t, "cloudflare", params.DNS.Internal.Provider)
+		assert.False(t, *params.DNS.Internal.Proxy)
 	})
 
-	t.Run("ReturnsTrueIfCpuLimitIsSet", func(t *testing.T) {
+	t.Run("DoesNotInjectOpenrestySidecarIfMeshTypeIsIstio", func(t *testing.T) {
 
-		params := validParams
-		params.Container.CPU.Limit = "100m"
+		params := Params{
+			Kind: "deployment",
+			Mesh: MeshParams{Type: "istio"},
+		}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		params.SetDefaults("", "", "", "", "", map[string]string{})
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.False(t, *params.InjectHTTPProxySidecar)
+		assert.Equal(t, 0, len(params.Sidecars))
+		assert.Equal(t, map[string]string{"istio": "ingressgateway"}, params.Mesh.GatewaySelector)
+		assert.Equal(t, "istio-system", params.Mesh.GatewayNamespace)
+		assert.Equal(t, 10, params.Mesh.CanaryWeight)
 	})
 
-	t.Run("ReturnsFalseIfMemoryRequestIsNotSet", func(t *testing.T) {
+	t.Run("DefaultsStatefulSetReplicasPodManagementPolicyAndVolumeClaimAccessModes", func(t *testing.T) {
+
+		params := Params{
+			Kind: "statefulset",
+			StatefulSet: StatefulSetParams{
+				VolumeClaimTemplates: []VolumeClaimTemplateParams{
+					{Name: "data", MountPath: "/data", Size: "10Gi"},
+				},
+			},
+		}
+
+		// act
+		params.SetDefaults("", "", "", "", "", map[string]string{})
+
+		assert.Equal(t, 1, params.StatefulSet.Replicas)
+		assert.Equal(t, "OrderedReady", params.StatefulSet.PodManagementPolicy)
+		assert.Equal(t, []string{"ReadWriteOnce"}, params.StatefulSet.VolumeClaimTemplates[0].AccessModes)
+	})
+
+	t.Run("DoesNotInjectOpenrestySidecarIfKindIsStatefulSet", func(t *testing.T) {
+
+		params := Params{
+			Kind: "statefulset",
+		}
+
+		// act
+		params.SetDefaults("", "", "", "", "", map[string]string{})
+
+		assert.Equal(t, 0, len(params.Sidecars))
+	})
+
+	t.Run("DefaultsDaemonSetUpdateStrategyToRollingUpdateWithMaxUnavailableOne", func(t *testing.T) {
+
+		params := Params{
+			Kind: "daemonset",
+		}
+
+		// act
+		params.SetDefaults("", "", "", "", "", map[string]string{})
+
+		assert.Equal(t, "RollingUpdate", params.DaemonSet.UpdateStrategy)
+		assert.Equal(t, "1", params.DaemonSet.MaxUnavailable)
+		assert.Equal(t, 0, len(params.Sidecars))
+	})
+
+	t.Run("DoesNotDefaultDaemonSetMaxUnavailableIfUpdateStrategyIsOnDelete", func(t *testing.T) {
+
+		params := Params{
+			Kind: "daemonset",
+			DaemonSet: DaemonSetParams{
+				UpdateStrategy: "OnDelete",
+			},
+		}
+
+		// act
+		params.SetDefaults("", "", "", "", "", map[string]string{})
+
+		assert.Equal(t, "OnDelete", params.DaemonSet.UpdateStrategy)
+		assert.Equal(t, "", params.DaemonSet.MaxUnavailable)
+	})
+}
+
+func TestValidateRequiredProperties(t *testing.T) {
+
+	t.Run("ReturnsFalseIfAppIsNotSet", func(t *testing.T) {
 
 		params := validParams
-		params.Container.Memory.Request = ""
+		params.App = ""
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2345,10 +2570,10 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsTrueIfMemoryRequestIsSet", func(t *testing.T) {
+	t.Run("ReturnsTrueIfAppIsSet", func(t *testing.T) {
 
 		params := validParams
-		params.Container.Memory.Request = "100m"
+		params.App = "myapp"
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2357,10 +2582,10 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) == 0)
 	})
 
-	t.Run("ReturnsFalseIfMemoryLimitIsNotSet", func(t *testing.T) {
+	t.Run("ReturnsFalseIfNamespaceIsNotSet", func(t *testing.T) {
 
 		params := validParams
-		params.Container.Memory.Limit = ""
+		params.Namespace = ""
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2369,10 +2594,10 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsTrueIfMemoryLimitIsSet", func(t *testing.T) {
+	t.Run("ReturnsTrueIfNamespaceIsSet", func(t *testing.T) {
 
 		params := validParams
-		params.Container.Memory.Limit = "100m"
+		params.Namespace = "mynamespace"
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2381,10 +2606,10 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) == 0)
 	})
 
-	t.Run("ReturnsFalseIfContainerPortIsZeroOrLess", func(t *testing.T) {
+	t.Run("ReturnsFalseIfImageRepositoryIsNotSet", func(t *testing.T) {
 
 		params := validParams
-		params.Container.Port = 0
+		params.Container.ImageRepository = ""
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2393,10 +2618,10 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsTrueIfContainerPortIsLargerThanZero", func(t *testing.T) {
+	t.Run("ReturnsTrueIfImageRepositoryIsSet", func(t *testing.T) {
 
 		params := validParams
-		params.Container.Port = 5000
+		params.Container.ImageRepository = "myrepository"
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2405,10 +2630,10 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) == 0)
 	})
 
-	t.Run("ReturnsFalseIfHostsAreNotSet", func(t *testing.T) {
+	t.Run("ReturnsFalseIfImageNameIsNotSet", func(t *testing.T) {
 
 		params := validParams
-		params.Hosts = []string{}
+		params.Container.ImageName = ""
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2417,10 +2642,10 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsTrueIfOneOrMoreHostsAreSet", func(t *testing.T) {
+	t.Run("ReturnsTrueIfImageNameIsSet", func(t *testing.T) {
 
 		params := validParams
-		params.Hosts = []string{"gke.estafette.io"}
+		params.Container.ImageName = "myimage"
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2429,34 +2654,34 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) == 0)
 	})
 
-	t.Run("ReturnsTrueIfOneOrMoreUppercaseHostsAreSet", func(t *testing.T) {
+	t.Run("ReturnsFalseIfImageTagIsNotSet", func(t *testing.T) {
 
 		params := validParams
-		params.Hosts = []string{"GKE.ESTAFETTE.IO"}
+		params.Container.ImageTag = ""
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsFalseIfHostHasLabelsLongerThan63Characters", func(t *testing.T) {
+	t.Run("ReturnsTrueIfImageTagIsSet", func(t *testing.T) {
 
 		params := validParams
-		params.Hosts = []string{"abcdefghijklmnopqrstuvwxyzabcdefghijklmnopqrstuvwxyzabcdefghijkl.estafette.io"}
+		params.Container.ImageTag = "1.0.0"
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.False(t, valid)
-		assert.True(t, len(errors) > 0)
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
 	})
 
-	t.Run("ReturnsFalseIfHostIsLongerThan253Characters", func(t *testing.T) {
+	t.Run("ReturnsFalseIfVisibilityIsNotSet", func(t *testing.T) {
 
 		params := validParams
-		params.Hosts = []string{"ab.abcdefghijklmnopqrstuvwxyz.abcdefghijklmnopqrstuvwxyzabcdefghijklmnopqrstuvwxyz.abcdefghijklmnopqrstuvwxyzabcdefghijklmnopqrstuvwxyz.abcdefghijklmnopqrstuvwxyzabcdefghijklmnopqrstuvwxyz.abcdefghijklmnopqrstuvwxyzabcdefghijklmnopqrstuvwxyz.estafette.io"}
+		params.Visibility = ""
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2465,10 +2690,10 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsFalseIfHostHasOtherCharacterThanAlphaNumericOrHyphen", func(t *testing.T) {
+	t.Run("ReturnsFalseIfVisibilityIsSetToUnsupportedValue", func(t *testing.T) {
 
 		params := validParams
-		params.Hosts = []string{"gke_site.estafette.io"}
+		params.Visibility = "everywhere"
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2477,10 +2702,10 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsTrueIfInternalHostsAreNotSet", func(t *testing.T) {
+	t.Run("ReturnsTrueIfVisibilityIsSetToPublic", func(t *testing.T) {
 
 		params := validParams
-		params.InternalHosts = []string{}
+		params.Visibility = "public"
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2489,10 +2714,10 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) == 0)
 	})
 
-	t.Run("ReturnsTrueIfOneOrMoreInternalHostsAreSet", func(t *testing.T) {
+	t.Run("ReturnsTrueIfVisibilityIsSetToPrivate", func(t *testing.T) {
 
 		params := validParams
-		params.InternalHosts = []string{"ci.estafette.internal"}
+		params.Visibility = "private"
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2501,10 +2726,12 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) == 0)
 	})
 
-	t.Run("ReturnsTrueIfOneOrMoreUppercaseInternalHostsAreSet", func(t *testing.T) {
+	t.Run("ReturnsTrueIfVisibilityIsSetToIAP", func(t *testing.T) {
 
 		params := validParams
-		params.InternalHosts = []string{"CI.ESTAFETTE.INTERNAL"}
+		params.Visibility = "iap"
+		params.IapOauthCredentialsClientID = "123123"
+		params.IapOauthCredentialsClientSecret = "somesecret"
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2513,22 +2740,22 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) == 0)
 	})
 
-	t.Run("ReturnsFalseIfInternalHostHasLabelsLongerThan63Characters", func(t *testing.T) {
+	t.Run("ReturnsTrueIfVisibilityIsSetToPublicWhitelist", func(t *testing.T) {
 
 		params := validParams
-		params.InternalHosts = []string{"abcdefghijklmnopqrstuvwxyzabcdefghijklmnopqrstuvwxyzabcdefghijkl.estafette.internal"}
+		params.Visibility = "public-whitelist"
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.False(t, valid)
-		assert.True(t, len(errors) > 0)
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
 	})
 
-	t.Run("ReturnsFalseIfInternalHostIsLongerThan253Characters", func(t *testing.T) {
+	t.Run("ReturnsFalseIfCpuRequestIsNotSet", func(t *testing.T) {
 
 		params := validParams
-		params.InternalHosts = []string{"abcdefghijklmnopqrstuvw.abcdefghijklmnopqrstuvwxyzabcdefghijklmnopqrstuvwxyz.abcdefghijklmnopqrstuvwxyzabcdefghijklmnopqrstuvwxyz.abcdefghijklmnopqrstuvwxyzabcdefghijklmnopqrstuvwxyz.abcdefghijklmnopqrstuvwxyzabcdefghijklmnopqrstuvwxyz.estafette.internal"}
+		params.Container.CPU.Request = ""
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2537,22 +2764,22 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsFalseIfInternalHostHasOtherCharacterThanAlphaNumericOrHyphen", func(t *testing.T) {
+	t.Run("ReturnsTrueIfCpuRequestIsSet", func(t *testing.T) {
 
 		params := validParams
-		params.InternalHosts = []string{"gke_site.estafette.internal"}
+		params.Container.CPU.Request = "100m"
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.False(t, valid)
-		assert.True(t, len(errors) > 0)
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
 	})
 
-	t.Run("ReturnsFalseIfAutoscaleMinReplicasIsZeroOrLess", func(t *testing.T) {
+	t.Run("ReturnsFalseIfCpuLimitIsNotSet", func(t *testing.T) {
 
 		params := validParams
-		params.Autoscale.MinReplicas = 0
+		params.Container.CPU.Limit = ""
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2561,10 +2788,10 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsTrueIfAutoscaleMinReplicasIsLargerThanZero", func(t *testing.T) {
+	t.Run("ReturnsTrueIfCpuLimitIsSet", func(t *testing.T) {
 
 		params := validParams
-		params.Autoscale.MinReplicas = 5
+		params.Container.CPU.Limit = "100m"
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2573,10 +2800,10 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) == 0)
 	})
 
-	t.Run("ReturnsFalseIfAutoscaleMaxReplicasIsZeroOrLess", func(t *testing.T) {
+	t.Run("ReturnsFalseIfMemoryRequestIsNotSet", func(t *testing.T) {
 
 		params := validParams
-		params.Autoscale.MaxReplicas = 0
+		params.Container.Memory.Request = ""
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2585,10 +2812,10 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsTrueIfAutoscaleMaxReplicasIsLargerThanZero", func(t *testing.T) {
+	t.Run("ReturnsTrueIfMemoryRequestIsSet", func(t *testing.T) {
 
 		params := validParams
-		params.Autoscale.MaxReplicas = 15
+		params.Container.Memory.Request = "100m"
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2597,10 +2824,10 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) == 0)
 	})
 
-	t.Run("ReturnsFalseIfAutoscaleCPUPercentageIsZeroOrLess", func(t *testing.T) {
+	t.Run("ReturnsFalseIfMemoryLimitIsNotSet", func(t *testing.T) {
 
 		params := validParams
-		params.Autoscale.CPUPercentage = 0
+		params.Container.Memory.Limit = ""
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2609,10 +2836,10 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsTrueIfAutoscaleCPUPercentageIsLargerThanZero", func(t *testing.T) {
+	t.Run("ReturnsTrueIfMemoryLimitIsSet", func(t *testing.T) {
 
 		params := validParams
-		params.Autoscale.CPUPercentage = 35
+		params.Container.Memory.Limit = "1024Mi"
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2621,10 +2848,10 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) == 0)
 	})
 
-	t.Run("ReturnsFalseIfLivenessPathIsEmpty", func(t *testing.T) {
+	t.Run("ReturnsFalseIfContainerPortIsZeroOrLess", func(t *testing.T) {
 
 		params := validParams
-		params.Container.LivenessProbe.Path = ""
+		params.Container.Port = 0
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2633,10 +2860,10 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsTrueIfLivenessPathIsNotEmpty", func(t *testing.T) {
+	t.Run("ReturnsTrueIfContainerPortIsLargerThanZero", func(t *testing.T) {
 
 		params := validParams
-		params.Container.LivenessProbe.Path = "/liveness"
+		params.Container.Port = 5000
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2645,10 +2872,10 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) == 0)
 	})
 
-	t.Run("ReturnsFalseIfLivenessProbePortIsZeroOrLess", func(t *testing.T) {
+	t.Run("ReturnsFalseIfHostsAreNotSet", func(t *testing.T) {
 
 		params := validParams
-		params.Container.LivenessProbe.Port = 0
+		params.Hosts = []string{}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2657,10 +2884,10 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsTrueIfLivenessProbePortIsLargerThanZero", func(t *testing.T) {
+	t.Run("ReturnsTrueIfOneOrMoreHostsAreSet", func(t *testing.T) {
 
 		params := validParams
-		params.Container.LivenessProbe.Port = 5000
+		params.Hosts = []string{"gke.estafette.io"}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2669,22 +2896,10 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) == 0)
 	})
 
-	t.Run("ReturnsFalseIfLivenessInitialDelaySecondsIsZeroOrLess", func(t *testing.T) {
-
-		params := validParams
-		params.Container.LivenessProbe.InitialDelaySeconds = 0
-
-		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
-
-		assert.False(t, valid)
-		assert.True(t, len(errors) > 0)
-	})
-
-	t.Run("ReturnsTrueIfLivenessInitialDelaySecondsIsLargerThanZero", func(t *testing.T) {
+	t.Run("ReturnsTrueIfOneOrMoreUppercaseHostsAreSet", func(t *testing.T) {
 
 		params := validParams
-		params.Container.LivenessProbe.InitialDelaySeconds = 30
+		params.Hosts = []string{"GKE.ESTAFETTE.IO"}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2693,10 +2908,10 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) == 0)
 	})
 
-	t.Run("ReturnsFalseIfLivenessTimeoutSecondsIsZeroOrLess", func(t *testing.T) {
+	t.Run("ReturnsFalseIfHostHasLabelsLongerThan63Characters", func(t *testing.T) {
 
 		params := validParams
-		params.Container.LivenessProbe.TimeoutSeconds = 0
+		params.Hosts = []string{"abcdefghijklmnopqrstuvwxyzabcdefghijklmnopqrstuvwxyzabcdefghijkl.estafette.io"}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2705,22 +2920,22 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsTrueIfLivenessTimeoutSecondsIsLargerThanZero", func(t *testing.T) {
+	t.Run("ReturnsFalseIfHostIsLongerThan253Characters", func(t *testing.T) {
 
 		params := validParams
-		params.Container.LivenessProbe.TimeoutSeconds = 2
+		params.Hosts = []string{"ab.abcdefghijklmnopqrstuvwxyz.abcdefghijklmnopqrstuvwxyzabcdefghijklmnopqrstuvwxyz.abcdefghijklmnopqrstuvwxyzabcdefghijklmnopqrstuvwxyz.abcdefghijklmnopqrstuvwxyzabcdefghijklmnopqrstuvwxyz.abcdefghijklmnopqrstuvwxyzabcdefghijklmnopqrstuvwxyz.estafette.io"}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsFalseIfReadinessProbePathIsEmpty", func(t *testing.T) {
+	t.Run("ReturnsFalseIfHostHasOtherCharacterThanAlphaNumericOrHyphen", func(t *testing.T) {
 
 		params := validParams
-		params.Container.ReadinessProbe.Path = ""
+		params.Hosts = []string{"gke_site.estafette.io"}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2729,10 +2944,10 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsTrueIfReadinessProbePathIsNotEmpty", func(t *testing.T) {
+	t.Run("ReturnsTrueIfInternalHostsAreNotSet", func(t *testing.T) {
 
 		params := validParams
-		params.Container.ReadinessProbe.Path = "/readiness"
+		params.InternalHosts = []string{}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2741,22 +2956,22 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) == 0)
 	})
 
-	t.Run("ReturnsFalseIfReadinessProbePortIsZeroOrLess", func(t *testing.T) {
+	t.Run("ReturnsTrueIfOneOrMoreInternalHostsAreSet", func(t *testing.T) {
 
 		params := validParams
-		params.Container.ReadinessProbe.Port = 0
+		params.InternalHosts = []string{"ci.estafette.internal"}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.False(t, valid)
-		assert.True(t, len(errors) > 0)
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
 	})
 
-	t.Run("ReturnsTrueIfReadinessProbePortIsLargerThanZero", func(t *testing.T) {
+	t.Run("ReturnsTrueIfOneOrMoreUppercaseInternalHostsAreSet", func(t *testing.T) {
 
 		params := validParams
-		params.Container.ReadinessProbe.Port = 5000
+		params.InternalHosts = []string{"CI.ESTAFETTE.INTERNAL"}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2765,10 +2980,10 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) == 0)
 	})
 
-	t.Run("ReturnsFalseIfReadinessProbeTimeoutSecondsIsZeroOrLess", func(t *testing.T) {
+	t.Run("ReturnsFalseIfInternalHostHasLabelsLongerThan63Characters", func(t *testing.T) {
 
 		params := validParams
-		params.Container.ReadinessProbe.TimeoutSeconds = 0
+		params.InternalHosts = []string{"abcdefghijklmnopqrstuvwxyzabcdefghijklmnopqrstuvwxyzabcdefghijkl.estafette.internal"}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2777,22 +2992,22 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsTrueIfReadinessProbeTimeoutSecondsIsLargerThanZero", func(t *testing.T) {
+	t.Run("ReturnsFalseIfInternalHostIsLongerThan253Characters", func(t *testing.T) {
 
 		params := validParams
-		params.Container.ReadinessProbe.TimeoutSeconds = 2
+		params.InternalHosts = []string{"abcdefghijklmnopqrstuvw.abcdefghijklmnopqrstuvwxyzabcdefghijklmnopqrstuvwxyz.abcdefghijklmnopqrstuvwxyzabcdefghijklmnopqrstuvwxyz.abcdefghijklmnopqrstuvwxyzabcdefghijklmnopqrstuvwxyz.abcdefghijklmnopqrstuvwxyzabcdefghijklmnopqrstuvwxyz.estafette.internal"}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsFalseIfMetricsPathIsEmpty", func(t *testing.T) {
+	t.Run("ReturnsFalseIfInternalHostHasOtherCharacterThanAlphaNumericOrHyphen", func(t *testing.T) {
 
 		params := validParams
-		params.Container.Metrics.Path = ""
+		params.InternalHosts = []string{"gke_site.estafette.internal"}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2801,23 +3016,22 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsTrueIfMetricsPathIsNotEmpty", func(t *testing.T) {
+	t.Run("ReturnsFalseIfAutoscaleMinReplicasIsZeroOrLess", func(t *testing.T) {
 
 		params := validParams
-		params.Container.Metrics.Path = "/metrics"
+		params.Autoscale.MinReplicas = 0
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsTrueIfMetricsPathIsEmptyButScrapeIsFalse", func(t *testing.T) {
+	t.Run("ReturnsTrueIfAutoscaleMinReplicasIsLargerThanZero", func(t *testing.T) {
 
 		params := validParams
-		params.Container.Metrics.Scrape = &falseValue
-		params.Container.Metrics.Path = ""
+		params.Autoscale.MinReplicas = 5
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2826,10 +3040,10 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) == 0)
 	})
 
-	t.Run("ReturnsFalseIfMetricsPortIsZeroOrLess", func(t *testing.T) {
+	t.Run("ReturnsFalseIfAutoscaleMaxReplicasIsZeroOrLess", func(t *testing.T) {
 
 		params := validParams
-		params.Container.Metrics.Port = 0
+		params.Autoscale.MaxReplicas = 0
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2838,10 +3052,10 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsTrueIfMetricsPortIsLargerThanZero", func(t *testing.T) {
+	t.Run("ReturnsTrueIfAutoscaleMaxReplicasIsLargerThanZero", func(t *testing.T) {
 
 		params := validParams
-		params.Container.Metrics.Port = 5000
+		params.Autoscale.MaxReplicas = 15
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2850,11 +3064,22 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) == 0)
 	})
 
-	t.Run("ReturnsTrueIfMetricsPortIsZeroOrLessButScrapeIsFalse", func(t *testing.T) {
+	t.Run("ReturnsFalseIfAutoscaleCPUPercentageIsZeroOrLess", func(t *testing.T) {
 
 		params := validParams
-		params.Container.Metrics.Scrape = &falseValue
-		params.Container.Metrics.Port = 0
+		params.Autoscale.CPUPercentage = 0
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfAutoscaleCPUPercentageIsLargerThanZero", func(t *testing.T) {
+
+		params := validParams
+		params.Autoscale.CPUPercentage = 35
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2863,10 +3088,10 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) == 0)
 	})
 
-	t.Run("ReturnsFalseIfMetricsScrapeIsEmpty", func(t *testing.T) {
+	t.Run("ReturnsFalseIfLivenessPathIsEmpty", func(t *testing.T) {
 
 		params := validParams
-		params.Container.Metrics.Scrape = nil
+		params.Container.LivenessProbe.Path = ""
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2875,10 +3100,10 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsTrueIfMetricsScrapeIsTrue", func(t *testing.T) {
+	t.Run("ReturnsTrueIfLivenessPathIsNotEmpty", func(t *testing.T) {
 
 		params := validParams
-		params.Container.Metrics.Scrape = &trueValue
+		params.Container.LivenessProbe.Path = "/liveness"
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2887,10 +3112,22 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) == 0)
 	})
 
-	t.Run("ReturnsTrueIfMetricsScrapeIsFalse", func(t *testing.T) {
+	t.Run("ReturnsFalseIfLivenessProbePortIsZeroOrLess", func(t *testing.T) {
 
 		params := validParams
-		params.Container.Metrics.Scrape = &falseValue
+		params.Container.LivenessProbe.Port = 0
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfLivenessProbePortIsLargerThanZero", func(t *testing.T) {
+
+		params := validParams
+		params.Container.LivenessProbe.Port = 5000
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2899,10 +3136,10 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) == 0)
 	})
 
-	t.Run("ReturnsFalseIfSidecarTypeIsIncorrect", func(t *testing.T) {
+	t.Run("ReturnsFalseIfLivenessInitialDelaySecondsIsZeroOrLess", func(t *testing.T) {
 
 		params := validParams
-		params.Sidecar.Type = "unknownsidecar"
+		params.Container.LivenessProbe.InitialDelaySeconds = 0
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2911,10 +3148,10 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsTrueIfSidecarTypeIsSet", func(t *testing.T) {
+	t.Run("ReturnsTrueIfLivenessInitialDelaySecondsIsLargerThanZero", func(t *testing.T) {
 
 		params := validParams
-		params.Sidecar.Type = "openresty"
+		params.Container.LivenessProbe.InitialDelaySeconds = 30
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2923,10 +3160,10 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) == 0)
 	})
 
-	t.Run("ReturnsFalseIfSidecarImageIsNotSet", func(t *testing.T) {
+	t.Run("ReturnsFalseIfLivenessTimeoutSecondsIsZeroOrLess", func(t *testing.T) {
 
 		params := validParams
-		params.Sidecar.Image = ""
+		params.Container.LivenessProbe.TimeoutSeconds = 0
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2935,10 +3172,10 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsTrueIfSidecarImageIsSet", func(t *testing.T) {
+	t.Run("ReturnsTrueIfLivenessTimeoutSecondsIsLargerThanZero", func(t *testing.T) {
 
 		params := validParams
-		params.Sidecar.Image = "estafette/openresty-sidecar:1.13.6.2-alpine"
+		params.Container.LivenessProbe.TimeoutSeconds = 2
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2947,10 +3184,10 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) == 0)
 	})
 
-	t.Run("ReturnsFalseIfSidecarCpuRequestIsNotSet", func(t *testing.T) {
+	t.Run("ReturnsFalseIfReadinessProbePathIsEmpty", func(t *testing.T) {
 
 		params := validParams
-		params.Sidecar.CPU.Request = ""
+		params.Container.ReadinessProbe.Path = ""
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2959,10 +3196,10 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsTrueIfSidecarCpuRequestIsSet", func(t *testing.T) {
+	t.Run("ReturnsTrueIfReadinessProbePathIsNotEmpty", func(t *testing.T) {
 
 		params := validParams
-		params.Sidecar.CPU.Request = "100m"
+		params.Container.ReadinessProbe.Path = "/readiness"
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2971,10 +3208,10 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) == 0)
 	})
 
-	t.Run("ReturnsFalseIfSidecarCpuLimitIsNotSet", func(t *testing.T) {
+	t.Run("ReturnsFalseIfReadinessProbePortIsZeroOrLess", func(t *testing.T) {
 
 		params := validParams
-		params.Sidecar.CPU.Limit = ""
+		params.Container.ReadinessProbe.Port = 0
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2983,10 +3220,10 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsTrueIfSidecarCpuLimitIsSet", func(t *testing.T) {
+	t.Run("ReturnsTrueIfReadinessProbePortIsLargerThanZero", func(t *testing.T) {
 
 		params := validParams
-		params.Sidecar.CPU.Limit = "100m"
+		params.Container.ReadinessProbe.Port = 5000
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2995,10 +3232,10 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) == 0)
 	})
 
-	t.Run("ReturnsFalseIfSidecarMemoryRequestIsNotSet", func(t *testing.T) {
+	t.Run("ReturnsFalseIfReadinessProbeTimeoutSecondsIsZeroOrLess", func(t *testing.T) {
 
 		params := validParams
-		params.Sidecar.Memory.Request = ""
+		params.Container.ReadinessProbe.TimeoutSeconds = 0
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -3007,10 +3244,10 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsTrueIfSidecarMemoryRequestIsSet", func(t *testing.T) {
+	t.Run("ReturnsTrueIfReadinessProbeTimeoutSecondsIsLargerThanZero", func(t *testing.T) {
 
 		params := validParams
-		params.Sidecar.Memory.Request = "100m"
+		params.Container.ReadinessProbe.TimeoutSeconds = 2
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -3019,22 +3256,23 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) == 0)
 	})
 
-	t.Run("ReturnsFalseIfSidecarMemoryLimitIsNotSet", func(t *testing.T) {
+	t.Run("ReturnsFalseIfLivenessProbeTypeIsUnknown", func(t *testing.T) {
 
 		params := validParams
-		params.Sidecar.Memory.Limit = ""
+		params.Container.LivenessProbe.Type = "udp"
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
 		assert.False(t, valid)
-		assert.True(t, len(errors) > 0)
+		assert.Equal(t, "container.liveness.type", errors[0].Path)
 	})
 
-	t.Run("ReturnsTrueIfSidecarMemoryLimitIsSet", func(t *testing.T) {
+	t.Run("ReturnsTrueIfReadinessProbeIsTcpWithoutPath", func(t *testing.T) {
 
 		params := validParams
-		params.Sidecar.Memory.Limit = "100m"
+		params.Container.ReadinessProbe.Type = "tcp"
+		params.Container.ReadinessProbe.Path = ""
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -3043,239 +3281,2071 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) == 0)
 	})
 
-	t.Run("ReturnsFalseIfSqlProxyInstanceNameNotSet", func(t *testing.T) {
+	t.Run("ReturnsFalseIfExecProbeHasNoCommand", func(t *testing.T) {
 
 		params := validParams
-		params.Sidecar.Type = "cloudsqlproxy"
-		params.Sidecar.DbInstanceConnectionName = ""
-		params.Sidecar.SQLProxyPort = 8080
+		params.Container.LivenessProbe.Type = "exec"
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
 		assert.False(t, valid)
-		assert.True(t, len(errors) == 1)
+		assert.Equal(t, "container.liveness.command", errors[0].Path)
 	})
 
-	t.Run("ReturnsFalseIfSqlProxyPortNotSet", func(t *testing.T) {
+	t.Run("ReturnsFalseIfProbeSchemeIsInvalid", func(t *testing.T) {
 
 		params := validParams
-		params.Sidecar.Type = "cloudsqlproxy"
-		params.Sidecar.DbInstanceConnectionName = "instance"
-		params.Sidecar.SQLProxyPort = 0
+		params.Container.ReadinessProbe.Scheme = "FTP"
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
 		assert.False(t, valid)
-		assert.True(t, len(errors) == 1)
+		assert.Equal(t, "container.readiness.scheme", errors[0].Path)
 	})
 
-	t.Run("ReturnsTrueIfSqlProxyProperlyConfigured", func(t *testing.T) {
+	t.Run("ReturnsFalseIfProbeTimeoutIsLongerThanPeriod", func(t *testing.T) {
 
 		params := validParams
-		params.Sidecar.Type = "cloudsqlproxy"
-		params.Sidecar.DbInstanceConnectionName = "instance"
-		params.Sidecar.SQLProxyPort = 8080
+		params.Container.ReadinessProbe.PeriodSeconds = 5
+		params.Container.ReadinessProbe.TimeoutSeconds = 10
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.False(t, valid)
+		assert.Equal(t, "container.readiness.timeout", errors[0].Path)
 	})
 
-	t.Run("ReturnsWarningIfSidecarFieldUsed", func(t *testing.T) {
+	t.Run("ReturnsFalseIfLivenessSuccessThresholdIsLargerThanOne", func(t *testing.T) {
 
 		params := validParams
-		params.Sidecar = SidecarParams{
-			Type:  "openresty",
-			Image: "estafette/openresty-sidecar:1.13.6.2-alpine",
-			CPU: CPUParams{
-				Request: "10m",
-				Limit:   "50m",
-			},
-			Memory: MemoryParams{
-				Request: "10Mi",
-				Limit:   "50Mi",
-			},
-		}
+		params.Container.LivenessProbe.SuccessThreshold = 2
 
 		// act
-		valid, errors, warnings := params.ValidateRequiredProperties()
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, "container.liveness.successthreshold", errors[0].Path)
+	})
+
+	t.Run("ReturnsTrueIfReadinessSuccessThresholdIsLargerThanOne", func(t *testing.T) {
+
+		params := validParams
+		params.Container.ReadinessProbe.SuccessThreshold = 2
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
 
 		assert.True(t, valid)
 		assert.True(t, len(errors) == 0)
-		assert.Equal(t, 1, len(warnings))
 	})
 
-	t.Run("NoWarningRetuendIfSidecarsCollectionUsed", func(t *testing.T) {
+	t.Run("ReturnsFalseIfStartupProbePortIsNotExposedByContainer", func(t *testing.T) {
 
 		params := validParams
-		params.Sidecar = SidecarParams{
-			Type: "",
+		params.Container.StartupProbe = &ProbeParams{
+			Type:           "grpc",
+			Port:           5001,
+			TimeoutSeconds: 1,
 		}
 
-		params.Sidecars = []*SidecarParams{
-			&SidecarParams{
-				Type:  "openresty",
-				Image: "estafette/openresty-sidecar:1.13.6.2-alpine",
-				CPU: CPUParams{
-					Request: "10m",
-					Limit:   "50m",
-				},
-				Memory: MemoryParams{
-					Request: "10Mi",
-					Limit:   "50Mi",
-				},
-			},
-		}
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, "container.startup.port", errors[0].Path)
+	})
+
+	t.Run("ReturnsFalseIfSchedulingAntiAffinityTypeIsInvalid", func(t *testing.T) {
+
+		params := validParams
+		params.Scheduling.AntiAffinity.Type = "always"
 
 		// act
-		valid, errors, warnings := params.ValidateRequiredProperties()
+		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
-		assert.Equal(t, 0, len(warnings))
+		assert.False(t, valid)
+		assert.Equal(t, "scheduling.antiaffinity.type", errors[0].Path)
 	})
 
-	t.Run("ReturnsFalseIfBasepathIsNotSet", func(t *testing.T) {
+	t.Run("ReturnsFalseIfSchedulingPreferredAntiAffinityWeightIsOutOfRange", func(t *testing.T) {
 
 		params := validParams
-		params.Basepath = ""
+		params.Scheduling.AntiAffinity = AntiAffinityParams{Type: "preferred", TopologyKey: "kubernetes.io/hostname", Weight: 101}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
 		assert.False(t, valid)
-		assert.True(t, len(errors) > 0)
+		assert.Equal(t, "scheduling.antiaffinity.weight", errors[0].Path)
 	})
 
-	t.Run("ReturnsTrueIfBasepathIsSet", func(t *testing.T) {
+	t.Run("ReturnsFalseIfSchedulingTolerationWithOperatorEqualHasNoKey", func(t *testing.T) {
 
 		params := validParams
-		params.Basepath = "/"
+		params.Scheduling.Tolerations = []TolerationParams{{Operator: "Equal", Value: "myapp"}}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.False(t, valid)
+		assert.Equal(t, "scheduling.tolerations[0].key", errors[0].Path)
 	})
 
-	t.Run("ReturnsFalseIfRollingUpdateMaxSurgeIsNotSet", func(t *testing.T) {
+	t.Run("ReturnsFalseIfSchedulingTolerationSecondsAreSetWithoutEffectNoExecute", func(t *testing.T) {
 
+		tolerationSeconds := 30
 		params := validParams
-		params.RollingUpdate.MaxSurge = ""
+		params.Scheduling.Tolerations = []TolerationParams{{Key: "dedicated", Operator: "Exists", Effect: "NoSchedule", TolerationSeconds: &tolerationSeconds}}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
 		assert.False(t, valid)
-		assert.True(t, len(errors) > 0)
+		assert.Equal(t, "scheduling.tolerations[0].tolerationseconds", errors[0].Path)
 	})
 
-	t.Run("ReturnsTrueIfRollingUpdateMaxSurgeIsSet", func(t *testing.T) {
+	t.Run("ReturnsFalseIfSchedulingTopologySpreadWhenUnsatisfiableIsInvalid", func(t *testing.T) {
 
 		params := validParams
-		params.RollingUpdate.MaxSurge = "25%"
+		params.Scheduling.TopologySpreadConstraints = []TopologySpreadConstraintParams{{TopologyKey: "topology.kubernetes.io/zone", MaxSkew: 1, WhenUnsatisfiable: "Ignore"}}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.False(t, valid)
+		assert.Equal(t, "scheduling.topologyspreadconstraints[0].whenunsatisfiable", errors[0].Path)
 	})
 
-	t.Run("ReturnsFalseIfRollingUpdateMaxUnavailableIsNotSet", func(t *testing.T) {
+	t.Run("ReturnsFalseIfSchedulingIsInvalidAndKindIsJob", func(t *testing.T) {
 
 		params := validParams
-		params.RollingUpdate.MaxUnavailable = ""
+		params.Kind = "job"
+		params.Scheduling.Tolerations = []TolerationParams{{Key: "batch", Operator: "Matches"}}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
 		assert.False(t, valid)
-		assert.True(t, len(errors) > 0)
+		assert.Equal(t, "scheduling.tolerations[0].operator", errors[0].Path)
 	})
 
-	t.Run("ReturnsTrueIfRollingUpdateMaxUnavailableIsSet", func(t *testing.T) {
+	t.Run("ReturnsTrueIfSchedulingIsValid", func(t *testing.T) {
 
 		params := validParams
-		params.RollingUpdate.MaxUnavailable = "25%"
+		params.Scheduling = SchedulingParams{
+			NodeSelector:              map[string]string{"cloud.google.com/gke-nodepool": "dedicated"},
+			Tolerations:               []TolerationParams{{Key: "dedicated", Operator: "Equal", Value: "myapp", Effect: "NoSchedule"}},
+			TopologySpreadConstraints: []TopologySpreadConstraintParams{{TopologyKey: "topology.kubernetes.io/zone", MaxSkew: 1, WhenUnsatisfiable: "DoNotSchedule"}},
+			AntiAffinity:              AntiAffinityParams{Type: "required", TopologyKey: "kubernetes.io/hostname"},
+			PriorityClassName:         "high-priority",
+		}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
 		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.Equal(t, 0, len(errors))
 	})
 
-	t.Run("ReturnsFalseIfScheduleIsNotSetAndKindIsCronjob", func(t *testing.T) {
+	t.Run("ReturnsFalseIfSecurityContextPresetIsInvalid", func(t *testing.T) {
 
 		params := validParams
-		params.Kind = "cronjob"
-		params.Schedule = ""
-		params.ConcurrencyPolicy = "Allow"
+		params.SecurityContext.Preset = "baseline"
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
 		assert.False(t, valid)
-		assert.True(t, len(errors) > 0)
+		assert.Equal(t, "securitycontext.preset", errors[0].Path)
 	})
 
-	t.Run("ReturnsFalseIfConcurrencyPolicyIsInvalidAndKindIsCronjob", func(t *testing.T) {
+	t.Run("ReturnsFalseIfSecurityContextRunsAsRootUserWithRunAsNonRoot", func(t *testing.T) {
 
+		trueValue := true
+		rootUser := 0
 		params := validParams
-		params.Kind = "cronjob"
-		params.Schedule = "*/5 * * * *"
-		params.ConcurrencyPolicy = "InvalidPolicy"
+		params.SecurityContext.Pod = PodSecurityContextParams{RunAsNonRoot: &trueValue, RunAsUser: &rootUser}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
 		assert.False(t, valid)
-		assert.True(t, len(errors) > 0)
+		assert.Equal(t, "securitycontext.pod.runasuser", errors[0].Path)
 	})
 
-	t.Run("ReturnsTrueIfScheduleIsSetAndConcurrencyPolicyIsValidAndKindIsCronjob", func(t *testing.T) {
+	t.Run("ReturnsFalseIfSecurityContextSeccompProfileIsInvalid", func(t *testing.T) {
 
 		params := validParams
-		params.Kind = "cronjob"
-		params.Schedule = "*/5 * * * *"
-		params.ConcurrencyPolicy = "Allow"
+		params.SecurityContext.Pod = PodSecurityContextParams{SeccompProfile: "Localhost"}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.False(t, valid)
+		assert.Equal(t, "securitycontext.pod.seccompprofile", errors[0].Path)
 	})
-}
 
-func TestReplaceOpenrestyTagWithDigest(t *testing.T) {
+	t.Run("ReturnsFalseIfSidecarSecurityContextGroupIsNegative", func(t *testing.T) {
 
-	t.Run("ReplacesOpenrestySidecarImageTagWithDigest", func(t *testing.T) {
+		group := -1
+		params := validParams
+		params.Sidecars = []*SidecarParams{
+			{
+				Type:                     "cloudsqlproxy",
+				Image:                    "gcr.io/cloudsql-docker/gce-proxy:1.14",
+				CPU:                      CPUParams{Request: "50m", Limit: "75m"},
+				Memory:                   MemoryParams{Request: "10Mi", Limit: "50Mi"},
+				DbInstanceConnectionName: "project:region:instance",
+				SQLProxyPort:             5043,
+				SecurityContext:          ContainerSecurityContextParams{RunAsGroup: &group},
+			},
+		}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, "sidecars[0].securitycontext.runasgroup", errors[0].Path)
+	})
+
+	t.Run("ReturnsFalseIfInitContainerNameIsEmpty", func(t *testing.T) {
 
 		params := validParams
+		params.InitContainers = []InitContainerParams{{Command: []string{"./migrate"}}}
 
 		// act
-		params.ReplaceOpenrestyTagWithDigest()
+		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.Equal(t, "openresty", params.Sidecars[0].Type)
-		assert.True(t, strings.HasPrefix(params.Sidecars[0].Image, "estafette/openresty-sidecar@sha256:"))
+		assert.False(t, valid)
+		assert.Equal(t, "initcontainers[0].name", errors[0].Path)
 	})
 
-	t.Run("KeepsOpenrestySidecarImageTagWithDigest", func(t *testing.T) {
+	t.Run("ReturnsFalseIfInitContainerNameIsNotUnique", func(t *testing.T) {
 
 		params := validParams
-		params.Sidecars[0].Image = "estafette/openresty-sidecar@sha256:4300dc7d45600c428f4196009ee842c1c3bdd51aaa4f55361479f6fa60e78faf"
+		params.InitContainers = []InitContainerParams{{Name: "migrate"}, {Name: "migrate"}}
 
 		// act
-		params.ReplaceOpenrestyTagWithDigest()
+		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.Equal(t, "openresty", params.Sidecars[0].Type)
-		assert.True(t, strings.HasPrefix(params.Sidecars[0].Image, "estafette/openresty-sidecar@sha256:"))
+		assert.False(t, valid)
+		assert.Equal(t, "initcontainers[1].name", errors[0].Path)
+	})
+
+	t.Run("ReturnsFalseIfInitContainerNameIsInvalid", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = "job"
+		params.InitContainers = []InitContainerParams{{Name: "Migrate_DB"}}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, "initcontainers[0].name", errors[0].Path)
+	})
+
+	t.Run("ReturnsFalseIfInitContainerMemoryRequestExceedsLimit", func(t *testing.T) {
+
+		params := validParams
+		params.InitContainers = []InitContainerParams{{Name: "migrate", Memory: MemoryParams{Request: "256Mi", Limit: "128Mi"}}}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, "initcontainers[0].memory", errors[0].Path)
+	})
+
+	t.Run("ReturnsTrueIfInitContainersAreValid", func(t *testing.T) {
+
+		params := validParams
+		params.InitContainers = []InitContainerParams{
+			{
+				Name:    "migrate",
+				Command: []string{"./migrate"},
+				Args:    []string{"--up"},
+				EnvironmentVariables: map[string]interface{}{
+					"DB_PASSWORD": map[string]interface{}{"secretKeyRef": map[string]interface{}{"name": "database", "key": "password"}},
+				},
+				CPU:    CPUParams{Request: "100m", Limit: "200m"},
+				Memory: MemoryParams{Request: "64Mi", Limit: "128Mi"},
+			},
+		}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.Equal(t, 0, len(errors))
+	})
+
+	t.Run("ReturnsFalseIfAutoscaleMinReplicasExceedsMaxReplicas", func(t *testing.T) {
+
+		params := validParams
+		params.Autoscale.MinReplicas = 5
+		params.Autoscale.MaxReplicas = 4
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, "autoscale.min", errors[0].Path)
+	})
+
+	t.Run("ReturnsFalseIfAutoscalePodsMetricHasTargetTypeValue", func(t *testing.T) {
+
+		params := validParams
+		params.Autoscale.Metrics = []AutoscaleMetricParams{{Type: "pods", Name: "http_requests_per_second", Target: "100", TargetType: "Value"}}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, "autoscale.metrics[0].targettype", errors[0].Path)
+	})
+
+	t.Run("ReturnsFalseIfAutoscaleObjectMetricHasNoObject", func(t *testing.T) {
+
+		params := validParams
+		params.Autoscale.Metrics = []AutoscaleMetricParams{{Type: "object", Name: "requests_per_second", Target: "2k", TargetType: "Value"}}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, "autoscale.metrics[0].object", errors[0].Path)
+	})
+
+	t.Run("ReturnsFalseIfAutoscaleMetricTargetIsNotAQuantity", func(t *testing.T) {
+
+		params := validParams
+		params.Autoscale.Metrics = []AutoscaleMetricParams{{Type: "external", Name: "pubsub.googleapis.com|subscription|num_undelivered_messages", Target: "lots", TargetType: "AverageValue"}}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, "autoscale.metrics[0].target", errors[0].Path)
+	})
+
+	t.Run("ReturnsFalseIfAutoscaleBehaviorPolicyPeriodIsTooLong", func(t *testing.T) {
+
+		params := validParams
+		params.Autoscale.Behavior.ScaleUp = &AutoscaleScalingRulesParams{Policies: []AutoscalingPolicyParams{{Type: "Percent", Value: 100, PeriodSeconds: 3600}}}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, "autoscale.behavior.scaleup.policies[0].periodseconds", errors[0].Path)
+	})
+
+	t.Run("ReturnsFalseIfAutoscaleSafetyScaleDownRatioIsLargerThanOne", func(t *testing.T) {
+
+		params := validParams
+		params.Autoscale.Safety = AutoscaleSafetyParams{Enabled: true, PromQuery: "sum(rate(nginx_http_requests_total{app='myapp'}[5m])) by (app)", Ratio: 2, ScaleDownRatio: 1.5}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, "autoscale.safety.scaledownratio", errors[0].Path)
+	})
+
+	t.Run("ReturnsWarningIfAutoscaleSafetyIsEnabledWithScaleDownDisabled", func(t *testing.T) {
+
+		params := validParams
+		params.Autoscale.Safety = AutoscaleSafetyParams{Enabled: true, PromQuery: "sum(rate(nginx_http_requests_total{app='myapp'}[5m])) by (app)", Ratio: 2, ScaleDownRatio: 0.2}
+		params.Autoscale.Behavior.ScaleDown = &AutoscaleScalingRulesParams{SelectPolicy: "Disabled"}
+
+		// act
+		valid, _, warnings := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		warningPaths := []string{}
+		for _, w := range warnings {
+			warningPaths = append(warningPaths, w.Path)
+		}
+		assert.Contains(t, warningPaths, "autoscale.behavior.scaledown.selectpolicy")
+	})
+
+	t.Run("ReturnsFalseIfRoutePortIsNotAnAdditionalPort", func(t *testing.T) {
+
+		params := validParams
+		params.Routes = []RouteParams{{Path: "/admin", Port: "admin"}}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, "routes[0].port", errors[0].Path)
+	})
+
+	t.Run("ReturnsFalseIfRoutePortHasOtherVisibility", func(t *testing.T) {
+
+		params := validParams
+		params.Container.AdditionalPorts = []*AdditionalPortParams{{Name: "admin", Port: 8081, Protocol: "TCP", Visibility: "public"}}
+		params.Routes = []RouteParams{{Path: "/admin", Port: "admin"}}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, "routes[0].port", errors[0].Path)
+	})
+
+	t.Run("ReturnsFalseIfRouteHostIsNotAHost", func(t *testing.T) {
+
+		params := validParams
+		params.Routes = []RouteParams{{Hosts: []string{"other.estafette.io"}, Path: "/api", Port: "https"}}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, "routes[0].hosts[0]", errors[0].Path)
+	})
+
+	t.Run("ReturnsFalseIfRoutePathIsRoutedTwiceForSameHost", func(t *testing.T) {
+
+		params := validParams
+		params.Routes = []RouteParams{
+			{Path: "/api", Port: "https"},
+			{Hosts: []string{"gke.estafette.io"}, Path: "/api", Port: "http"},
+		}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, "routes[1].path", errors[0].Path)
+	})
+
+	t.Run("ReturnsFalseIfRouteToOtherServiceHasNoPort", func(t *testing.T) {
+
+		params := validParams
+		params.Routes = []RouteParams{{Path: "/static", Service: "static-bucket"}}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, "routes[0].port", errors[0].Path)
+	})
+
+	t.Run("ReturnsTrueWithBackendProtocolWarningIfRouteTargetsAdditionalPortOnNginxIngress", func(t *testing.T) {
+
+		params := validParams
+		params.Container.AdditionalPorts = []*AdditionalPortParams{{Name: "admin", Port: 8081, Protocol: "TCP", Visibility: "private"}}
+		params.Routes = []RouteParams{
+			{Path: "/api", Port: "https"},
+			{Path: "/admin", Port: "admin"},
+		}
+
+		// act
+		valid, errors, warnings := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.Equal(t, 0, len(errors))
+		warningPaths := []string{}
+		for _, w := range warnings {
+			warningPaths = append(warningPaths, w.Path)
+		}
+		assert.Contains(t, warningPaths, "routes[1].port")
+		assert.NotContains(t, warningPaths, "routes[0].port")
+	})
+
+	t.Run("ReturnsFalseIfIngressAnnotationIsNotAllowed", func(t *testing.T) {
+
+		params := validParams
+		params.Ingress = IngressParams{
+			Annotations:        map[string]string{"nginx.ingress.kubernetes.io/rewrite-target": "/"},
+			AllowedAnnotations: []string{"nginx.ingress.kubernetes.io/proxy-*"},
+		}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, "ingress.annotations.nginx.ingress.kubernetes.io/rewrite-target", errors[0].Path)
+	})
+
+	t.Run("ReturnsTrueIfIngressAnnotationIsAllowedByPrefix", func(t *testing.T) {
+
+		params := validParams
+		params.Ingress = IngressParams{
+			Annotations:        map[string]string{"nginx.ingress.kubernetes.io/proxy-next-upstream": "off"},
+			AllowedAnnotations: []string{"nginx.ingress.kubernetes.io/proxy-*"},
+		}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.Equal(t, 0, len(errors))
+	})
+
+	t.Run("ReturnsFalseIfIngressServerSnippetIsNotAllowed", func(t *testing.T) {
+
+		params := validParams
+		params.Ingress = IngressParams{
+			ServerSnippet: "location /nope { return 404; }",
+		}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, "ingress.serversnippet", errors[0].Path)
+	})
+
+	t.Run("ReturnsFalseIfIngressBasicAuthHasNoSecret", func(t *testing.T) {
+
+		params := validParams
+		params.Ingress = IngressParams{
+			Auth: IngressAuthParams{Type: "basic"},
+		}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, "ingress.auth.secret", errors[0].Path)
+	})
+
+	t.Run("ReturnsFalseIfIngressCORSAllowsAnyOriginWithCredentials", func(t *testing.T) {
+
+		trueValue := true
+		params := validParams
+		params.Ingress = IngressParams{
+			CORS: IngressCORSParams{Enabled: true, AllowOrigin: "*", AllowCredentials: &trueValue},
+		}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, "ingress.cors.alloworigin", errors[0].Path)
+	})
+
+	t.Run("ReturnsWarningIfIngressFeaturesAreSetForIapVisibility", func(t *testing.T) {
+
+		params := validParams
+		params.Visibility = "iap"
+		params.IapOauthCredentialsClientID = "abc"
+		params.IapOauthCredentialsClientSecret = "xyz"
+		params.Ingress = IngressParams{
+			RateLimit: IngressRateLimitParams{RPS: 10},
+		}
+
+		// act
+		valid, _, warnings := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		warningPaths := []string{}
+		for _, w := range warnings {
+			warningPaths = append(warningPaths, w.Path)
+		}
+		assert.Contains(t, warningPaths, "ingress")
+	})
+
+	t.Run("ReturnsFalseIfProtocolIsInvalid", func(t *testing.T) {
+
+		params := validParams
+		params.Protocol = "http3"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, "protocol", errors[0].Path)
+	})
+
+	t.Run("ReturnsFalseIfCertificateProviderIsInvalid", func(t *testing.T) {
+
+		params := validParams
+		params.Certificate.Provider = "digicert"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, "certificate.provider", errors[0].Path)
+	})
+
+	t.Run("ReturnsFalseIfCertificateProviderIsCertManagerWithoutIssuer", func(t *testing.T) {
+
+		params := validParams
+		params.Certificate = CertificateParams{Provider: "cert-manager", IssuerKind: "ClusterIssuer"}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, "certificate.issuer", errors[0].Path)
+	})
+
+	t.Run("ReturnsTrueIfCertificateProviderIsCertManagerWithIssuer", func(t *testing.T) {
+
+		params := validParams
+		params.Certificate = CertificateParams{Provider: "cert-manager", Issuer: "letsencrypt-prod", IssuerKind: "ClusterIssuer"}
+
+		// act
+		valid, _, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+	})
+
+	t.Run("ReturnsFalseIfCertificateProviderIsSecretWithoutSecretName", func(t *testing.T) {
+
+		params := validParams
+		params.Certificate = CertificateParams{Provider: "secret"}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, "certificate.secretname", errors[0].Path)
+	})
+
+	t.Run("ReturnsFalseIfCertificateProviderIsManagedWithoutVisibilityIap", func(t *testing.T) {
+
+		params := validParams
+		params.Certificate = CertificateParams{Provider: "managed"}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, "certificate.provider", errors[0].Path)
+	})
+
+	t.Run("ReturnsWarningIfCertificateIssuerIsSetForOtherProvider", func(t *testing.T) {
+
+		params := validParams
+		params.Certificate = CertificateParams{Provider: "letsencrypt", Issuer: "letsencrypt-prod"}
+
+		// act
+		valid, _, warnings := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		warningPaths := []string{}
+		for _, w := range warnings {
+			warningPaths = append(warningPaths, w.Path)
+		}
+		assert.Contains(t, warningPaths, "certificate.issuer")
+	})
+
+	t.Run("ReturnsFalseIfDNSProviderIsInvalid", func(t *testing.T) {
+
+		params := validParams
+		params.DNS.Internal.Provider = "route53"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, "dns.internal.provider", errors[0].Path)
+	})
+
+	t.Run("ReturnsWarningIfDNSProxyIsSetForProviderOtherThanCloudflare", func(t *testing.T) {
+
+		trueValue := true
+		params := validParams
+		params.DNS.Public = DNSProviderParams{Provider: "external-dns", Proxy: &trueValue}
+
+		// act
+		valid, _, warnings := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		warningPaths := []string{}
+		for _, w := range warnings {
+			warningPaths = append(warningPaths, w.Path)
+		}
+		assert.Contains(t, warningPaths, "dns.public.proxy")
+	})
+
+	t.Run("ReturnsWarningIfVisibilityIsPublicWithoutCloudflareProxy", func(t *testing.T) {
+
+		falseValue := false
+		params := validParams
+		params.Visibility = "public"
+		params.DNS.Public = DNSProviderParams{Provider: "cloudflare", Proxy: &falseValue}
+
+		// act
+		valid, _, warnings := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		warningPaths := []string{}
+		for _, w := range warnings {
+			warningPaths = append(warningPaths, w.Path)
+		}
+		assert.Contains(t, warningPaths, "trustedips")
+	})
+
+	t.Run("ReturnsFalseIfMeshTypeIsInvalid", func(t *testing.T) {
+
+		params := validParams
+		params.Mesh.Type = "linkerd"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, "mesh.type", errors[0].Path)
+	})
+
+	t.Run("ReturnsFalseIfMeshTypeIsIstioWithVisibilityIap", func(t *testing.T) {
+
+		params := validParams
+		params.Visibility = "iap"
+		params.IapOauthCredentialsClientID = "123123"
+		params.IapOauthCredentialsClientSecret = "somesecret"
+		params.Mesh = MeshParams{Type: "istio", CanaryWeight: 10}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, "visibility", errors[0].Path)
+	})
+
+	t.Run("ReturnsFalseIfMeshTypeIsIstioWithCanaryWeightOutsideOneToHundred", func(t *testing.T) {
+
+		for _, canaryWeight := range []int{-10, 101} {
+			params := validParams
+			params.Mesh = MeshParams{Type: "istio", CanaryWeight: canaryWeight}
+
+			// act
+			valid, errors, _ := params.ValidateRequiredProperties()
+
+			assert.False(t, valid)
+			assert.Equal(t, "mesh.canaryweight", errors[0].Path)
+		}
+	})
+
+	t.Run("ReturnsFalseIfMeshTypeIsIstioWithRouteToOtherServiceByPortName", func(t *testing.T) {
+
+		params := validParams
+		params.Mesh = MeshParams{Type: "istio", CanaryWeight: 10}
+		params.Routes = []RouteParams{{Path: "/api", Service: "myapi", Port: "https"}}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, "routes[0].port", errors[0].Path)
+	})
+
+	t.Run("ReturnsWarningIfMeshTypeIsIstioWithOpenrestySidecar", func(t *testing.T) {
+
+		params := validParams
+		params.Mesh = MeshParams{Type: "istio", CanaryWeight: 10}
+
+		// act
+		valid, _, warnings := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		warningPaths := []string{}
+		for _, w := range warnings {
+			warningPaths = append(warningPaths, w.Path)
+		}
+		assert.Contains(t, warningPaths, "sidecar.type")
+	})
+
+	t.Run("ReturnsFalseIfNetworkPolicyAllowedClientHasNeitherAppNorNamespace", func(t *testing.T) {
+
+		params := validParams
+		params.NetworkPolicy = NetworkPolicyParams{
+			Enabled:        true,
+			AllowedClients: []NetworkPolicyPeerParams{{}},
+		}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, "networkpolicy.allowedclients[0]", errors[0].Path)
+	})
+
+	t.Run("ReturnsFalseIfNetworkPolicyEgressCIDRIsInvalid", func(t *testing.T) {
+
+		params := validParams
+		params.NetworkPolicy = NetworkPolicyParams{
+			Enabled: true,
+			Egress:  []NetworkPolicyEgressParams{{CIDR: "10.0.0.0"}},
+		}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, "networkpolicy.egress[0].cidr", errors[0].Path)
+	})
+
+	t.Run("ReturnsFalseIfNetworkPolicyEgressPortIsOutOfRange", func(t *testing.T) {
+
+		params := validParams
+		params.NetworkPolicy = NetworkPolicyParams{
+			Enabled: true,
+			Egress:  []NetworkPolicyEgressParams{{App: "postgres", Ports: []NetworkPolicyPortParams{{Port: 70000, Protocol: "TCP"}}}},
+		}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, "networkpolicy.egress[0].ports[0].port", errors[0].Path)
+	})
+
+	t.Run("ReturnsTrueIfNetworkPolicyIsValid", func(t *testing.T) {
+
+		params := validParams
+		params.NetworkPolicy = NetworkPolicyParams{
+			Enabled:               true,
+			GCELoadBalancerRanges: []string{"35.191.0.0/16"},
+			AllowedClients:        []NetworkPolicyPeerParams{{App: "frontend"}},
+			Egress: []NetworkPolicyEgressParams{
+				{CIDR: "10.0.0.0/8", Ports: []NetworkPolicyPortParams{{Port: 5432, Protocol: "TCP"}}},
+				{Namespace: "kafka"},
+			},
+		}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.Equal(t, 0, len(errors))
+	})
+
+	t.Run("ReturnsFalseIfPodDisruptionBudgetHasBothMinAvailableAndMaxUnavailable", func(t *testing.T) {
+
+		params := validParams
+		params.PodDisruptionBudget = PodDisruptionBudgetParams{MinAvailable: "1", MaxUnavailable: "1"}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, "pdb", errors[0].Path)
+	})
+
+	t.Run("ReturnsFalseIfPodDisruptionBudgetMaxUnavailableIsInvalid", func(t *testing.T) {
+
+		params := validParams
+		params.PodDisruptionBudget = PodDisruptionBudgetParams{MaxUnavailable: "half"}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, "pdb.maxunavailable", errors[0].Path)
+	})
+
+	t.Run("ReturnsWarningIfPodDisruptionBudgetMinAvailableBlocksEvictionAtMinReplicas", func(t *testing.T) {
+
+		params := validParams
+		params.PodDisruptionBudget = PodDisruptionBudgetParams{MinAvailable: "3"}
+
+		// act
+		valid, _, warnings := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		warningPaths := []string{}
+		for _, w := range warnings {
+			warningPaths = append(warningPaths, w.Path)
+		}
+		assert.Contains(t, warningPaths, "pdb.minavailable")
+	})
+
+	t.Run("ReturnsTrueIfPodDisruptionBudgetIsDisabledWithInvalidValues", func(t *testing.T) {
+
+		falseValue := false
+		params := validParams
+		params.PodDisruptionBudget = PodDisruptionBudgetParams{Enabled: &falseValue, MaxUnavailable: "half"}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.Equal(t, 0, len(errors))
+	})
+
+	t.Run("ReturnsFalseIfMetricsPathIsEmpty", func(t *testing.T) {
+
+		params := validParams
+		params.Container.Metrics.Path = ""
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfMetricsPathIsNotEmpty", func(t *testing.T) {
+
+		params := validParams
+		params.Container.Metrics.Path = "/metrics"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsTrueIfMetricsPathIsEmptyButScrapeIsFalse", func(t *testing.T) {
+
+		params := validParams
+		params.Container.Metrics.Scrape = &falseValue
+		params.Container.Metrics.Path = ""
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfMetricsPortIsZeroOrLess", func(t *testing.T) {
+
+		params := validParams
+		params.Container.Metrics.Port = 0
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfMetricsPortIsLargerThanZero", func(t *testing.T) {
+
+		params := validParams
+		params.Container.Metrics.Port = 5000
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsTrueIfMetricsPortIsZeroOrLessButScrapeIsFalse", func(t *testing.T) {
+
+		params := validParams
+		params.Container.Metrics.Scrape = &falseValue
+		params.Container.Metrics.Port = 0
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfMetricsScrapeIsEmpty", func(t *testing.T) {
+
+		params := validParams
+		params.Container.Metrics.Scrape = nil
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfMetricsScrapeIsTrue", func(t *testing.T) {
+
+		params := validParams
+		params.Container.Metrics.Scrape = &trueValue
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsTrueIfMetricsScrapeIsFalse", func(t *testing.T) {
+
+		params := validParams
+		params.Container.Metrics.Scrape = &falseValue
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfSidecarTypeIsIncorrect", func(t *testing.T) {
+
+		params := validParams
+		params.Sidecar.Type = "unknownsidecar"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfSidecarTypeIsSet", func(t *testing.T) {
+
+		params := validParams
+		params.Sidecar.Type = "openresty"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfSidecarImageIsNotSet", func(t *testing.T) {
+
+		params := validParams
+		params.Sidecar.Image = ""
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfSidecarImageIsSet", func(t *testing.T) {
+
+		params := validParams
+		params.Sidecar.Image = "estafette/openresty-sidecar:1.13.6.2-alpine"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfSidecarCpuRequestIsNotSet", func(t *testing.T) {
+
+		params := validParams
+		params.Sidecar.CPU.Request = ""
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfSidecarCpuRequestIsSet", func(t *testing.T) {
+
+		params := validParams
+		params.Sidecar.CPU.Request = "50m"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfSidecarCpuLimitIsNotSet", func(t *testing.T) {
+
+		params := validParams
+		params.Sidecar.CPU.Limit = ""
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfSidecarCpuLimitIsSet", func(t *testing.T) {
+
+		params := validParams
+		params.Sidecar.CPU.Limit = "100m"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfSidecarMemoryRequestIsNotSet", func(t *testing.T) {
+
+		params := validParams
+		params.Sidecar.Memory.Request = ""
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfSidecarMemoryRequestIsSet", func(t *testing.T) {
+
+		params := validParams
+		params.Sidecar.Memory.Request = "100m"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfSidecarMemoryLimitIsNotSet", func(t *testing.T) {
+
+		params := validParams
+		params.Sidecar.Memory.Limit = ""
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfSidecarMemoryLimitIsSet", func(t *testing.T) {
+
+		params := validParams
+		params.Sidecar.Memory.Limit = "100Mi"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfSqlProxyInstanceNameNotSet", func(t *testing.T) {
+
+		params := validParams
+		params.Sidecar.Type = "cloudsqlproxy"
+		params.Sidecar.DbInstanceConnectionName = ""
+		params.Sidecar.SQLProxyPort = 8080
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) == 1)
+	})
+
+	t.Run("ReturnsFalseIfSqlProxyPortNotSet", func(t *testing.T) {
+
+		params := validParams
+		params.Sidecar.Type = "cloudsqlproxy"
+		params.Sidecar.DbInstanceConnectionName = "instance"
+		params.Sidecar.SQLProxyPort = 0
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) == 1)
+	})
+
+	t.Run("ReturnsTrueIfSqlProxyProperlyConfigured", func(t *testing.T) {
+
+		params := validParams
+		params.Sidecar.Type = "cloudsqlproxy"
+		params.Sidecar.DbInstanceConnectionName = "instance"
+		params.Sidecar.SQLProxyPort = 8080
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsWarningIfSidecarFieldUsed", func(t *testing.T) {
+
+		params := validParams
+		params.Sidecar = SidecarParams{
+			Type:  "openresty",
+			Image: "estafette/openresty-sidecar:1.13.6.2-alpine",
+			CPU: CPUParams{
+				Request: "10m",
+				Limit:   "50m",
+			},
+			Memory: MemoryParams{
+				Request: "10Mi",
+				Limit:   "50Mi",
+			},
+		}
+
+		// act
+		valid, errors, warnings := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+		assert.Equal(t, 1, len(warnings))
+	})
+
+	t.Run("NoWarningRetuendIfSidecarsCollectionUsed", func(t *testing.T) {
+
+		params := validParams
+		params.Sidecar = SidecarParams{
+			Type: "",
+		}
+
+		params.Sidecars = []*SidecarParams{
+			&SidecarParams{
+				Type:  "openresty",
+				Image: "estafette/openresty-sidecar:1.13.6.2-alpine",
+				CPU: CPUParams{
+					Request: "10m",
+					Limit:   "50m",
+				},
+				Memory: MemoryParams{
+					Request: "10Mi",
+					Limit:   "50Mi",
+				},
+			},
+		}
+
+		// act
+		valid, errors, warnings := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+		assert.Equal(t, 0, len(warnings))
+	})
+
+	t.Run("ReturnsFalseIfBasepathIsNotSet", func(t *testing.T) {
+
+		params := validParams
+		params.Basepath = ""
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfBasepathIsSet", func(t *testing.T) {
+
+		params := validParams
+		params.Basepath = "/"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfRollingUpdateMaxSurgeIsNotSet", func(t *testing.T) {
+
+		params := validParams
+		params.RollingUpdate.MaxSurge = ""
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfRollingUpdateMaxSurgeIsSet", func(t *testing.T) {
+
+		params := validParams
+		params.RollingUpdate.MaxSurge = "25%"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfRollingUpdateMaxUnavailableIsNotSet", func(t *testing.T) {
+
+		params := validParams
+		params.RollingUpdate.MaxUnavailable = ""
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfRollingUpdateMaxUnavailableIsSet", func(t *testing.T) {
+
+		params := validParams
+		params.RollingUpdate.MaxUnavailable = "25%"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfScheduleIsNotSetAndKindIsCronjob", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = "cronjob"
+		params.Schedule = ""
+		params.ConcurrencyPolicy = "Allow"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsFalseIfConcurrencyPolicyIsInvalidAndKindIsCronjob", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = "cronjob"
+		params.Schedule = "*/5 * * * *"
+		params.ConcurrencyPolicy = "InvalidPolicy"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfScheduleIsSetAndConcurrencyPolicyIsValidAndKindIsCronjob", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = "cronjob"
+		params.Schedule = "*/5 * * * *"
+		params.ConcurrencyPolicy = "Allow"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfUnknownPropertiesIsInvalid", func(t *testing.T) {
+
+		params := validParams
+		params.UnknownProperties = "ignore"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsFalseIfCpuRequestIsNotAValidQuantity", func(t *testing.T) {
+
+		params := validParams
+		params.Container.CPU.Request = "100 millicores"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsFalseIfMemoryRequestIsNegative", func(t *testing.T) {
+
+		params := validParams
+		params.Container.Memory.Request = "-1Gi"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, "container.memory.request", errors[0].Path)
+	})
+
+	t.Run("ReturnsFalseIfMemoryRequestIsLargerThanMemoryLimit", func(t *testing.T) {
+
+		params := validParams
+		params.Container.Memory.Request = "2Gi"
+		params.Container.Memory.Limit = "1024Mi"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfMemoryRequestEqualsMemoryLimitInDifferentUnits", func(t *testing.T) {
+
+		params := validParams
+		params.Container.Memory.Request = "1Gi"
+		params.Container.Memory.Limit = "1024Mi"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfSidecarCpuRequestIsLargerThanSidecarCpuLimit", func(t *testing.T) {
+
+		params := validParams
+		params.Sidecars = []*SidecarParams{
+			&SidecarParams{
+				Type:   "openresty",
+				Image:  "estafette/openresty-sidecar:1.13.6.2-alpine",
+				CPU:    CPUParams{Request: "0.2", Limit: "100m"},
+				Memory: MemoryParams{Request: "10Mi", Limit: "50Mi"},
+			},
+		}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsFalseIfRollingUpdateMaxSurgeIsNeitherIntegerNorPercentage", func(t *testing.T) {
+
+		params := validParams
+		params.RollingUpdate.MaxSurge = "a quarter"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsFalseIfRollingUpdateMaxSurgeAndMaxUnavailableAreBothZero", func(t *testing.T) {
+
+		params := validParams
+		params.RollingUpdate.MaxSurge = "0%"
+		params.RollingUpdate.MaxUnavailable = "0"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsFalseIfLivenessPortIsNotExposedByContainer", func(t *testing.T) {
+
+		params := validParams
+		params.Container.LivenessProbe.Port = 5001
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfReadinessPortIsAnAdditionalPort", func(t *testing.T) {
+
+		params := validParams
+		params.Container.ReadinessProbe.Port = 5001
+		params.Container.AdditionalPorts = []*AdditionalPortParams{
+			&AdditionalPortParams{Name: "health", Port: 5001, Protocol: "TCP", Visibility: "private"},
+		}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfScheduleIsInvalidAndKindIsCronjob", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = "cronjob"
+		params.Schedule = "*/5 * * *"
+		params.ConcurrencyPolicy = "Allow"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsErrorsWithPathSeverityAndCode", func(t *testing.T) {
+
+		params := validParams
+		params.Sidecars = []*SidecarParams{
+			validParams.Sidecars[0],
+			&SidecarParams{
+				Type:                     "cloudsqlproxy",
+				Image:                    "gcr.io/cloudsql-docker/gce-proxy:1.14",
+				DbInstanceConnectionName: "my-project:europe-west1:my-db",
+				CPU:                      CPUParams{Request: "10m", Limit: "50m"},
+				Memory:                   MemoryParams{Request: "10Mi", Limit: "50Mi"},
+			},
+		}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, 1, len(errors))
+		assert.Equal(t, "sidecars[1].sqlproxyport", errors[0].Path)
+		assert.Equal(t, "error", errors[0].Severity)
+		assert.Equal(t, "required", errors[0].Code)
+	})
+
+	t.Run("ReturnsHostErrorsWithIndexInPath", func(t *testing.T) {
+
+		params := validParams
+		params.Hosts = []string{"gke.estafette.io", "gke_estafette.io"}
+
+		// act
+		_, errors, _ := params.ValidateRequiredProperties()
+
+		assert.Equal(t, 1, len(errors))
+		assert.Equal(t, "hosts[1]", errors[0].Path)
+		assert.Equal(t, "invalid-host", errors[0].Code)
+	})
+
+	t.Run("ReturnsDeprecationWarningForSidecarField", func(t *testing.T) {
+
+		params := validParams
+
+		// act
+		_, _, warnings := params.ValidateRequiredProperties()
+
+		assert.Equal(t, 1, len(warnings))
+		assert.Equal(t, "sidecar", warnings[0].Path)
+		assert.Equal(t, "warning", warnings[0].Severity)
+		assert.Equal(t, "deprecated", warnings[0].Code)
+	})
+
+	t.Run("ReturnsTrueIfEnvironmentVariablesReferenceSecretsConfigmapsAndPodFields", func(t *testing.T) {
+
+		params := validParams
+		params.Secrets.Keys = map[string]interface{}{"db-password": "estafette.secret(abc)"}
+		params.Container.EnvironmentVariables = map[string]interface{}{
+			"MY_ENV":      "value",
+			"DB_PASSWORD": map[string]interface{}{"secretKeyRef": map[string]interface{}{"key": "db-password"}},
+			"API_KEY":     map[string]interface{}{"secretKeyRef": map[string]interface{}{"name": "shared-secrets", "key": "api-key"}},
+			"LOG_LEVEL":   map[string]interface{}{"configMapKeyRef": map[string]interface{}{"name": "shared-config", "key": "log-level"}},
+			"POD_NAME":    map[string]interface{}{"fieldRef": map[string]interface{}{"fieldPath": "metadata.name"}},
+			"CPU_LIMIT":   map[string]interface{}{"resourceFieldRef": map[string]interface{}{"resource": "limits.cpu"}},
+		}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.Equal(t, 0, len(errors))
+	})
+
+	t.Run("ReturnsFalseIfEnvironmentVariableHasMultipleSources", func(t *testing.T) {
+
+		params := validParams
+		params.Container.EnvironmentVariables = map[string]interface{}{
+			"POD_IP": map[string]interface{}{
+				"fieldRef":        map[string]interface{}{"fieldPath": "status.podIP"},
+				"configMapKeyRef": map[string]interface{}{"name": "shared-config", "key": "pod-ip"},
+			},
+		}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, 1, len(errors))
+		assert.Equal(t, "container.env.POD_IP", errors[0].Path)
+	})
+
+	t.Run("ReturnsFalseIfEnvironmentVariableHasUnsupportedSource", func(t *testing.T) {
+
+		params := validParams
+		params.Container.EnvironmentVariables = map[string]interface{}{
+			"POD_IP": map[string]interface{}{"fieldref": map[string]interface{}{"fieldPath": "status.podIP"}},
+		}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, "container.env.POD_IP.fieldref", errors[0].Path)
+		assert.Equal(t, "invalid-value", errors[0].Code)
+	})
+
+	t.Run("ReturnsFalseIfEnvironmentVariableSourceMissesRequiredProperty", func(t *testing.T) {
+
+		params := validParams
+		params.Container.EnvironmentVariables = map[string]interface{}{
+			"LOG_LEVEL": map[string]interface{}{"configMapKeyRef": map[string]interface{}{"key": "log-level"}},
+		}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, 1, len(errors))
+		assert.Equal(t, "container.env.LOG_LEVEL.configMapKeyRef.name", errors[0].Path)
+		assert.Equal(t, "required", errors[0].Code)
+	})
+
+	t.Run("ReturnsFalseIfEnvironmentVariableReferencesUnknownApplicationSecretKey", func(t *testing.T) {
+
+		params := validParams
+		params.Secrets.Keys = map[string]interface{}{"db-password": "estafette.secret(abc)"}
+		params.Container.EnvironmentVariables = map[string]interface{}{
+			"DB_USER": map[string]interface{}{"secretKeyRef": map[string]interface{}{"key": "db-user"}},
+		}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, 1, len(errors))
+		assert.Equal(t, "container.env.DB_USER.secretKeyRef.key", errors[0].Path)
+	})
+
+	t.Run("ReturnsFalseIfSidecarEnvironmentVariableIsInvalid", func(t *testing.T) {
+
+		params := validParams
+		params.Sidecars = []*SidecarParams{
+			&SidecarParams{
+				Type:   "openresty",
+				Image:  "estafette/openresty-sidecar:1.13.6.2-alpine",
+				CPU:    CPUParams{Request: "10m", Limit: "50m"},
+				Memory: MemoryParams{Request: "10Mi", Limit: "50Mi"},
+				EnvironmentVariables: map[string]interface{}{
+					"POD_NAME": map[string]interface{}{"fieldRef": map[string]interface{}{}},
+				},
+			},
+		}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, 1, len(errors))
+		assert.Equal(t, "sidecars[0].env.POD_NAME.fieldRef.fieldPath", errors[0].Path)
+	})
+
+	t.Run("ReturnsTrueIfKindIsStatefulSetWithValidVolumeClaimTemplates", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = "statefulset"
+		params.Sidecar = SidecarParams{}
+		params.Sidecars = []*SidecarParams{}
+		params.StatefulSet = StatefulSetParams{
+			Replicas:            3,
+			PodManagementPolicy: "Parallel",
+			VolumeClaimTemplates: []VolumeClaimTemplateParams{
+				{Name: "data", MountPath: "/data", Size: "10Gi", AccessModes: []string{"ReadWriteOnce"}},
+			},
+		}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.Equal(t, 0, len(errors))
+	})
+
+	t.Run("ReturnsFalseIfKindIsStatefulSetAndVolumeClaimTemplatesAreInvalid", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = "statefulset"
+		params.Sidecar = SidecarParams{}
+		params.Sidecars = []*SidecarParams{}
+		params.StatefulSet = StatefulSetParams{
+			Replicas:            1,
+			PodManagementPolicy: "OrderedReady",
+			VolumeClaimTemplates: []VolumeClaimTemplateParams{
+				{Name: "data", MountPath: "/data", Size: "lots", AccessModes: []string{"ReadWriteOnce"}},
+				{Name: "data", Size: "1Gi", AccessModes: []string{"ReadWriteSometimes"}},
+			},
+		}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		errorPaths := []string{}
+		for _, e := range errors {
+			errorPaths = append(errorPaths, e.Path)
+		}
+		assert.Contains(t, errorPaths, "statefulset.volumeclaimtemplates[0].size")
+		assert.Contains(t, errorPaths, "statefulset.volumeclaimtemplates[1].name")
+		assert.Contains(t, errorPaths, "statefulset.volumeclaimtemplates[1].mountpath")
+		assert.Contains(t, errorPaths, "statefulset.volumeclaimtemplates[1].accessmodes")
+	})
+
+	t.Run("ReturnsFalseIfKindIsStatefulSetAndVolumeClaimTemplateSizeIsNegative", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = "statefulset"
+		params.Sidecar = SidecarParams{}
+		params.Sidecars = []*SidecarParams{}
+		params.StatefulSet = StatefulSetParams{
+			Replicas:            1,
+			PodManagementPolicy: "OrderedReady",
+			VolumeClaimTemplates: []VolumeClaimTemplateParams{
+				{Name: "data", MountPath: "/data", Size: "-10Gi", AccessModes: []string{"ReadWriteOnce"}},
+			},
+		}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, 1, len(errors))
+		assert.Equal(t, "statefulset.volumeclaimtemplates[0].size", errors[0].Path)
+	})
+
+	t.Run("ReturnsFalseIfKindIsStatefulSetAndPartitionIsLargerThanReplicas", func(t *testing.T) {
+
+		partition := 4
+		params := validParams
+		params.Kind = "statefulset"
+		params.Sidecar = SidecarParams{}
+		params.Sidecars = []*SidecarParams{}
+		params.StatefulSet = StatefulSetParams{
+			Replicas:            3,
+			PodManagementPolicy: "OrderedReady",
+			Partition:           &partition,
+		}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, 1, len(errors))
+		assert.Equal(t, "statefulset.partition", errors[0].Path)
+	})
+
+	t.Run("ReturnsFalseIfKindIsStatefulSetAndOpenrestySidecarIsSet", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = "statefulset"
+		params.StatefulSet = StatefulSetParams{
+			Replicas:            1,
+			PodManagementPolicy: "OrderedReady",
+		}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		errorPaths := []string{}
+		for _, e := range errors {
+			errorPaths = append(errorPaths, e.Path)
+		}
+		assert.Contains(t, errorPaths, "sidecar.type")
+	})
+
+	t.Run("ReturnsFalseIfKindIsStatefulSetAndActionIsDeployCanary", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = "statefulset"
+		params.Action = "deploy-canary"
+		params.Sidecar = SidecarParams{}
+		params.Sidecars = []*SidecarParams{}
+		params.StatefulSet = StatefulSetParams{
+			Replicas:            1,
+			PodManagementPolicy: "OrderedReady",
+		}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, "action", errors[0].Path)
+	})
+
+	t.Run("ReturnsTrueIfKindIsDaemonSetWithValidHostPathVolumes", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = "daemonset"
+		params.Sidecar = SidecarParams{}
+		params.Sidecars = []*SidecarParams{}
+		params.DaemonSet = DaemonSetParams{
+			HostNetwork:    true,
+			UpdateStrategy: "RollingUpdate",
+			MaxUnavailable: "10%",
+			HostPathVolumes: []HostPathVolumeParams{
+				{Name: "var-log", HostPath: "/var/log", MountPath: "/var/log", Type: "Directory", ReadOnly: true},
+			},
+		}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.Equal(t, 0, len(errors))
+	})
+
+	t.Run("ReturnsFalseIfKindIsDaemonSetAndHostPathVolumesAreInvalid", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = "daemonset"
+		params.Sidecar = SidecarParams{}
+		params.Sidecars = []*SidecarParams{}
+		params.DaemonSet = DaemonSetParams{
+			UpdateStrategy: "RollingUpdate",
+			MaxUnavailable: "1",
+			HostPathVolumes: []HostPathVolumeParams{
+				{Name: "var-log", HostPath: "var/log", MountPath: "/var/log"},
+				{Name: "var-log", HostPath: "/run/docker.sock", Type: "Pipe"},
+			},
+		}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		errorPaths := []string{}
+		for _, e := range errors {
+			errorPaths = append(errorPaths, e.Path)
+		}
+		assert.Contains(t, errorPaths, "daemonset.hostpathvolumes[0].hostpath")
+		assert.Contains(t, errorPaths, "daemonset.hostpathvolumes[1].name")
+		assert.Contains(t, errorPaths, "daemonset.hostpathvolumes[1].mountpath")
+		assert.Contains(t, errorPaths, "daemonset.hostpathvolumes[1].type")
+	})
+
+	t.Run("ReturnsFalseIfKindIsDaemonSetAndMaxUnavailableIsZero", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = "daemonset"
+		params.Sidecar = SidecarParams{}
+		params.Sidecars = []*SidecarParams{}
+		params.DaemonSet = DaemonSetParams{
+			UpdateStrategy: "RollingUpdate",
+			MaxUnavailable: "0",
+		}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, 1, len(errors))
+		assert.Equal(t, "daemonset.maxunavailable", errors[0].Path)
+	})
+
+	t.Run("ReturnsWarningIfKindIsDaemonSetAndMaxUnavailableIsSetForOnDelete", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = "daemonset"
+		params.Sidecar = SidecarParams{}
+		params.Sidecars = []*SidecarParams{}
+		params.DaemonSet = DaemonSetParams{
+			UpdateStrategy: "OnDelete",
+			MaxUnavailable: "1",
+		}
+
+		// act
+		valid, _, warnings := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		warningPaths := []string{}
+		for _, w := range warnings {
+			warningPaths = append(warningPaths, w.Path)
+		}
+		assert.Contains(t, warningPaths, "daemonset.maxunavailable")
+	})
+
+	t.Run("ReturnsFalseIfKindIsDaemonSetAndOpenrestySidecarIsSet", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = "daemonset"
+		params.DaemonSet = DaemonSetParams{
+			UpdateStrategy: "RollingUpdate",
+			MaxUnavailable: "1",
+		}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		errorPaths := []string{}
+		for _, e := range errors {
+			errorPaths = append(errorPaths, e.Path)
+		}
+		assert.Contains(t, errorPaths, "sidecar.type")
+	})
+}
+
+func TestReplaceOpenrestyTagWithDigest(t *testing.T) {
+
+	t.Run("ReplacesOpenrestySidecarImageTagWithDigest", func(t *testing.T) {
+
+		params := validParams
+
+		// act
+		params.ReplaceOpenrestyTagWithDigest()
+
+		assert.Equal(t, "openresty", params.Sidecars[0].Type)
+		assert.True(t, strings.HasPrefix(params.Sidecars[0].Image, "estafette/openresty-sidecar@sha256:"))
+	})
+
+	t.Run("KeepsOpenrestySidecarImageTagWithDigest", func(t *testing.T) {
+
+		params := validParams
+		params.Sidecars[0].Image = "estafette/openresty-sidecar@sha256:4300dc7d45600c428f4196009ee842c1c3bdd51aaa4f55361479f6fa60e78faf"
+
+		// act
+		params.ReplaceOpenrestyTagWithDigest()
+
+		assert.Equal(t, "openresty", params.Sidecars[0].Type)
+		assert.True(t, strings.HasPrefix(params.Sidecars[0].Image, "estafette/openresty-sidecar@sha256:"))
+	})
+}
+
+func TestValidateUnknownProperties(t *testing.T) {
+
+	t.Run("ReturnsWarningsIfUnknownPropertiesIsWarn", func(t *testing.T) {
+
+		params := validParams
+		params.UnknownProperties = "warn"
+
+		// act
+		errors, warnings := params.ValidateUnknownProperties([]UnknownPropertyError{{Path: "autoscale.mim", Suggestion: "autoscale.min"}})
+
+		assert.Equal(t, 0, len(errors))
+		assert.Equal(t, 1, len(warnings))
+		assert.Equal(t, "Property autoscale.mim is unknown and will be ignored; did you mean autoscale.min?", warnings[0].Message)
+		assert.Equal(t, "autoscale.mim", warnings[0].Path)
+		assert.Equal(t, "warning", warnings[0].Severity)
+		assert.Equal(t, "unknown-property", warnings[0].Code)
+	})
+
+	t.Run("ReturnsErrorsIfUnknownPropertiesIsError", func(t *testing.T) {
+
+		params := validParams
+		params.UnknownProperties = "error"
+
+		// act
+		errors, warnings := params.ValidateUnknownProperties([]UnknownPropertyError{{Path: "autoscale.mim", Suggestion: "autoscale.min"}})
+
+		assert.Equal(t, 1, len(errors))
+		assert.Equal(t, 0, len(warnings))
+	})
+}
+
+func TestApplyEnvironment(t *testing.T) {
+
+	t.Run("MergesEnvironmentForReleaseNameOverBaseParams", func(t *testing.T) {
+
+		paramsJSON := `{"app":"myapp","hosts":["myapp.staging.com"],"autoscale":{"min":2,"max":5},"labels":{"team":"myteam"},"environments":{"production":{"hosts":["myapp.com"],"autoscale":{"min":6},"labels":{"tier":"gold"}}}}`
+		var params Params
+		err := json.Unmarshal([]byte(paramsJSON), &params)
+		assert.Nil(t, err)
+
+		// act
+		applied, err := params.ApplyEnvironment([]byte(paramsJSON), "production")
+
+		assert.Nil(t, err)
+		assert.True(t, applied)
+		assert.Equal(t, "myapp", params.App)
+		assert.Equal(t, []string{"myapp.com"}, params.Hosts)
+		assert.Equal(t, 6, params.Autoscale.MinReplicas)
+		assert.Equal(t, 5, params.Autoscale.MaxReplicas)
+		assert.Equal(t, map[string]string{"team": "myteam", "tier": "gold"}, params.Labels)
+		assert.Nil(t, params.Environments)
+	})
+
+	t.Run("KeepsBaseParamsIfNoEnvironmentMatchesReleaseName", func(t *testing.T) {
+
+		paramsJSON := `{"hosts":["myapp.staging.com"],"environments":{"production":{"hosts":["myapp.com"]}}}`
+		var params Params
+		err := json.Unmarshal([]byte(paramsJSON), &params)
+		assert.Nil(t, err)
+
+		// act
+		applied, err := params.ApplyEnvironment([]byte(paramsJSON), "staging")
+
+		assert.Nil(t, err)
+		assert.False(t, applied)
+		assert.Equal(t, []string{"myapp.staging.com"}, params.Hosts)
+		assert.Nil(t, params.Environments)
+	})
+
+	t.Run("ReturnsErrorForNestedEnvironments", func(t *testing.T) {
+
+		paramsJSON := `{"environments":{"production":{"environments":{"staging":{}}}}}`
+		var params Params
+		err := json.Unmarshal([]byte(paramsJSON), &params)
+		assert.Nil(t, err)
+
+		// act
+		_, err = params.ApplyEnvironment([]byte(paramsJSON), "production")
+
+		assert.NotNil(t, err)
+	})
+
+	t.Run("ReplacesListsOfObjectsInsteadOfMergingThemByIndex", func(t *testing.T) {
+
+		paramsJSON := `{"sidecars":[{"type":"openresty","image":"estafette/openresty-sidecar:1.13.6.2-alpine","cpu":{"request":"10m","limit":"50m"}}],"volumemounts":[{"name":"client-certs","mountpath":"/cockroach-certs","volume":{"secret":{"secretName":"estafette-ci-db-client-secret"}}}],"environments":{"production":{"sidecars":[{"type":"cloudsqlproxy"}],"volumemounts":[{"name":"cache","mountpath":"/cache"}]}}}`
+		var params Params
+		err := json.Unmarshal([]byte(paramsJSON), &params)
+		assert.Nil(t, err)
+
+		// act
+		applied, err := params.ApplyEnvironment([]byte(paramsJSON), "production")
+
+		assert.Nil(t, err)
+		assert.True(t, applied)
+		assert.Equal(t, []*SidecarParams{{Type: "cloudsqlproxy"}}, params.Sidecars)
+		assert.Equal(t, 1, len(params.VolumeMounts))
+		assert.Equal(t, "cache", params.VolumeMounts[0].Name)
+		assert.Equal(t, "/cache", params.VolumeMounts[0].MountPath)
+		assert.Nil(t, params.VolumeMounts[0].Volume)
+	})
+}
+
+func TestRestrictIngressAnnotationAllowlist(t *testing.T) {
+
+	t.Run("TakesAllowlistFromCredentialDefaults", func(t *testing.T) {
+
+		params := Params{
+			Ingress: IngressParams{AllowedAnnotations: []string{"nginx.ingress.kubernetes.io/proxy-*"}},
+		}
+		defaults := &Params{
+			Ingress: IngressParams{AllowedAnnotations: []string{"nginx.ingress.kubernetes.io/proxy-*"}},
+		}
+
+		// act
+		warnings := params.RestrictIngressAnnotationAllowlist(defaults)
+
+		assert.Equal(t, 0, len(warnings))
+		assert.Equal(t, []string{"nginx.ingress.kubernetes.io/proxy-*"}, params.Ingress.AllowedAnnotations)
+	})
+
+	t.Run("IgnoresAllowlistSetInManifest", func(t *testing.T) {
+
+		params := Params{
+			Ingress: IngressParams{AllowedAnnotations: []string{"*"}},
+		}
+
+		// act
+		warnings := params.RestrictIngressAnnotationAllowlist(nil)
+
+		assert.Equal(t, 1, len(warnings))
+		assert.Equal(t, "ingress.allowedannotations", warnings[0].Path)
+		assert.Nil(t, params.Ingress.AllowedAnnotations)
 	})
 }
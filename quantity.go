@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	quantityRegex         = regexp.MustCompile(`^([+-]?[0-9]*\.?[0-9]+|[+-]?[0-9]+\.)([a-zA-Z]*|[eE][+-]?[0-9]+)$`)
+	quantityExponentRegex = regexp.MustCompile(`^[eE][+-]?[0-9]+$`)
+
+	quantityMultipliers = map[string]float64{
+		"":   1,
+		"n":  1e-9,
+		"u":  1e-6,
+		"m":  1e-3,
+		"k":  1e3,
+		"M":  1e6,
+		"G":  1e9,
+		"T":  1e12,
+		"P":  1e15,
+		"E":  1e18,
+		"Ki": 1 << 10,
+		"Mi": 1 << 20,
+		"Gi": 1 << 30,
+		"Ti": 1 << 40,
+		"Pi": 1 << 50,
+		"Ei": 1 << 60,
+	}
+)
+
+// parseQuantity parses a non-negative kubernetes resource quantity like 100m, 0.5, 128Mi or 1e3 into its numeric value; it's used for
+// resources and sizes, which can't be negative
+func parseQuantity(quantity string) (float64, error) {
+
+	matches := quantityRegex.FindStringSubmatch(strings.TrimSpace(quantity))
+	if matches == nil {
+		return 0, fmt.Errorf("%v is not a valid quantity", quantity)
+	}
+
+	number, err := strconv.ParseFloat(matches[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("%v is not a valid quantity: %v", quantity, err)
+	}
+	if number < 0 {
+		return 0, fmt.Errorf("%v should not be negative", quantity)
+	}
+
+	// look up the suffix first, since the decimal and binary exa suffixes E and Ei start like an exponent
+	suffix := matches[2]
+	if multiplier, ok := quantityMultipliers[suffix]; ok {
+		return number * multiplier, nil
+	}
+
+	if quantityExponentRegex.MatchString(suffix) {
+		exponent, err := strconv.Atoi(suffix[1:])
+		if err != nil {
+			return 0, fmt.Errorf("%v is not a valid quantity: %v", quantity, err)
+		}
+		return number * math.Pow10(exponent), nil
+	}
+
+	return 0, fmt.Errorf("%v is not a valid quantity; suffix %v is unknown", quantity, suffix)
+}
+
+// parseIntOrPercentage parses values like maxSurge or maxUnavailable, which are either a non-negative integer or a percentage
+func parseIntOrPercentage(value string) (number int, isPercentage bool, err error) {
+
+	isPercentage = strings.HasSuffix(value, "%")
+
+	number, err = strconv.Atoi(strings.TrimSuffix(value, "%"))
+	if err != nil {
+		return 0, isPercentage, fmt.Errorf("%v is neither an integer nor a percentage", value)
+	}
+	if number < 0 {
+		return 0, isPercentage, fmt.Errorf("%v should not be negative", value)
+	}
+	if isPercentage && number > 100 {
+		return 0, isPercentage, fmt.Errorf("%v should not be more than 100%%", value)
+	}
+
+	return number, isPercentage, nil
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseQuantity(t *testing.T) {
+
+	t.Run("ParsesMilliCPU", func(t *testing.T) {
+
+		// act
+		value, err := parseQuantity("100m")
+
+		assert.Nil(t, err)
+		assert.InDelta(t, 0.1, value, 0.0000001)
+	})
+
+	t.Run("ParsesDecimalWithoutSuffix", func(t *testing.T) {
+
+		// act
+		value, err := parseQuantity("0.5")
+
+		assert.Nil(t, err)
+		assert.Equal(t, 0.5, value)
+	})
+
+	t.Run("ParsesBinarySuffix", func(t *testing.T) {
+
+		// act
+		value, err := parseQuantity("128Mi")
+
+		assert.Nil(t, err)
+		assert.Equal(t, float64(128*1024*1024), value)
+	})
+
+	t.Run("ParsesDecimalSuffix", func(t *testing.T) {
+
+		// act
+		value, err := parseQuantity("1G")
+
+		assert.Nil(t, err)
+		assert.Equal(t, float64(1000000000), value)
+	})
+
+	t.Run("ParsesExponent", func(t *testing.T) {
+
+		// act
+		value, err := parseQuantity("12e3")
+
+		assert.Nil(t, err)
+		assert.Equal(t, float64(12000), value)
+	})
+
+	t.Run("ReturnsErrorForUnknownSuffix", func(t *testing.T) {
+
+		// act
+		_, err := parseQuantity("128MB")
+
+		assert.NotNil(t, err)
+	})
+
+	t.Run("ReturnsErrorForNonNumericValue", func(t *testing.T) {
+
+		// act
+		_, err := parseQuantity("lots")
+
+		assert.NotNil(t, err)
+	})
+
+	t.Run("ParsesExaSuffixesAndExponents", func(t *testing.T) {
+
+		tests := []struct {
+			quantity string
+			value    float64
+		}{
+			{"1E", 1e18},
+			{"2Ei", 2 * (1 << 60)},
+			{"1e3", 1e3},
+			{"5E-2", 5e-2},
+		}
+
+		for _, tt := range tests {
+			// act
+			value, err := parseQuantity(tt.quantity)
+
+			assert.Nil(t, err, tt.quantity)
+			assert.Equal(t, tt.value, value, tt.quantity)
+		}
+	})
+
+	t.Run("ReturnsErrorForNegativeOrIncompleteQuantity", func(t *testing.T) {
+
+		for _, quantity := range []string{"-1Gi", "-100m", "1e", "1Eb"} {
+			// act
+			_, err := parseQuantity(quantity)
+
+			assert.NotNil(t, err, quantity)
+		}
+	})
+}
+
+func TestParseIntOrPercentage(t *testing.T) {
+
+	t.Run("ParsesInteger", func(t *testing.T) {
+
+		// act
+		number, isPercentage, err := parseIntOrPercentage("2")
+
+		assert.Nil(t, err)
+		assert.Equal(t, 2, number)
+		assert.False(t, isPercentage)
+	})
+
+	t.Run("ParsesPercentage", func(t *testing.T) {
+
+		// act
+		number, isPercentage, err := parseIntOrPercentage("25%")
+
+		assert.Nil(t, err)
+		assert.Equal(t, 25, number)
+		assert.True(t, isPercentage)
+	})
+
+	t.Run("ReturnsErrorForPercentageAbove100", func(t *testing.T) {
+
+		// act
+		_, _, err := parseIntOrPercentage("150%")
+
+		assert.NotNil(t, err)
+	})
+
+	t.Run("ReturnsErrorForNegativeNumber", func(t *testing.T) {
+
+		// act
+		_, _, err := parseIntOrPercentage("-1")
+
+		assert.NotNil(t, err)
+	})
+
+	t.Run("ReturnsErrorForFraction", func(t *testing.T) {
+
+		// act
+		_, _, err := parseIntOrPercentage("0.5")
+
+		assert.NotNil(t, err)
+	})
+}
@@ -10,37 +10,79 @@ import (
 	"text/template"
 
 	"github.com/Masterminds/sprig"
+	yaml "gopkg.in/yaml.v2"
 )
 
-func buildTemplates(params Params) (*template.Template, error) {
+// builtInTemplatesDir is the directory the names of the built-in manifests are prefixed with; these manifests aren't read from disk, but
+// generated by buildManifest
+const builtInTemplatesDir = "/templates"
 
-	// merge templates
-	templatesToMerge := getTemplates(params)
+// renderManifests generates the built-in manifests from typed objects and renders the local manifests set via manifests.files as templates;
+// the rendered manifests are joined into a single multi-document yaml
+func renderManifests(templates []string, templateData TemplateData) (bytes.Buffer, error) {
 
-	if len(templatesToMerge) == 0 {
-		return nil, nil
+	var renderedManifests bytes.Buffer
+
+	if len(templates) == 0 {
+		return renderedManifests, nil
 	}
 
-	logInfo("Merging templates %v...", strings.Join(templatesToMerge, ", "))
+	logInfo("Rendering manifests %v...", strings.Join(templates, ", "))
 
-	templateStrings := []string{}
-	for _, t := range templatesToMerge {
-		data, err := ioutil.ReadFile(t)
+	manifests := []string{}
+	for _, t := range templates {
+		manifest, err := renderManifest(t, templateData)
 		if err != nil {
-			log.Fatal(fmt.Sprintf("Failed reading file %v. Do you have a git-clone stage before running this extension? For releases git-clone is not automatically handled to save time in case it's not needed. ", t), err)
+			return renderedManifests, err
 		}
-		templateStrings = append(templateStrings, string(data))
+		manifests = append(manifests, strings.TrimSuffix(manifest, "\n"))
 	}
-	templateString := strings.Join(templateStrings, "\n---\n")
+	renderedManifests.WriteString(strings.Join(manifests, "\n---\n"))
+
+	logInfo("Manifests after rendering:")
+	log.Println(renderedManifests.String())
+	log.Println("")
 
-	// parse templates
-	logInfo("Parsing merged templates...")
-	return template.New("kubernetes.yaml").Funcs(sprig.TxtFuncMap()).Parse(templateString)
+	return renderedManifests, nil
+}
+
+func renderManifest(t string, templateData TemplateData) (string, error) {
+
+	if filepath.Dir(t) == builtInTemplatesDir {
+		if object, ok := buildManifest(filepath.Base(t), templateData); ok {
+			data, err := yaml.Marshal(object)
+			if err != nil {
+				return "", fmt.Errorf("Failed serializing manifest %v: %v", t, err)
+			}
+			return string(data), nil
+		}
+	}
+
+	data, err := ioutil.ReadFile(t)
+	if err != nil {
+		return "", fmt.Errorf("Failed reading file %v. Do you have a git-clone stage before running this extension? For releases git-clone is not automatically handled to save time in case it's not needed: %v", t, err)
+	}
+	tmpl, err := template.New(filepath.Base(t)).Funcs(sprig.TxtFuncMap()).Parse(string(data))
+	if err != nil {
+		return "", fmt.Errorf("Failed parsing template %v: %v", t, err)
+	}
+
+	var renderedTemplate bytes.Buffer
+	err = tmpl.Execute(&renderedTemplate, templateData)
+	if err != nil {
+		return "", fmt.Errorf("Failed rendering template %v: %v", t, err)
+	}
+
+	return renderedTemplate.String(), nil
 }
 
 func getTemplates(params Params) []string {
 
 	if params.Action == "rollback-canary" {
+		// with istio the canary subset has to stop receiving traffic before the canary deployment is scaled down
+		if params.Kind == "deployment" && params.Mesh.Type == "istio" {
+			return []string{filepath.Join(builtInTemplatesDir, "virtualservice.yaml")}
+		}
 		return []string{}
 	}
 
@@ -61,33 +103,76 @@ func getTemplates(params Params) []string {
 			"cronjob.yaml",
 		}...)
 
+	case "statefulset":
+		templatesToMerge = append(templatesToMerge, []string{
+			"namespace.yaml",
+			"service-headless.yaml",
+			"serviceaccount.yaml",
+			"statefulset.yaml",
+		}...)
+		if params.PodDisruptionBudget.Enabled == nil || *params.PodDisruptionBudget.Enabled {
+			templatesToMerge = append(templatesToMerge, "poddisruptionbudget.yaml")
+		}
+
+	case "daemonset":
+		templatesToMerge = append(templatesToMerge, []string{
+			"namespace.yaml",
+			"serviceaccount.yaml",
+			"daemonset.yaml",
+		}...)
+
 	default:
 		templatesToMerge = append(templatesToMerge, []string{
 			"namespace.yaml",
 			"service.yaml",
 			"serviceaccount.yaml",
-			"certificate-secret.yaml",
-			"deployment.yaml",
 		}...)
 
+		// the certificate secret is mounted by the openresty sidecar, so it goes before the deployment; the openresty sidecar and internal
+		// ingress still need a certificate secret next to a managed certificate
+		switch params.Certificate.Provider {
+		case "cert-manager":
+			templatesToMerge = append(templatesToMerge, "certificate.yaml")
+		case "managed":
+			templatesToMerge = append(templatesToMerge, "managedcertificate.yaml")
+			if params.Certificate.SecretName == "" {
+				templatesToMerge = append(templatesToMerge, "certificate-secret.yaml")
+			}
+		case "secret":
+		default:
+			templatesToMerge = append(templatesToMerge, "certificate-secret.yaml")
+		}
+
+		templatesToMerge = append(templatesToMerge, "deployment.yaml")
+
 	}
 
 	if params.Kind == "deployment" && (params.Action == "deploy-simple" || params.Action == "deploy-stable") {
-		templatesToMerge = append(templatesToMerge, []string{
-			"poddisruptionbudget.yaml",
-			"horizontalpodautoscaler.yaml",
-		}...)
+		if params.PodDisruptionBudget.Enabled == nil || *params.PodDisruptionBudget.Enabled {
+			templatesToMerge = append(templatesToMerge, "poddisruptionbudget.yaml")
+		}
+		templatesToMerge = append(templatesToMerge, "horizontalpodautoscaler.yaml")
 	}
 
-	if params.Kind == "deployment" && (params.Visibility == "private" || params.Visibility == "iap" || params.Visibility == "public-whitelist") {
+	useIstio := params.Kind == "deployment" && params.Mesh.Type == "istio"
+	if useIstio {
+		templatesToMerge = append(templatesToMerge, "destinationrule.yaml", "virtualservice.yaml")
+		if params.Mesh.Gateway == "" {
+			templatesToMerge = append(templatesToMerge, "gateway.yaml")
+		}
+	}
+	if !useIstio && params.Kind == "deployment" && (params.Visibility == "private" || params.Visibility == "iap" || params.Visibility == "public-whitelist") {
 		templatesToMerge = append(templatesToMerge, "ingress.yaml")
 	}
-	if params.Kind == "deployment" && params.Visibility == "iap" {
+	if !useIstio && params.Kind == "deployment" && params.Visibility == "iap" {
 		templatesToMerge = append(templatesToMerge, "backend-config.yaml", "iap-oauth-credentials-secret.yaml")
 	}
-	if params.Kind == "deployment" && len(params.InternalHosts) > 0 {
+	if !useIstio && params.Kind == "deployment" && len(params.InternalHosts) > 0 {
 		templatesToMerge = append(templatesToMerge, "ingress-internal.yaml")
 	}
+	if params.NetworkPolicy.Enabled && params.Action != "deploy-canary" {
+		templatesToMerge = append(templatesToMerge, "networkpolicy.yaml")
+	}
 	if len(params.Secrets.Keys) > 0 {
 		templatesToMerge = append(templatesToMerge, "application-secrets.yaml")
 	}
@@ -98,9 +183,9 @@ func getTemplates(params Params) []string {
 		templatesToMerge = append(templatesToMerge, "configmap.yaml")
 	}
 
-	// prefix all filenames with templates dir
+	// prefix all filenames with the built-in templates dir, so they can be told apart from local manifests
 	for i, t := range templatesToMerge {
-		templatesToMerge[i] = fmt.Sprintf("/templates/%v", t)
+		templatesToMerge[i] = filepath.Join(builtInTemplatesDir, t)
 	}
 
 	// add or override with local manifests
@@ -170,24 +255,3 @@ func renderConfig(params Params) (renderedConfigFiles map[string]string) {
 
 	return
 }
-
-func renderTemplate(tmpl *template.Template, templateData TemplateData) (bytes.Buffer, error) {
-
-	if tmpl == nil {
-		return bytes.Buffer{}, nil
-	}
-
-	// render templates
-	logInfo("Rendering merged templates...")
-	var renderedTemplate bytes.Buffer
-	err := tmpl.Execute(&renderedTemplate, templateData)
-	if err != nil {
-		return renderedTemplate, err
-	}
-
-	logInfo("Template after rendering:")
-	log.Println(renderedTemplate.String())
-	log.Println("")
-
-	return renderedTemplate, err
-}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -168,13 +169,247 @@ func TestGetTemplates(t *testing.T) {
 		assert.False(t, stringArrayContains(templates, "/templates/horizontalpodautoscaler.yaml"))
 		assert.False(t, stringArrayContains(templates, "/templates/poddisruptionbudget.yaml"))
 	})
+
+	t.Run("DoesNotIncludePodDisruptionBudgetIfDisabled", func(t *testing.T) {
+
+		falseValue := false
+		params := Params{
+			Kind:   "deployment",
+			Action: "deploy-stable",
+			PodDisruptionBudget: PodDisruptionBudgetParams{
+				Enabled: &falseValue,
+			},
+		}
+
+		// act
+		templates := getTemplates(params)
+
+		assert.True(t, stringArrayContains(templates, "/templates/horizontalpodautoscaler.yaml"))
+		assert.False(t, stringArrayContains(templates, "/templates/poddisruptionbudget.yaml"))
+	})
+
+	t.Run("IncludesNetworkPolicyIfEnabled", func(t *testing.T) {
+
+		params := Params{
+			Kind:   "job",
+			Action: "deploy-simple",
+			NetworkPolicy: NetworkPolicyParams{
+				Enabled: true,
+			},
+		}
+
+		// act
+		templates := getTemplates(params)
+
+		assert.True(t, stringArrayContains(templates, "/templates/networkpolicy.yaml"))
+	})
+
+	t.Run("DoesNotIncludeNetworkPolicyIfNotEnabled", func(t *testing.T) {
+
+		params := Params{
+			Kind:   "deployment",
+			Action: "deploy-simple",
+		}
+
+		// act
+		templates := getTemplates(params)
+
+		assert.False(t, stringArrayContains(templates, "/templates/networkpolicy.yaml"))
+	})
+
+	t.Run("IncludesCertificateSecretIfCertificateProviderIsLetsencrypt", func(t *testing.T) {
+
+		params := Params{
+			Kind:        "deployment",
+			Certificate: CertificateParams{Provider: "letsencrypt"},
+		}
+
+		// act
+		templates := getTemplates(params)
+
+		assert.True(t, stringArrayContains(templates, "/templates/certificate-secret.yaml"))
+		assert.False(t, stringArrayContains(templates, "/templates/certificate.yaml"))
+		assert.False(t, stringArrayContains(templates, "/templates/managedcertificate.yaml"))
+	})
+
+	t.Run("IncludesCertificateInsteadOfCertificateSecretIfCertificateProviderIsCertManager", func(t *testing.T) {
+
+		params := Params{
+			Kind:        "deployment",
+			Certificate: CertificateParams{Provider: "cert-manager"},
+		}
+
+		// act
+		templates := getTemplates(params)
+
+		assert.True(t, stringArrayContains(templates, "/templates/certificate.yaml"))
+		assert.False(t, stringArrayContains(templates, "/templates/certificate-secret.yaml"))
+	})
+
+	t.Run("IncludesManagedCertificateAndCertificateSecretIfCertificateProviderIsManagedWithoutSecretName", func(t *testing.T) {
+
+		params := Params{
+			Kind:        "deployment",
+			Visibility:  "iap",
+			Certificate: CertificateParams{Provider: "managed"},
+		}
+
+		// act
+		templates := getTemplates(params)
+
+		assert.True(t, stringArrayContains(templates, "/templates/managedcertificate.yaml"))
+		assert.True(t, stringArrayContains(templates, "/templates/certificate-secret.yaml"))
+	})
+
+	t.Run("DoesNotIncludeCertificateObjectsIfCertificateProviderIsSecret", func(t *testing.T) {
+
+		params := Params{
+			Kind:        "deployment",
+			Certificate: CertificateParams{Provider: "secret", SecretName: "myapp-ev-certificate"},
+		}
+
+		// act
+		templates := getTemplates(params)
+
+		assert.False(t, stringArrayContains(templates, "/templates/certificate-secret.yaml"))
+		assert.False(t, stringArrayContains(templates, "/templates/certificate.yaml"))
+		assert.False(t, stringArrayContains(templates, "/templates/managedcertificate.yaml"))
+	})
+
+	t.Run("IncludesIstioResourcesInsteadOfIngressesIfMeshTypeIsIstio", func(t *testing.T) {
+
+		params := Params{
+			Kind:          "deployment",
+			Visibility:    "private",
+			InternalHosts: []string{"ci.estafette.internal"},
+			Mesh:          MeshParams{Type: "istio"},
+		}
+
+		// act
+		templates := getTemplates(params)
+
+		assert.True(t, stringArrayContains(templates, "/templates/destinationrule.yaml"))
+		assert.True(t, stringArrayContains(templates, "/templates/virtualservice.yaml"))
+		assert.True(t, stringArrayContains(templates, "/templates/gateway.yaml"))
+		assert.False(t, stringArrayContains(templates, "/templates/ingress.yaml"))
+		assert.False(t, stringArrayContains(templates, "/templates/ingress-internal.yaml"))
+	})
+
+	t.Run("DoesNotIncludeGatewayIfMeshGatewayIsSet", func(t *testing.T) {
+
+		params := Params{
+			Kind: "deployment",
+			Mesh: MeshParams{Type: "istio", Gateway: "istio-system/public-gateway"},
+		}
+
+		// act
+		templates := getTemplates(params)
+
+		assert.False(t, stringArrayContains(templates, "/templates/gateway.yaml"))
+	})
+
+	t.Run("IncludesOnlyVirtualServiceForRollbackCanaryIfMeshTypeIsIstio", func(t *testing.T) {
+
+		params := Params{
+			Action: "rollback-canary",
+			Kind:   "deployment",
+			Mesh:   MeshParams{Type: "istio"},
+		}
+
+		// act
+		templates := getTemplates(params)
+
+		assert.Equal(t, []string{"/templates/virtualservice.yaml"}, templates)
+	})
+
+	t.Run("ReturnsStatefulSetWithHeadlessServiceIfKindIsStatefulSet", func(t *testing.T) {
+
+		params := Params{
+			Kind:       "statefulset",
+			Visibility: "private",
+		}
+
+		// act
+		templates := getTemplates(params)
+
+		assert.True(t, stringArrayContains(templates, "/templates/statefulset.yaml"))
+		assert.True(t, stringArrayContains(templates, "/templates/service-headless.yaml"))
+		assert.True(t, stringArrayContains(templates, "/templates/poddisruptionbudget.yaml"))
+		assert.False(t, stringArrayContains(templates, "/templates/deployment.yaml"))
+		assert.False(t, stringArrayContains(templates, "/templates/service.yaml"))
+		assert.False(t, stringArrayContains(templates, "/templates/ingress.yaml"))
+		assert.False(t, stringArrayContains(templates, "/templates/horizontalpodautoscaler.yaml"))
+	})
+
+	t.Run("ReturnsDaemonSetWithoutServiceIngressOrScalingIfKindIsDaemonSet", func(t *testing.T) {
+
+		params := Params{
+			Kind:       "daemonset",
+			Visibility: "private",
+		}
+
+		// act
+		templates := getTemplates(params)
+
+		assert.True(t, stringArrayContains(templates, "/templates/daemonset.yaml"))
+		assert.True(t, stringArrayContains(templates, "/templates/serviceaccount.yaml"))
+		assert.False(t, stringArrayContains(templates, "/templates/service.yaml"))
+		assert.False(t, stringArrayContains(templates, "/templates/ingress.yaml"))
+		assert.False(t, stringArrayContains(templates, "/templates/horizontalpodautoscaler.yaml"))
+		assert.False(t, stringArrayContains(templates, "/templates/poddisruptionbudget.yaml"))
+		assert.False(t, stringArrayContains(templates, "/templates/certificate-secret.yaml"))
+	})
+
+	t.Run("ReturnsCertificateSecretBeforeDeployment", func(t *testing.T) {
+
+		params := Params{
+			Kind:       "deployment",
+			Action:     "deploy-simple",
+			Visibility: "private",
+		}
+
+		// act
+		templates := getTemplates(params)
+
+		assert.Equal(t, []string{
+			"/templates/namespace.yaml",
+			"/templates/service.yaml",
+			"/templates/serviceaccount.yaml",
+			"/templates/certificate-secret.yaml",
+			"/templates/deployment.yaml",
+		}, templates[:5])
+	})
 }
 
-func stringArrayContains(array []string, search string) bool {
-	for _, v := range array {
-		if v == search {
-			return true
+func TestRenderManifests(t *testing.T) {
+
+	t.Run("JoinsBuiltInAndLocalManifests", func(t *testing.T) {
+
+		data := TemplateData{
+			Name:             "myapp",
+			Namespace:        "mynamespace",
+			AppLabelSelector: "myapp",
+			ServiceType:      "ClusterIP",
+			ManifestData: map[string]interface{}{
+				"property3": "value3",
+			},
 		}
-	}
-	return false
+
+		// act
+		renderedManifests, err := renderManifests([]string{"/templates/namespace.yaml", "override/service.yaml"}, data)
+
+		assert.Nil(t, err)
+		documents := strings.Split(renderedManifests.String(), "\n---\n")
+		assert.Equal(t, 2, len(documents))
+		assert.Equal(t, "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: mynamespace", documents[0])
+		assert.True(t, strings.Contains(documents[1], "anotherlabel: value3"))
+	})
+
+	t.Run("ReturnsErrorIfLocalManifestDoesNotExist", func(t *testing.T) {
+
+		// act
+		_, err := renderManifests([]string{"./gke/another-ingress.yaml"}, TemplateData{})
+
+		assert.NotNil(t, err)
+	})
 }
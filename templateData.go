@@ -17,24 +17,58 @@ type TemplateData struct {
 	AllHostsJoined                      string
 	IngressPath                         string
 	InternalIngressPath                 string
+	Routes                              []RouteData
 	UseIngress                          bool
 	UseNginxIngress                     bool
 	UseGCEIngress                       bool
+	UseInternalIngress                  bool
 	UseDNSAnnotationsOnIngress          bool
 	UseDNSAnnotationsOnService          bool
+	PublicDNSProvider                   string
+	PublicDNSAnnotations                map[string]string
+	InternalDNSProvider                 string
+	InternalDNSAnnotations              map[string]string
 	UseBackendConfigAnnotationOnService bool
 	ServiceType                         string
+	NginxIngressBackendProtocol         string
+	ServiceAppProtocol                  string
+	CertificateProvider                 string
+	CertificateSecretName               string
+	CertificateSecretHasTLSKeys         bool
+	CertManagerIssuer                   string
+	CertManagerIssuerKind               string
 	MinReplicas                         int
 	MaxReplicas                         int
 	TargetCPUPercentage                 int
+	TargetMemoryPercentage              int
+	AutoscaleMetrics                    []AutoscaleMetricData
+	AutoscaleScaleUp                    *AutoscaleScalingRulesData
+	AutoscaleScaleDown                  *AutoscaleScalingRulesData
+	PodDisruptionBudgetMinAvailable     string
+	PodDisruptionBudgetMaxUnavailable   string
+	StatefulSet                         StatefulSetData
+	DaemonSet                           DaemonSetData
+	UseIstio                            bool
+	Mesh                                MeshData
+	UseNetworkPolicy                    bool
+	NetworkPolicy                       NetworkPolicyData
 	UseHpaScaler                        bool
 	HpaScalerPromQuery                  string
 	HpaScalerRequestsPerReplica         string
 	HpaScalerDelta                      string
 	HpaScalerScaleDownMaxRatio          string
 	PreferPreemptibles                  bool
+	NodeSelector                        map[string]string
+	Tolerations                         []TolerationData
+	TopologySpreadConstraints           []TopologySpreadConstraintData
+	AntiAffinityType                    string
+	AntiAffinityTopologyKey             string
+	AntiAffinityWeight                  int
+	PriorityClassName                   string
+	PodSecurityContext                  PodSecurityContextData
 	Container                           ContainerData
 	Sidecars                            []SidecarData
+	InitContainers                      []InitContainerData
 	MountApplicationSecrets             bool
 	Secrets                             map[string]interface{}
 	SecretMountPath                     string
@@ -48,6 +82,10 @@ type TemplateData struct {
 	RollingUpdateMaxSurge               string
 	RollingUpdateMaxUnavailable         string
 	BuildVersion                        string
+	IngressAPIVersion                   string
+	PodDisruptionBudgetAPIVersion       string
+	CronJobAPIVersion                   string
+	HorizontalPodAutoscalerAPIVersion   string
 	LimitTrustedIPRanges                bool
 	TrustedIPRanges                     []string
 	ManifestData                        map[string]interface{}
@@ -71,12 +109,62 @@ type TemplateData struct {
 	NginxIngressProxyBodySize           string
 	NginxIngressProxyBufferSize         string
 	NginxIngressProxyBuffersNumber      string
+	NginxIngressFeatureAnnotations      map[string]string
+	IngressCustomAnnotations            map[string]string
 	IncludeReplicas                     bool
 	Replicas                            int
 	IapOauthCredentialsClientID         string
 	IapOauthCredentialsClientSecret     string
 }
 
+// StatefulSetData has data specific to kind statefulset
+type StatefulSetData struct {
+	ServiceName          string
+	Replicas             int
+	PodManagementPolicy  string
+	Partition            *int
+	VolumeClaimTemplates []VolumeClaimTemplateData
+}
+
+// VolumeClaimTemplateData has data for a persistent volume claimed for each pod of a statefulset
+type VolumeClaimTemplateData struct {
+	Name         string
+	MountPath    string
+	Size         string
+	StorageClass string
+	AccessModes  []string
+}
+
+// DaemonSetData has data specific to kind daemonset
+type DaemonSetData struct {
+	HostNetwork     bool
+	UpdateStrategy  string
+	MaxUnavailable  string
+	HostPathVolumes []HostPathVolumeData
+}
+
+// HostPathVolumeData has data for a file or directory of the node mounted into the application container
+type HostPathVolumeData struct {
+	Name      string
+	HostPath  string
+	MountPath string
+	Type      string
+	ReadOnly  bool
+}
+
+// MeshData has data for the istio gateway, virtual service and destination rule
+type MeshData struct {
+	Gateways        []string
+	RenderGateway   bool
+	GatewaySelector map[string]string
+	UseSubsets      bool
+	CanaryWeight    int
+	ServicePortName string
+	Timeout         string
+	Retries         *int
+	RetryOn         string
+}
+
 // ContainerData has data specific to the application container
 type ContainerData struct {
 	Repository                      string
@@ -88,19 +176,98 @@ type ContainerData struct {
 	MemoryLimit                     string
 	Port                            int
 	EnvironmentVariables            map[string]interface{}
+	Command                         []string
+	Args                            []string
 	Liveness                        ProbeData
 	Readiness                       ProbeData
+	Startup                         ProbeData
 	Metrics                         MetricsData
 	UseLifecyclePreStopSleepCommand bool
 	PreStopSleepSeconds             int
+	SecurityContext                 ContainerSecurityContextData
+}
+
+// EnvironmentVariableSourceData sets an environment variable from a secret, configmap or pod field instead of a literal value
+type EnvironmentVariableSourceData struct {
+	ValueFrom     map[string]interface{}
+	ValueFromYAML string
+}
+
+// AutoscaleMetricData has data for a pods, object or external metric to scale on
+type AutoscaleMetricData struct {
+	Type             string
+	Name             string
+	Selector         map[string]string
+	Target           string
+	TargetType       string
+	ObjectAPIVersion string
+	ObjectKind       string
+	ObjectName       string
+}
+
+// AutoscaleScalingRulesData has data for the scale up or scale down behavior of the autoscaler
+type AutoscaleScalingRulesData struct {
+	StabilizationWindowSeconds *int
+	SelectPolicy               string
+	Policies                   []AutoscalingPolicyData
+}
+
+// AutoscalingPolicyData has data for a single scaling policy
+type AutoscalingPolicyData struct {
+	Type          string
+	Value         int
+	PeriodSeconds int
+}
+
+// TolerationData has data for a toleration of a node taint
+type TolerationData struct {
+	Key               string
+	Operator          string
+	Value             string
+	Effect            string
+	TolerationSeconds *int
 }
 
-// ProbeData has data specific to liveness and readiness probes
+// TopologySpreadConstraintData has data for spreading pods across topology domains
+type TopologySpreadConstraintData struct {
+	TopologyKey       string
+	MaxSkew           int
+	WhenUnsatisfiable string
+}
+
+// PodSecurityContextData has the security context shared by all containers in the pod
+type PodSecurityContextData struct {
+	RunAsNonRoot   *bool
+	RunAsUser      *int
+	RunAsGroup     *int
+	FSGroup        *int
+	SeccompProfile string
+}
+
+// ContainerSecurityContextData has the security context of a single container
+type ContainerSecurityContextData struct {
+	RunAsNonRoot             *bool
+	RunAsUser                *int
+	RunAsGroup               *int
+	ReadOnlyRootFilesystem   *bool
+	AllowPrivilegeEscalation *bool
+	DropCapabilities         []string
+	AddCapabilities          []string
+}
+
+// ProbeData has data specific to liveness, readiness and startup probes
 type ProbeData struct {
+	Type                string
 	Path                string
 	Port                int
+	Scheme              string
+	Command             []string
+	Service             string
 	InitialDelaySeconds int
 	TimeoutSeconds      int
+	PeriodSeconds       int
+	FailureThreshold    int
+	SuccessThreshold    int
 	IncludeOnContainer  bool
 }
 
@@ -111,6 +278,47 @@ type MetricsData struct {
 	Port   int
 }
 
+// RouteData routes a path on a set of hosts to a port of a service; an empty set of hosts applies to all hosts
+type RouteData struct {
+	Hosts        []string
+	Path         string
+	InternalPath string
+	ServiceName  string
+	ServicePort  string
+}
+
+// NetworkPolicyData has the sources the pods accept traffic from and the destinations they can send traffic to
+type NetworkPolicyData struct {
+	AllowAllIngress     bool
+	IngressNamespaces   []string
+	IngressIPBlocks     []string
+	PrometheusNamespace string
+	MetricsPorts        []int
+	AllowedClients      []NetworkPolicyPeerData
+	RestrictEgress      bool
+	Egress              []NetworkPolicyEgressData
+}
+
+// NetworkPolicyPeerData selects pods by app label and/or namespace
+type NetworkPolicyPeerData struct {
+	App       string
+	Namespace string
+}
+
+// NetworkPolicyEgressData is a destination the pods are allowed to send traffic to
+type NetworkPolicyEgressData struct {
+	App       string
+	Namespace string
+	CIDR      string
+	Ports     []NetworkPolicyPortData
+}
+
+// NetworkPolicyPortData is a port with its protocol
+type NetworkPolicyPortData struct {
+	Port     int
+	Protocol string
+}
+
 // SidecarData configures the injected sidecar
 type SidecarData struct {
 	Type                      string
@@ -121,12 +329,28 @@ type SidecarData struct {
 	CPULimit                  string
 	MemoryLimit               string
 	SidecarSpecificProperties map[string]interface{}
+	SecurityContext           ContainerSecurityContextData
+}
+
+// InitContainerData has data for an init container that runs before the application container
+type InitContainerData struct {
+	Name                 string
+	Image                string
+	Command              []string
+	Args                 []string
+	EnvironmentVariables map[string]interface{}
+	CPURequest           string
+	MemoryRequest        string
+	CPULimit             string
+	MemoryLimit          string
+	SecurityContext      ContainerSecurityContextData
 }
 
 // VolumeMountData configures additional volume mounts for shared secrets, existing volumes, etc
 type VolumeMountData struct {
 	Name       string
 	MountPath  string
+	Volume     map[string]interface{}
 	VolumeYAML string
 }
 
@@ -11,8 +11,14 @@ import (
 
 func generateTemplateData(params Params, currentReplicas int, releaseID, triggeredBy string) TemplateData {
 
+	apiVersions := getAPIVersions(params.ClusterVersion)
+
 	data := TemplateData{
-		BuildVersion: params.BuildVersion,
+		BuildVersion:                      params.BuildVersion,
+		IngressAPIVersion:                 apiVersions.Ingress,
+		PodDisruptionBudgetAPIVersion:     apiVersions.PodDisruptionBudget,
+		CronJobAPIVersion:                 apiVersions.CronJob,
+		HorizontalPodAutoscalerAPIVersion: apiVersions.HorizontalPodAutoscaler,
 
 		Name:              params.App,
 		NameWithTrack:     params.App,
@@ -37,6 +43,13 @@ func generateTemplateData(params Params, currentReplicas int, releaseID, trigger
 		MaxReplicas:         params.Autoscale.MaxReplicas,
 		TargetCPUPercentage: params.Autoscale.CPUPercentage,
 
+		TargetMemoryPercentage: params.Autoscale.MemoryPercentage,
+		AutoscaleScaleUp:       buildAutoscaleScalingRulesData(params.Autoscale.Behavior.ScaleUp),
+		AutoscaleScaleDown:     buildAutoscaleScalingRulesData(params.Autoscale.Behavior.ScaleDown),
+
+		PodDisruptionBudgetMinAvailable:   params.PodDisruptionBudget.MinAvailable,
+		PodDisruptionBudgetMaxUnavailable: params.PodDisruptionBudget.MaxUnavailable,
+
 		UseHpaScaler:                params.Autoscale.Safety.Enabled,
 		HpaScalerPromQuery:          params.Autoscale.Safety.PromQuery,
 		HpaScalerRequestsPerReplica: fmt.Sprintf("%.3f", params.Autoscale.Safety.Ratio),
@@ -55,7 +68,19 @@ func generateTemplateData(params Params, currentReplicas int, releaseID, trigger
 		RollingUpdateMaxSurge:       params.RollingUpdate.MaxSurge,
 		RollingUpdateMaxUnavailable: params.RollingUpdate.MaxUnavailable,
 
-		PreferPreemptibles:               params.ChaosProof,
+		PreferPreemptibles:      params.ChaosProof,
+		NodeSelector:            params.Scheduling.NodeSelector,
+		AntiAffinityType:        params.Scheduling.AntiAffinity.Type,
+		AntiAffinityTopologyKey: params.Scheduling.AntiAffinity.TopologyKey,
+		AntiAffinityWeight:      params.Scheduling.AntiAffinity.Weight,
+		PriorityClassName:       params.Scheduling.PriorityClassName,
+		PodSecurityContext: PodSecurityContextData{
+			RunAsNonRoot:   params.SecurityContext.Pod.RunAsNonRoot,
+			RunAsUser:      params.SecurityContext.Pod.RunAsUser,
+			RunAsGroup:     params.SecurityContext.Pod.RunAsGroup,
+			FSGroup:        params.SecurityContext.Pod.FSGroup,
+			SeccompProfile: params.SecurityContext.Pod.SeccompProfile,
+		},
 		MountServiceAccountSecret:        params.UseGoogleCloudCredentials,
 		GoogleCloudCredentialsAppName:    params.GoogleCloudCredentialsApp,
 		DisableServiceAccountKeyRotation: params.DisableServiceAccountKeyRotation,
@@ -72,21 +97,12 @@ func generateTemplateData(params Params, currentReplicas int, releaseID, trigger
 			MemoryLimit:   params.Container.Memory.Limit,
 
 			EnvironmentVariables: params.Container.EnvironmentVariables,
+			Command:              params.Container.Command,
+			Args:                 params.Container.Args,
+			SecurityContext:      buildContainerSecurityContextData(params.SecurityContext.Container),
 
-			Liveness: ProbeData{
-				Path:                params.Container.LivenessProbe.Path,
-				Port:                params.Container.LivenessProbe.Port,
-				InitialDelaySeconds: params.Container.LivenessProbe.InitialDelaySeconds,
-				TimeoutSeconds:      params.Container.LivenessProbe.TimeoutSeconds,
-				IncludeOnContainer:  true,
-			},
-			Readiness: ProbeData{
-				Path:                params.Container.ReadinessProbe.Path,
-				Port:                params.Container.ReadinessProbe.Port,
-				InitialDelaySeconds: params.Container.ReadinessProbe.InitialDelaySeconds,
-				TimeoutSeconds:      params.Container.ReadinessProbe.TimeoutSeconds,
-				IncludeOnContainer:  params.Sidecar.Type != "openresty" || params.Container.ReadinessProbe.Port != params.Container.Port || params.Container.ReadinessProbe.Path != params.Sidecar.HealthCheckPath,
-			},
+			Liveness:  buildProbeData(params.Container.LivenessProbe, true),
+			Readiness: buildProbeData(params.Container.ReadinessProbe, !isHTTPProbe(params.Container.ReadinessProbe) || params.Sidecar.Type != "openresty" || params.Container.ReadinessProbe.Port != params.Container.Port || params.Container.ReadinessProbe.Path != params.Sidecar.HealthCheckPath),
 			Metrics: MetricsData{
 				Path: params.Container.Metrics.Path,
 				Port: params.Container.Metrics.Port,
@@ -94,6 +110,10 @@ func generateTemplateData(params Params, currentReplicas int, releaseID, trigger
 		},
 	}
 
+	if params.Container.StartupProbe != nil {
+		data.Container.Startup = buildProbeData(*params.Container.StartupProbe, true)
+	}
+
 	if params.UseGoogleCloudCredentials {
 		data.Container.EnvironmentVariables = addEnvironmentVariableIfNotSet(data.Container.EnvironmentVariables, "GOOGLE_APPLICATION_CREDENTIALS", "/gcp-service-account/service-account-key.json")
 	}
@@ -115,6 +135,39 @@ func generateTemplateData(params Params, currentReplicas int, releaseID, trigger
 		data.Sidecars = append(data.Sidecars, sidecar)
 	}
 
+	for _, m := range params.Autoscale.Metrics {
+		data.AutoscaleMetrics = append(data.AutoscaleMetrics, AutoscaleMetricData{
+			Type:             m.Type,
+			Name:             m.Name,
+			Selector:         m.Selector,
+			Target:           m.Target,
+			TargetType:       m.TargetType,
+			ObjectAPIVersion: m.Object.APIVersion,
+			ObjectKind:       m.Object.Kind,
+			ObjectName:       m.Object.Name,
+		})
+	}
+
+	// init containers run the application image unless set otherwise, so it can run a different command like a database migration
+	for _, ic := range params.InitContainers {
+		image := ic.Image
+		if image == "" {
+			image = fmt.Sprintf("%v/%v:%v", params.Container.ImageRepository, params.Container.ImageName, params.Container.ImageTag)
+		}
+		data.InitContainers = append(data.InitContainers, InitContainerData{
+			Name:                 ic.Name,
+			Image:                image,
+			Command:              ic.Command,
+			Args:                 ic.Args,
+			EnvironmentVariables: ic.EnvironmentVariables,
+			CPURequest:           ic.CPU.Request,
+			CPULimit:             ic.CPU.Limit,
+			MemoryRequest:        ic.Memory.Request,
+			MemoryLimit:          ic.Memory.Limit,
+			SecurityContext:      buildContainerSecurityContextData(ic.SecurityContext),
+		})
+	}
+
 	// set request params on the nginx ingress
 	requestTimeout, requestTimeoutConvertError := strconv.Atoi(strings.Trim(params.Request.Timeout, "s"))
 
@@ -137,6 +190,27 @@ func generateTemplateData(params Params, currentReplicas int, releaseID, trigger
 	data.NginxIngressClientBodyBufferSize = params.Request.ClientBodyBufferSize
 	data.NginxIngressProxyBufferSize = params.Request.ProxyBufferSize
 	data.NginxIngressProxyBuffersNumber = strconv.Itoa(params.Request.ProxyBuffersNumber)
+	// the openresty sidecar terminates tls, so the ingress and load balancer always talk https to it, with http2 for grpc
+	switch params.Protocol {
+	case "grpc":
+		data.NginxIngressBackendProtocol = "GRPCS"
+		data.ServiceAppProtocol = "HTTP2"
+	case "http2":
+		data.NginxIngressBackendProtocol = "HTTPS"
+		data.ServiceAppProtocol = "HTTP2"
+	default:
+		data.NginxIngressBackendProtocol = "HTTPS"
+		data.ServiceAppProtocol = "HTTPS"
+	}
+	if params.Protocol == "http2" || params.Protocol == "grpc" {
+		for i := range data.Sidecars {
+			if data.Sidecars[i].Type == "openresty" {
+				data.Sidecars[i].EnvironmentVariables = addEnvironmentVariableIfNotSet(data.Sidecars[i].EnvironmentVariables, "OFFLOAD_PROTOCOL", params.Protocol)
+			}
+		}
+	}
+	data.NginxIngressFeatureAnnotations = buildNginxIngressFeatureAnnotations(params.Ingress)
+	data.IngressCustomAnnotations = params.Ingress.Annotations
 
 	if params.Container.Metrics.Scrape != nil {
 		data.Container.Metrics.Scrape = *params.Container.Metrics.Scrape
@@ -228,14 +302,69 @@ func generateTemplateData(params Params, currentReplicas int, releaseID, trigger
 		data.OverrideDefaultWhitelist = false
 	}
 
-	if !strings.HasSuffix(data.IngressPath, "/") && !strings.HasSuffix(data.IngressPath, "*") {
-		data.IngressPath += "/"
+	data.UseInternalIngress = len(params.InternalHosts) > 0
+
+	data.PublicDNSProvider, data.PublicDNSAnnotations = buildDNSAnnotations(params.DNS.Public, data.HostsJoined, true)
+	data.InternalDNSProvider, data.InternalDNSAnnotations = buildDNSAnnotations(params.DNS.Internal, data.InternalHostsJoined, false)
+
+	// the certificate secret is used by the openresty sidecar and the ingresses, except for the gce ingress with a managed certificate;
+	// secrets other than the estafette letsencrypt one are of type kubernetes.io/tls with keys tls.crt and tls.key
+	data.CertificateProvider = params.Certificate.Provider
+	data.CertificateSecretName = fmt.Sprintf("%v-letsencrypt-certificate", params.App)
+	switch params.Certificate.Provider {
+	case "cert-manager":
+		data.CertificateSecretName = fmt.Sprintf("%v-certificate", params.App)
+		data.CertificateSecretHasTLSKeys = true
+		data.CertManagerIssuer = params.Certificate.Issuer
+		data.CertManagerIssuerKind = params.Certificate.IssuerKind
+	case "managed", "secret":
+		if params.Certificate.SecretName != "" {
+			data.CertificateSecretName = params.Certificate.SecretName
+			data.CertificateSecretHasTLSKeys = true
+		}
 	}
-	if data.UseGCEIngress && !strings.HasSuffix(data.IngressPath, "*") {
-		data.IngressPath += "*"
+
+	if params.Kind == "statefulset" {
+		data.StatefulSet = buildStatefulSetData(params)
 	}
-	if !strings.HasSuffix(data.InternalIngressPath, "/") && !strings.HasSuffix(data.InternalIngressPath, "*") {
-		data.InternalIngressPath += "/"
+	if params.Kind == "daemonset" {
+		data.DaemonSet = buildDaemonSetData(params)
+
+		// an operator Exists toleration without key tolerates every taint, so the node agent runs on tainted node pools as well
+		if params.DaemonSet.TolerateAllTaints {
+			data.Tolerations = append(data.Tolerations, TolerationData{Operator: "Exists"})
+		}
+	}
+
+	// with istio the hosts are routed by the mesh instead of an ingress or load balancer
+	if params.Mesh.Type == "istio" && params.Kind == "deployment" {
+		data.UseIstio = true
+		data.ServiceType = "ClusterIP"
+		data.UseNginxIngress = false
+		data.UseGCEIngress = false
+		data.UseInternalIngress = false
+		data.UseDNSAnnotationsOnIngress = false
+		data.UseDNSAnnotationsOnService = false
+		data.UseBackendConfigAnnotationOnService = false
+		data.LimitTrustedIPRanges = false
+		data.Mesh = buildMeshData(params)
+	}
+
+	data.IngressPath = buildIngressPath(data.IngressPath, data.UseGCEIngress)
+	data.InternalIngressPath = buildIngressPath(data.InternalIngressPath, false)
+
+	for _, route := range params.Routes {
+		routeData := RouteData{
+			Hosts:        route.Hosts,
+			Path:         buildIngressPath(route.Path, data.UseGCEIngress),
+			InternalPath: buildIngressPath(route.Path, false),
+			ServiceName:  route.Service,
+			ServicePort:  route.Port,
+		}
+		if routeData.ServiceName == "" {
+			routeData.ServiceName = data.Name
+		}
+		data.Routes = append(data.Routes, routeData)
 	}
 
 	data.TrustedIPRanges = params.TrustedIPRanges
@@ -247,12 +376,30 @@ func generateTemplateData(params Params, currentReplicas int, releaseID, trigger
 			data.AdditionalVolumeMounts = append(data.AdditionalVolumeMounts, VolumeMountData{
 				Name:       vm.Name,
 				MountPath:  vm.MountPath,
+				Volume:     vm.Volume,
 				VolumeYAML: string(yamlBytes),
 			})
 		}
 	}
 	data.MountAdditionalVolumes = len(data.AdditionalVolumeMounts) > 0
 
+	for _, t := range params.Scheduling.Tolerations {
+		data.Tolerations = append(data.Tolerations, TolerationData{
+			Key:               t.Key,
+			Operator:          t.Operator,
+			Value:             t.Value,
+			Effect:            t.Effect,
+			TolerationSeconds: t.TolerationSeconds,
+		})
+	}
+	for _, tsc := range params.Scheduling.TopologySpreadConstraints {
+		data.TopologySpreadConstraints = append(data.TopologySpreadConstraints, TopologySpreadConstraintData{
+			TopologyKey:       tsc.TopologyKey,
+			MaxSkew:           tsc.MaxSkew,
+			WhenUnsatisfiable: tsc.WhenUnsatisfiable,
+		})
+	}
+
 	data.AdditionalContainerPorts = []AdditionalPortData{}
 	data.AdditionalServicePorts = []AdditionalPortData{}
 	for _, ap := range params.Container.AdditionalPorts {
@@ -270,9 +417,314 @@ func generateTemplateData(params Params, currentReplicas int, releaseID, trigger
 		}
 	}
 
+	if params.NetworkPolicy.Enabled {
+		data.UseNetworkPolicy = true
+		data.NetworkPolicy = buildNetworkPolicyData(params, data)
+	}
+
+	// environment variables can reference the application secrets, so this has to happen after the name with track is known
+	data.Container.EnvironmentVariables = buildEnvironmentVariables(data.Container.EnvironmentVariables, data.NameWithTrack)
+	for i := range data.Sidecars {
+		data.Sidecars[i].EnvironmentVariables = buildEnvironmentVariables(data.Sidecars[i].EnvironmentVariables, data.NameWithTrack)
+	}
+	for i := range data.InitContainers {
+		data.InitContainers[i].EnvironmentVariables = buildEnvironmentVariables(data.InitContainers[i].EnvironmentVariables, data.NameWithTrack)
+	}
+
+	return data
+}
+
+// buildNetworkPolicyData derives the allowed traffic sources from the visibility, so the ingress controller or load balancer can reach
+// the pods, and adds prometheus for the metrics ports and the explicitly declared clients and egress destinations
+func buildNetworkPolicyData(params Params, data TemplateData) NetworkPolicyData {
+
+	networkPolicy := NetworkPolicyData{
+		PrometheusNamespace: params.NetworkPolicy.PrometheusNamespace,
+		RestrictEgress:      len(params.NetworkPolicy.Egress) > 0,
+	}
+
+	// jobs, cronjobs, statefulsets and daemonsets don't receive traffic via an ingress or load balancer, so visibility doesn't apply to them
+	if data.UseIstio {
+		networkPolicy.IngressNamespaces = []string{params.Mesh.GatewayNamespace}
+	} else if params.Kind != "job" && params.Kind != "cronjob" && params.Kind != "statefulset" && params.Kind != "daemonset" {
+		if data.UseNginxIngress || data.UseInternalIngress {
+			networkPolicy.IngressNamespaces = params.NetworkPolicy.IngressControllerNamespaces
+		}
+		if data.UseGCEIngress {
+			networkPolicy.IngressIPBlocks = params.NetworkPolicy.GCELoadBalancerRanges
+		}
+		if data.ServiceType == "LoadBalancer" {
+			networkPolicy.AllowAllIngress = true
+		}
+	}
+
+	if data.Container.Metrics.Scrape && data.Container.Metrics.Port > 0 {
+		networkPolicy.MetricsPorts = append(networkPolicy.MetricsPorts, data.Container.Metrics.Port)
+	}
+	for _, sidecar := range data.Sidecars {
+		if sidecar.Type == "openresty" {
+			networkPolicy.MetricsPorts = append(networkPolicy.MetricsPorts, 9101)
+			break
+		}
+	}
+
+	for _, client := range params.NetworkPolicy.AllowedClients {
+		networkPolicy.AllowedClients = append(networkPolicy.AllowedClients, NetworkPolicyPeerData{
+			App:       client.App,
+			Namespace: client.Namespace,
+		})
+	}
+
+	for _, egress := range params.NetworkPolicy.Egress {
+		egressData := NetworkPolicyEgressData{
+			App:       egress.App,
+			Namespace: egress.Namespace,
+			CIDR:      egress.CIDR,
+		}
+		for _, port := range egress.Ports {
+			egressData.Ports = append(egressData.Ports, NetworkPolicyPortData{Port: port.Port, Protocol: port.Protocol})
+		}
+		networkPolicy.Egress = append(networkPolicy.Egress, egressData)
+	}
+
+	return networkPolicy
+}
+
+// buildStatefulSetData has the statefulset use a headless service named after the app, so it doesn't clash with the service of a deployment
+// with the same name
+func buildStatefulSetData(params Params) StatefulSetData {
+
+	statefulSet := StatefulSetData{
+		ServiceName:         fmt.Sprintf("%v-headless", params.App),
+		Replicas:            params.StatefulSet.Replicas,
+		PodManagementPolicy: params.StatefulSet.PodManagementPolicy,
+		Partition:           params.StatefulSet.Partition,
+	}
+	for _, claim := range params.StatefulSet.VolumeClaimTemplates {
+		statefulSet.VolumeClaimTemplates = append(statefulSet.VolumeClaimTemplates, VolumeClaimTemplateData{
+			Name:         claim.Name,
+			MountPath:    claim.MountPath,
+			Size:         claim.Size,
+			StorageClass: claim.StorageClass,
+			AccessModes:  claim.AccessModes,
+		})
+	}
+
+	return statefulSet
+}
+
+func buildDaemonSetData(params Params) DaemonSetData {
+
+	daemonSet := DaemonSetData{
+		HostNetwork:    params.DaemonSet.HostNetwork,
+		UpdateStrategy: params.DaemonSet.UpdateStrategy,
+		MaxUnavailable: params.DaemonSet.MaxUnavailable,
+	}
+	for _, volume := range params.DaemonSet.HostPathVolumes {
+		daemonSet.HostPathVolumes = append(daemonSet.HostPathVolumes, HostPathVolumeData{
+			Name:      volume.Name,
+			HostPath:  volume.HostPath,
+			MountPath: volume.MountPath,
+			Type:      volume.Type,
+			ReadOnly:  volume.ReadOnly,
+		})
+	}
+
+	return daemonSet
+}
+
+// buildMeshData binds the virtual service to the configured gateway or one rendered for the app; canary and stable releases route to
+// subsets by track, which get no canary traffic until the canary deployment is rolled out
+func buildMeshData(params Params) MeshData {
+
+	mesh := MeshData{
+		Gateways:        []string{params.App},
+		RenderGateway:   params.Mesh.Gateway == "",
+		GatewaySelector: params.Mesh.GatewaySelector,
+		UseSubsets:      params.Action == "deploy-canary" || params.Action == "deploy-stable" || params.Action == "rollback-canary",
+		ServicePortName: "http",
+		Timeout:         params.Request.Timeout,
+		Retries:         params.Request.Retries,
+		RetryOn:         params.Request.RetryOn,
+	}
+	if params.Mesh.Gateway != "" {
+		mesh.Gateways = []string{params.Mesh.Gateway}
+	}
+
+	// istio picks the protocol from the service port name
+	switch params.Protocol {
+	case "http2", "grpc":
+		mesh.ServicePortName = params.Protocol
+	}
+
+	return mesh
+}
+
+// dnsProviderAnnotations has the annotations set for and by each dns provider's controller, so the ones of unused providers can be removed
+var dnsProviderAnnotations = map[string][]string{
+	"cloudflare":       {"estafette.io/cloudflare-dns", "estafette.io/cloudflare-proxy", "estafette.io/cloudflare-hostnames", "estafette.io/cloudflare-state"},
+	"google-cloud-dns": {"estafette.io/google-cloud-dns", "estafette.io/google-cloud-dns-hostnames", "estafette.io/google-cloud-dns-state"},
+	"external-dns":     {"external-dns.alpha.kubernetes.io/hostname"},
+}
+
+// buildDNSAnnotations returns the provider and the annotations that have its controller create dns records for the hosts; without provider
+// it's cloudflare, proxied depending on the host group
+func buildDNSAnnotations(dns DNSProviderParams, hostsJoined string, defaultProxy bool) (string, map[string]string) {
+
+	switch dns.Provider {
+	case "google-cloud-dns":
+		return dns.Provider, map[string]string{
+			"estafette.io/google-cloud-dns":           "true",
+			"estafette.io/google-cloud-dns-hostnames": hostsJoined,
+		}
+	case "external-dns":
+		return dns.Provider, map[string]string{
+			"external-dns.alpha.kubernetes.io/hostname": hostsJoined,
+		}
+	case "none":
+		return dns.Provider, map[string]string{}
+	}
+
+	proxy := defaultProxy
+	if dns.Proxy != nil {
+		proxy = *dns.Proxy
+	}
+
+	return "cloudflare", map[string]string{
+		"estafette.io/cloudflare-dns":       "true",
+		"estafette.io/cloudflare-proxy":     strconv.FormatBool(proxy),
+		"estafette.io/cloudflare-hostnames": hostsJoined,
+	}
+}
+
+// buildNginxIngressFeatureAnnotations translates the ingress params into nginx ingress annotations, leaving out the ones that aren't set
+func buildNginxIngressFeatureAnnotations(ingress IngressParams) map[string]string {
+
+	annotations := map[string]string{}
+	setIfNotEmpty := func(key, value string) {
+		if value != "" {
+			annotations["nginx.ingress.kubernetes.io/"+key] = value
+		}
+	}
+	setIfPositive := func(key string, value int) {
+		if value > 0 {
+			annotations["nginx.ingress.kubernetes.io/"+key] = strconv.Itoa(value)
+		}
+	}
+
+	if ingress.CORS.Enabled {
+		setIfNotEmpty("enable-cors", "true")
+		setIfNotEmpty("cors-allow-origin", ingress.CORS.AllowOrigin)
+		setIfNotEmpty("cors-allow-methods", ingress.CORS.AllowMethods)
+		setIfNotEmpty("cors-allow-headers", ingress.CORS.AllowHeaders)
+		if ingress.CORS.AllowCredentials != nil {
+			setIfNotEmpty("cors-allow-credentials", strconv.FormatBool(*ingress.CORS.AllowCredentials))
+		}
+		setIfPositive("cors-max-age", ingress.CORS.MaxAge)
+	}
+
+	setIfPositive("limit-rps", ingress.RateLimit.RPS)
+	setIfPositive("limit-rpm", ingress.RateLimit.RPM)
+	setIfPositive("limit-connections", ingress.RateLimit.Connections)
+	setIfPositive("limit-burst-multiplier", ingress.RateLimit.BurstMultiplier)
+	setIfNotEmpty("limit-whitelist", strings.Join(ingress.RateLimit.Whitelist, ","))
+
+	switch ingress.Auth.Type {
+	case "basic":
+		setIfNotEmpty("auth-type", "basic")
+		setIfNotEmpty("auth-secret", ingress.Auth.Secret)
+		setIfNotEmpty("auth-realm", ingress.Auth.Realm)
+	case "external":
+		setIfNotEmpty("auth-url", ingress.Auth.URL)
+		setIfNotEmpty("auth-signin", ingress.Auth.SigninURL)
+		setIfNotEmpty("auth-response-headers", strings.Join(ingress.Auth.ResponseHeaders, ","))
+	}
+
+	setIfNotEmpty("server-snippet", ingress.ServerSnippet)
+	if ingress.HTTPSRedirect != nil {
+		setIfNotEmpty("ssl-redirect", strconv.FormatBool(*ingress.HTTPSRedirect))
+	}
+
+	customErrors := []string{}
+	for _, code := range ingress.CustomErrors {
+		customErrors = append(customErrors, strconv.Itoa(code))
+	}
+	setIfNotEmpty("custom-http-errors", strings.Join(customErrors, ","))
+	setIfNotEmpty("default-backend", ingress.DefaultBackend)
+
+	return annotations
+}
+
+// buildIngressPath ensures the path matches all paths below it; for the gce ingress controller that requires a wildcard
+func buildIngressPath(path string, useGCEIngress bool) string {
+	if !strings.HasSuffix(path, "/") && !strings.HasSuffix(path, "*") {
+		path += "/"
+	}
+	if useGCEIngress && !strings.HasSuffix(path, "*") {
+		path += "*"
+	}
+	return path
+}
+
+func buildProbeData(probe ProbeParams, includeOnContainer bool) ProbeData {
+	probeType := probe.Type
+	if probeType == "" {
+		probeType = "http"
+	}
+
+	return ProbeData{
+		Type:                probeType,
+		Path:                probe.Path,
+		Port:                probe.Port,
+		Scheme:              probe.Scheme,
+		Command:             probe.Command,
+		Service:             probe.Service,
+		InitialDelaySeconds: probe.InitialDelaySeconds,
+		TimeoutSeconds:      probe.TimeoutSeconds,
+		PeriodSeconds:       probe.PeriodSeconds,
+		FailureThreshold:    probe.FailureThreshold,
+		SuccessThreshold:    probe.SuccessThreshold,
+		IncludeOnContainer:  includeOnContainer,
+	}
+}
+
+// isHTTPProbe returns whether the probe does an http get, which is the only type the openresty sidecar can proxy
+func isHTTPProbe(probe ProbeParams) bool {
+	return probe.Type == "" || probe.Type == "http"
+}
+
+func buildAutoscaleScalingRulesData(rules *AutoscaleScalingRulesParams) *AutoscaleScalingRulesData {
+	if rules == nil {
+		return nil
+	}
+
+	data := &AutoscaleScalingRulesData{
+		StabilizationWindowSeconds: rules.StabilizationWindowSeconds,
+		SelectPolicy:               rules.SelectPolicy,
+	}
+	for _, policy := range rules.Policies {
+		data.Policies = append(data.Policies, AutoscalingPolicyData{
+			Type:          policy.Type,
+			Value:         policy.Value,
+			PeriodSeconds: policy.PeriodSeconds,
+		})
+	}
+
 	return data
 }
 
+func buildContainerSecurityContextData(securityContext ContainerSecurityContextParams) ContainerSecurityContextData {
+	return ContainerSecurityContextData{
+		RunAsNonRoot:             securityContext.RunAsNonRoot,
+		RunAsUser:                securityContext.RunAsUser,
+		RunAsGroup:               securityContext.RunAsGroup,
+		ReadOnlyRootFilesystem:   securityContext.ReadOnlyRootFilesystem,
+		AllowPrivilegeEscalation: securityContext.AllowPrivilegeEscalation,
+		DropCapabilities:         securityContext.DropCapabilities,
+		AddCapabilities:          securityContext.AddCapabilities,
+	}
+}
+
 func buildSidecar(sidecar *SidecarParams, request RequestParams) SidecarData {
 	builtSidecar := SidecarData{
 		Type:                 sidecar.Type,
@@ -282,6 +734,7 @@ func buildSidecar(sidecar *SidecarParams, request RequestParams) SidecarData {
 		MemoryRequest:        sidecar.Memory.Request,
 		MemoryLimit:          sidecar.Memory.Limit,
 		EnvironmentVariables: sidecar.EnvironmentVariables,
+		SecurityContext:      buildContainerSecurityContextData(sidecar.SecurityContext),
 		SidecarSpecificProperties: map[string]interface{}{
 			"healthcheckpath":                   sidecar.HealthCheckPath,
 			"dbinstanceconnectionname":          sidecar.DbInstanceConnectionName,
@@ -319,6 +772,44 @@ func addEnvironmentVariableIfNotSet(environmentVariables map[string]interface{},
 	return environmentVariables
 }
 
+// buildEnvironmentVariables turns values with a secretKeyRef, configMapKeyRef, fieldRef or resourceFieldRef into a valueFrom source;
+// a secretKeyRef without name refers to the application secrets
+func buildEnvironmentVariables(environmentVariables map[string]interface{}, nameWithTrack string) map[string]interface{} {
+
+	if environmentVariables == nil {
+		return nil
+	}
+
+	builtEnvironmentVariables := map[string]interface{}{}
+	for name, value := range environmentVariables {
+		valueFrom, ok := value.(map[string]interface{})
+		if !ok {
+			builtEnvironmentVariables[name] = value
+			continue
+		}
+
+		if secretKeyRef, ok := valueFrom["secretKeyRef"].(map[string]interface{}); ok {
+			if _, ok := secretKeyRef["name"]; !ok {
+				secretKeyRefWithName := map[string]interface{}{"name": fmt.Sprintf("%v-secrets", nameWithTrack)}
+				for k, v := range secretKeyRef {
+					secretKeyRefWithName[k] = v
+				}
+				valueFrom = map[string]interface{}{"secretKeyRef": secretKeyRefWithName}
+			}
+		}
+
+		yamlBytes, err := yaml.Marshal(valueFrom)
+		if err == nil {
+			builtEnvironmentVariables[name] = EnvironmentVariableSourceData{
+				ValueFrom:     valueFrom,
+				ValueFromYAML: strings.TrimSuffix(string(yamlBytes), "\n"),
+			}
+		}
+	}
+
+	return builtEnvironmentVariables
+}
+
 // a valid label must be an empty string or consist of alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character (e.g. 'MyValue',  or 'my_value',  or '12345', regex used for validation is '(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])?')
 func sanitizeLabel(value string) string {
 
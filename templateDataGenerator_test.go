@@ -650,6 +650,220 @@ func TestGenerateTemplateData(t *testing.T) {
 		assert.Equal(t, 1, templateData.Container.Readiness.TimeoutSeconds)
 	})
 
+	t.Run("SetsReadinessTypeThresholdsAndPeriodToReadinessProbeParams", func(t *testing.T) {
+
+		params := Params{
+			Container: ContainerParams{
+				ReadinessProbe: ProbeParams{
+					Type:             "tcp",
+					PeriodSeconds:    5,
+					FailureThreshold: 6,
+					SuccessThreshold: 2,
+				},
+			},
+		}
+
+		// act
+		templateData := generateTemplateData(params, -1, "", "")
+
+		assert.Equal(t, "tcp", templateData.Container.Readiness.Type)
+		assert.Equal(t, 5, templateData.Container.Readiness.PeriodSeconds)
+		assert.Equal(t, 6, templateData.Container.Readiness.FailureThreshold)
+		assert.Equal(t, 2, templateData.Container.Readiness.SuccessThreshold)
+	})
+
+	t.Run("IncludesReadinessOnContainerIfTypeIsNotHttpEvenWithOpenrestySidecar", func(t *testing.T) {
+
+		params := Params{
+			Container: ContainerParams{
+				Port: 5000,
+				ReadinessProbe: ProbeParams{
+					Type: "tcp",
+					Port: 5000,
+				},
+			},
+			Sidecar: SidecarParams{
+				Type: "openresty",
+			},
+		}
+
+		// act
+		templateData := generateTemplateData(params, -1, "", "")
+
+		assert.True(t, templateData.Container.Readiness.IncludeOnContainer)
+	})
+
+	t.Run("SetsStartupProbeIfSet", func(t *testing.T) {
+
+		params := Params{
+			Container: ContainerParams{
+				StartupProbe: &ProbeParams{
+					Type:             "http",
+					Path:             "/liveness",
+					Port:             5000,
+					FailureThreshold: 30,
+				},
+			},
+		}
+
+		// act
+		templateData := generateTemplateData(params, -1, "", "")
+
+		assert.True(t, templateData.Container.Startup.IncludeOnContainer)
+		assert.Equal(t, "/liveness", templateData.Container.Startup.Path)
+		assert.Equal(t, 30, templateData.Container.Startup.FailureThreshold)
+	})
+
+	t.Run("DoesNotIncludeStartupProbeIfNotSet", func(t *testing.T) {
+
+		params := Params{}
+
+		// act
+		templateData := generateTemplateData(params, -1, "", "")
+
+		assert.False(t, templateData.Container.Startup.IncludeOnContainer)
+	})
+
+	t.Run("SetsSchedulingFromSchedulingParams", func(t *testing.T) {
+
+		params := Params{
+			Scheduling: SchedulingParams{
+				NodeSelector:              map[string]string{"cloud.google.com/gke-nodepool": "dedicated"},
+				Tolerations:               []TolerationParams{{Key: "dedicated", Operator: "Equal", Value: "myapp", Effect: "NoSchedule"}},
+				TopologySpreadConstraints: []TopologySpreadConstraintParams{{TopologyKey: "topology.kubernetes.io/zone", MaxSkew: 2, WhenUnsatisfiable: "DoNotSchedule"}},
+				AntiAffinity:              AntiAffinityParams{Type: "preferred", TopologyKey: "kubernetes.io/hostname", Weight: 80},
+				PriorityClassName:         "high-priority",
+			},
+		}
+
+		// act
+		templateData := generateTemplateData(params, -1, "", "")
+
+		assert.Equal(t, map[string]string{"cloud.google.com/gke-nodepool": "dedicated"}, templateData.NodeSelector)
+		assert.Equal(t, []TolerationData{{Key: "dedicated", Operator: "Equal", Value: "myapp", Effect: "NoSchedule"}}, templateData.Tolerations)
+		assert.Equal(t, []TopologySpreadConstraintData{{TopologyKey: "topology.kubernetes.io/zone", MaxSkew: 2, WhenUnsatisfiable: "DoNotSchedule"}}, templateData.TopologySpreadConstraints)
+		assert.Equal(t, "preferred", templateData.AntiAffinityType)
+		assert.Equal(t, "kubernetes.io/hostname", templateData.AntiAffinityTopologyKey)
+		assert.Equal(t, 80, templateData.AntiAffinityWeight)
+		assert.Equal(t, "high-priority", templateData.PriorityClassName)
+	})
+
+	t.Run("SetsSecurityContextsFromSecurityContextParams", func(t *testing.T) {
+
+		trueValue := true
+		falseValue := false
+		group := 2000
+		params := Params{
+			SecurityContext: SecurityContextParams{
+				Pod: PodSecurityContextParams{
+					RunAsNonRoot:   &trueValue,
+					FSGroup:        &group,
+					SeccompProfile: "RuntimeDefault",
+				},
+				Container: ContainerSecurityContextParams{
+					ReadOnlyRootFilesystem: &trueValue,
+					DropCapabilities:       []string{"ALL"},
+				},
+			},
+			Sidecars: []*SidecarParams{
+				{
+					Type: "openresty",
+					SecurityContext: ContainerSecurityContextParams{
+						ReadOnlyRootFilesystem: &falseValue,
+					},
+				},
+			},
+		}
+
+		// act
+		templateData := generateTemplateData(params, -1, "", "")
+
+		assert.Equal(t, PodSecurityContextData{RunAsNonRoot: &trueValue, FSGroup: &group, SeccompProfile: "RuntimeDefault"}, templateData.PodSecurityContext)
+		assert.Equal(t, ContainerSecurityContextData{ReadOnlyRootFilesystem: &trueValue, DropCapabilities: []string{"ALL"}}, templateData.Container.SecurityContext)
+		assert.Equal(t, ContainerSecurityContextData{ReadOnlyRootFilesystem: &falseValue}, templateData.Sidecars[1].SecurityContext)
+	})
+
+	t.Run("SetsContainerCommandAndArgs", func(t *testing.T) {
+
+		params := Params{
+			Container: ContainerParams{
+				Command: []string{"/app/worker"},
+				Args:    []string{"--queue", "orders"},
+			},
+		}
+
+		// act
+		templateData := generateTemplateData(params, -1, "", "")
+
+		assert.Equal(t, []string{"/app/worker"}, templateData.Container.Command)
+		assert.Equal(t, []string{"--queue", "orders"}, templateData.Container.Args)
+	})
+
+	t.Run("SetsInitContainersWithApplicationImageIfImageIsEmpty", func(t *testing.T) {
+
+		params := Params{
+			App: "myapp",
+			Container: ContainerParams{
+				ImageRepository: "extensions",
+				ImageName:       "myapp",
+				ImageTag:        "1.0.0",
+			},
+			InitContainers: []InitContainerParams{
+				{
+					Name:    "migrate",
+					Command: []string{"/app/migrate"},
+					EnvironmentVariables: map[string]interface{}{
+						"DB_PASSWORD": map[string]interface{}{"secretKeyRef": map[string]interface{}{"key": "db-password"}},
+					},
+					CPU:    CPUParams{Request: "100m", Limit: "200m"},
+					Memory: MemoryParams{Request: "64Mi", Limit: "128Mi"},
+				},
+				{
+					Name:  "wait",
+					Image: "busybox:1.27.2",
+				},
+			},
+		}
+
+		// act
+		templateData := generateTemplateData(params, -1, "", "")
+
+		assert.Equal(t, 2, len(templateData.InitContainers))
+		assert.Equal(t, "migrate", templateData.InitContainers[0].Name)
+		assert.Equal(t, "extensions/myapp:1.0.0", templateData.InitContainers[0].Image)
+		assert.Equal(t, []string{"/app/migrate"}, templateData.InitContainers[0].Command)
+		assert.Equal(t, "100m", templateData.InitContainers[0].CPURequest)
+		assert.Equal(t, "128Mi", templateData.InitContainers[0].MemoryLimit)
+		assert.Equal(t, "secretKeyRef:\n  key: db-password\n  name: myapp-secrets", templateData.InitContainers[0].EnvironmentVariables["DB_PASSWORD"].(EnvironmentVariableSourceData).ValueFromYAML)
+		assert.Equal(t, "busybox:1.27.2", templateData.InitContainers[1].Image)
+	})
+
+	t.Run("SetsAutoscaleMetricsAndBehavior", func(t *testing.T) {
+
+		params := Params{
+			Autoscale: AutoscaleParams{
+				MemoryPercentage: 75,
+				Metrics: []AutoscaleMetricParams{
+					{Type: "object", Name: "requests_per_second", Target: "2k", TargetType: "Value", Object: AutoscaleMetricObjectParams{APIVersion: "v1", Kind: "Service", Name: "myapp"}},
+				},
+				Behavior: AutoscaleBehaviorParams{
+					ScaleUp: &AutoscaleScalingRulesParams{
+						SelectPolicy: "Max",
+						Policies:     []AutoscalingPolicyParams{{Type: "Pods", Value: 4, PeriodSeconds: 15}},
+					},
+				},
+			},
+		}
+
+		// act
+		templateData := generateTemplateData(params, -1, "", "")
+
+		assert.Equal(t, 75, templateData.TargetMemoryPercentage)
+		assert.Equal(t, []AutoscaleMetricData{{Type: "object", Name: "requests_per_second", Target: "2k", TargetType: "Value", ObjectAPIVersion: "v1", ObjectKind: "Service", ObjectName: "myapp"}}, templateData.AutoscaleMetrics)
+		assert.Equal(t, &AutoscaleScalingRulesData{SelectPolicy: "Max", Policies: []AutoscalingPolicyData{{Type: "Pods", Value: 4, PeriodSeconds: 15}}}, templateData.AutoscaleScaleUp)
+		assert.Nil(t, templateData.AutoscaleScaleDown)
+	})
+
 	t.Run("SetsEnvironmentVariablesToContainerEnvironmentVariablesParam", func(t *testing.T) {
 
 		params := Params{
@@ -904,6 +1118,71 @@ func TestGenerateTemplateData(t *testing.T) {
 		assert.Equal(t, "value2", templateData.Sidecars[0].EnvironmentVariables["MY_OTHER_CUSTOM_ENV"])
 	})
 
+	t.Run("SetsEnvironmentVariablesWithSourceAsValueFrom", func(t *testing.T) {
+
+		params := Params{
+			App: "myapp",
+			Container: ContainerParams{
+				EnvironmentVariables: map[string]interface{}{
+					"MY_CUSTOM_ENV": "value1",
+					"POD_NAME":      map[string]interface{}{"fieldRef": map[string]interface{}{"fieldPath": "metadata.name"}},
+				},
+			},
+		}
+
+		// act
+		templateData := generateTemplateData(params, -1, "", "")
+
+		assert.Equal(t, "value1", templateData.Container.EnvironmentVariables["MY_CUSTOM_ENV"])
+		source, ok := templateData.Container.EnvironmentVariables["POD_NAME"].(EnvironmentVariableSourceData)
+		assert.True(t, ok)
+		assert.Equal(t, map[string]interface{}{"fieldRef": map[string]interface{}{"fieldPath": "metadata.name"}}, source.ValueFrom)
+		assert.Equal(t, "fieldRef:\n  fieldPath: metadata.name", source.ValueFromYAML)
+	})
+
+	t.Run("SetsSecretKeyRefWithoutNameToApplicationSecrets", func(t *testing.T) {
+
+		params := Params{
+			App:    "myapp",
+			Action: "deploy-canary",
+			Container: ContainerParams{
+				EnvironmentVariables: map[string]interface{}{
+					"DB_PASSWORD": map[string]interface{}{"secretKeyRef": map[string]interface{}{"key": "db-password"}},
+				},
+			},
+		}
+
+		// act
+		templateData := generateTemplateData(params, -1, "", "")
+
+		source, ok := templateData.Container.EnvironmentVariables["DB_PASSWORD"].(EnvironmentVariableSourceData)
+		assert.True(t, ok)
+		assert.Equal(t, map[string]interface{}{"secretKeyRef": map[string]interface{}{"key": "db-password", "name": "myapp-canary-secrets"}}, source.ValueFrom)
+		assert.Equal(t, "secretKeyRef:\n  key: db-password\n  name: myapp-canary-secrets", source.ValueFromYAML)
+	})
+
+	t.Run("SetsSidecarEnvironmentVariablesWithSourceAsValueFrom", func(t *testing.T) {
+
+		params := Params{
+			App: "myapp",
+			Sidecars: []*SidecarParams{
+				&SidecarParams{
+					Type: "cloudsqlproxy",
+					EnvironmentVariables: map[string]interface{}{
+						"POD_IP": map[string]interface{}{"fieldRef": map[string]interface{}{"fieldPath": "status.podIP"}},
+					},
+				},
+			},
+		}
+
+		// act
+		templateData := generateTemplateData(params, -1, "", "")
+
+		source, ok := templateData.Sidecars[1].EnvironmentVariables["POD_IP"].(EnvironmentVariableSourceData)
+		assert.True(t, ok)
+		assert.Equal(t, map[string]interface{}{"fieldRef": map[string]interface{}{"fieldPath": "status.podIP"}}, source.ValueFrom)
+	})
+
 	t.Run("SetsCloudSQLProxySpecificArgsToSidecarSpecificProperties", func(t *testing.T) {
 
 		params := Params{
@@ -2036,4 +2315,438 @@ func TestGenerateTemplateData(t *testing.T) {
 		assert.Equal(t, 300, templateData.NginxIngressProxyReadTimeout)
 	})
 
+	t.Run("SetsNetworkPolicyIngressNamespacesForPrivateDeployment", func(t *testing.T) {
+
+		scrape := true
+		params := Params{
+			Kind:       "deployment",
+			Visibility: "private",
+			Container: ContainerParams{
+				Metrics: MetricsParams{Scrape: &scrape, Port: 5000},
+			},
+			Sidecar: SidecarParams{Type: "openresty"},
+			NetworkPolicy: NetworkPolicyParams{
+				Enabled:                     true,
+				IngressControllerNamespaces: []string{"ingress-nginx"},
+				PrometheusNamespace:         "monitoring",
+				GCELoadBalancerRanges:       []string{"35.191.0.0/16"},
+			},
+		}
+
+		// act
+		templateData := generateTemplateData(params, -1, "", "")
+
+		assert.True(t, templateData.UseNetworkPolicy)
+		assert.Equal(t, []string{"ingress-nginx"}, templateData.NetworkPolicy.IngressNamespaces)
+		assert.Equal(t, 0, len(templateData.NetworkPolicy.IngressIPBlocks))
+		assert.False(t, templateData.NetworkPolicy.AllowAllIngress)
+		assert.Equal(t, []int{5000, 9101}, templateData.NetworkPolicy.MetricsPorts)
+		assert.False(t, templateData.NetworkPolicy.RestrictEgress)
+	})
+
+	t.Run("SetsNetworkPolicyIngressIPBlocksForIapDeployment", func(t *testing.T) {
+
+		params := Params{
+			Kind:       "deployment",
+			Visibility: "iap",
+			NetworkPolicy: NetworkPolicyParams{
+				Enabled:                     true,
+				IngressControllerNamespaces: []string{"ingress-nginx"},
+				GCELoadBalancerRanges:       []string{"35.191.0.0/16", "130.211.0.0/22"},
+			},
+		}
+
+		// act
+		templateData := generateTemplateData(params, -1, "", "")
+
+		assert.Equal(t, 0, len(templateData.NetworkPolicy.IngressNamespaces))
+		assert.Equal(t, []string{"35.191.0.0/16", "130.211.0.0/22"}, templateData.NetworkPolicy.IngressIPBlocks)
+	})
+
+	t.Run("DoesNotAllowVisibilityTrafficForJobs", func(t *testing.T) {
+
+		params := Params{
+			Kind:       "job",
+			Visibility: "public",
+			NetworkPolicy: NetworkPolicyParams{
+				Enabled: true,
+				Egress: []NetworkPolicyEgressParams{
+					{CIDR: "10.0.0.0/8", Ports: []NetworkPolicyPortParams{{Port: 5432, Protocol: "TCP"}}},
+				},
+			},
+		}
+
+		// act
+		templateData := generateTemplateData(params, -1, "", "")
+
+		assert.False(t, templateData.NetworkPolicy.AllowAllIngress)
+		assert.True(t, templateData.NetworkPolicy.RestrictEgress)
+		assert.Equal(t, []NetworkPolicyEgressData{{CIDR: "10.0.0.0/8", Ports: []NetworkPolicyPortData{{Port: 5432, Protocol: "TCP"}}}}, templateData.NetworkPolicy.Egress)
+	})
+
+	t.Run("DoesNotSetNetworkPolicyIfNotEnabled", func(t *testing.T) {
+
+		params := Params{
+			Kind:       "deployment",
+			Visibility: "public",
+		}
+
+		// act
+		templateData := generateTemplateData(params, -1, "", "")
+
+		assert.False(t, templateData.UseNetworkPolicy)
+		assert.False(t, templateData.NetworkPolicy.AllowAllIngress)
+	})
+
+	t.Run("SetsAPIVersionsForClusterVersion", func(t *testing.T) {
+
+		params := Params{
+			ClusterVersion: "v1.20.15-gke.1000",
+		}
+
+		// act
+		templateData := generateTemplateData(params, -1, "", "")
+
+		assert.Equal(t, "networking.k8s.io/v1", templateData.IngressAPIVersion)
+		assert.Equal(t, "policy/v1beta1", templateData.PodDisruptionBudgetAPIVersion)
+		assert.Equal(t, "batch/v1beta1", templateData.CronJobAPIVersion)
+		assert.Equal(t, "autoscaling/v2beta2", templateData.HorizontalPodAutoscalerAPIVersion)
+	})
+
+	t.Run("SetsRoutesWithIngressPathsAndApplicationServiceName", func(t *testing.T) {
+
+		params := Params{
+			App:        "myapp",
+			Visibility: "iap",
+			Routes: []RouteParams{
+				{Path: "/api", Port: "https"},
+				{Hosts: []string{"static.example.com"}, Path: "/static/", Service: "static-bucket", Port: "80"},
+			},
+		}
+
+		// act
+		templateData := generateTemplateData(params, -1, "", "")
+
+		assert.Equal(t, []RouteData{
+			{Path: "/api/*", InternalPath: "/api/", ServiceName: "myapp", ServicePort: "https"},
+			{Hosts: []string{"static.example.com"}, Path: "/static/*", InternalPath: "/static/", ServiceName: "static-bucket", ServicePort: "80"},
+		}, templateData.Routes)
+	})
+
+	t.Run("SetsNginxIngressFeatureAnnotationsFromIngressParams", func(t *testing.T) {
+
+		falseValue := false
+		params := Params{
+			Ingress: IngressParams{
+				CORS:          IngressCORSParams{Enabled: true, AllowOrigin: "https://www.example.com", MaxAge: 600},
+				RateLimit:     IngressRateLimitParams{RPS: 10, BurstMultiplier: 3, Whitelist: []string{"10.0.0.0/8", "192.168.0.0/16"}},
+				Auth:          IngressAuthParams{Type: "external", URL: "https://auth.example.com/verify", ResponseHeaders: []string{"X-User", "X-Email"}},
+				HTTPSRedirect: &falseValue,
+				CustomErrors:  []int{404, 503},
+			},
+		}
+
+		// act
+		templateData := generateTemplateData(params, -1, "", "")
+
+		assert.Equal(t, map[string]string{
+			"nginx.ingress.kubernetes.io/enable-cors":            "true",
+			"nginx.ingress.kubernetes.io/cors-allow-origin":      "https://www.example.com",
+			"nginx.ingress.kubernetes.io/cors-max-age":           "600",
+			"nginx.ingress.kubernetes.io/limit-rps":              "10",
+			"nginx.ingress.kubernetes.io/limit-burst-multiplier": "3",
+			"nginx.ingress.kubernetes.io/limit-whitelist":        "10.0.0.0/8,192.168.0.0/16",
+			"nginx.ingress.kubernetes.io/auth-url":               "https://auth.example.com/verify",
+			"nginx.ingress.kubernetes.io/auth-response-headers":  "X-User,X-Email",
+			"nginx.ingress.kubernetes.io/ssl-redirect":           "false",
+			"nginx.ingress.kubernetes.io/custom-http-errors":     "404,503",
+		}, templateData.NginxIngressFeatureAnnotations)
+	})
+
+	t.Run("SetsNoNginxIngressFeatureAnnotationsIfIngressParamsAreEmpty", func(t *testing.T) {
+
+		params := Params{}
+
+		// act
+		templateData := generateTemplateData(params, -1, "", "")
+
+		assert.Equal(t, 0, len(templateData.NginxIngressFeatureAnnotations))
+	})
+
+	t.Run("SetsGrpcsBackendProtocolAndHttp2AppProtocolIfProtocolIsGrpc", func(t *testing.T) {
+
+		params := Params{
+			Protocol: "grpc",
+			Sidecar:  SidecarParams{Type: "openresty"},
+		}
+
+		// act
+		templateData := generateTemplateData(params, -1, "", "")
+
+		assert.Equal(t, "GRPCS", templateData.NginxIngressBackendProtocol)
+		assert.Equal(t, "HTTP2", templateData.ServiceAppProtocol)
+		assert.Equal(t, "grpc", templateData.Sidecars[0].EnvironmentVariables["OFFLOAD_PROTOCOL"])
+	})
+
+	t.Run("SetsHttpsBackendProtocolAndHttp2AppProtocolIfProtocolIsHttp2", func(t *testing.T) {
+
+		params := Params{
+			Protocol: "http2",
+		}
+
+		// act
+		templateData := generateTemplateData(params, -1, "", "")
+
+		assert.Equal(t, "HTTPS", templateData.NginxIngressBackendProtocol)
+		assert.Equal(t, "HTTP2", templateData.ServiceAppProtocol)
+	})
+
+	t.Run("SetsHttpsProtocolsAndNoOffloadProtocolIfProtocolIsHttp1", func(t *testing.T) {
+
+		params := Params{
+			Protocol: "http1",
+			Sidecar:  SidecarParams{Type: "openresty"},
+		}
+
+		// act
+		templateData := generateTemplateData(params, -1, "", "")
+
+		assert.Equal(t, "HTTPS", templateData.NginxIngressBackendProtocol)
+		assert.Equal(t, "HTTPS", templateData.ServiceAppProtocol)
+		assert.NotContains(t, templateData.Sidecars[0].EnvironmentVariables, "OFFLOAD_PROTOCOL")
+	})
+
+	t.Run("UsesLetsencryptCertificateSecretByDefault", func(t *testing.T) {
+
+		params := Params{
+			App:         "myapp",
+			Certificate: CertificateParams{Provider: "letsencrypt"},
+		}
+
+		// act
+		templateData := generateTemplateData(params, -1, "", "")
+
+		assert.Equal(t, "myapp-letsencrypt-certificate", templateData.CertificateSecretName)
+		assert.False(t, templateData.CertificateSecretHasTLSKeys)
+	})
+
+	t.Run("UsesCertManagerCertificateSecretIfProviderIsCertManager", func(t *testing.T) {
+
+		params := Params{
+			App:         "myapp",
+			Certificate: CertificateParams{Provider: "cert-manager", Issuer: "letsencrypt-prod", IssuerKind: "ClusterIssuer"},
+		}
+
+		// act
+		templateData := generateTemplateData(params, -1, "", "")
+
+		assert.Equal(t, "myapp-certificate", templateData.CertificateSecretName)
+		assert.True(t, templateData.CertificateSecretHasTLSKeys)
+		assert.Equal(t, "letsencrypt-prod", templateData.CertManagerIssuer)
+		assert.Equal(t, "ClusterIssuer", templateData.CertManagerIssuerKind)
+	})
+
+	t.Run("UsesExistingSecretIfProviderIsSecret", func(t *testing.T) {
+
+		params := Params{
+			App:         "myapp",
+			Certificate: CertificateParams{Provider: "secret", SecretName: "myapp-ev-certificate"},
+		}
+
+		// act
+		templateData := generateTemplateData(params, -1, "", "")
+
+		assert.Equal(t, "secret", templateData.CertificateProvider)
+		assert.Equal(t, "myapp-ev-certificate", templateData.CertificateSecretName)
+		assert.True(t, templateData.CertificateSecretHasTLSKeys)
+	})
+
+	t.Run("KeepsLetsencryptCertificateSecretIfProviderIsManagedWithoutSecretName", func(t *testing.T) {
+
+		params := Params{
+			App:         "myapp",
+			Certificate: CertificateParams{Provider: "managed"},
+		}
+
+		// act
+		templateData := generateTemplateData(params, -1, "", "")
+
+		assert.Equal(t, "myapp-letsencrypt-certificate", templateData.CertificateSecretName)
+		assert.False(t, templateData.CertificateSecretHasTLSKeys)
+	})
+
+	t.Run("SetsProxiedCloudflareDNSAnnotationsForPublicAndUnproxiedForInternalHostsByDefault", func(t *testing.T) {
+
+		params := Params{
+			Hosts:         []string{"myapp.example.com"},
+			InternalHosts: []string{"myapp.internal.example.com"},
+		}
+
+		// act
+		templateData := generateTemplateData(params, -1, "", "")
+
+		assert.Equal(t, "cloudflare", templateData.PublicDNSProvider)
+		assert.Equal(t, map[string]string{"estafette.io/cloudflare-dns": "true", "estafette.io/cloudflare-proxy": "true", "estafette.io/cloudflare-hostnames": "myapp.example.com"}, templateData.PublicDNSAnnotations)
+		assert.Equal(t, "cloudflare", templateData.InternalDNSProvider)
+		assert.Equal(t, map[string]string{"estafette.io/cloudflare-dns": "true", "estafette.io/cloudflare-proxy": "false", "estafette.io/cloudflare-hostnames": "myapp.internal.example.com"}, templateData.InternalDNSAnnotations)
+	})
+
+	t.Run("SetsDNSAnnotationsOfSelectedProviders", func(t *testing.T) {
+
+		falseValue := false
+		params := Params{
+			Hosts:         []string{"myapp.example.com", "www.example.com"},
+			InternalHosts: []string{"myapp.internal.example.com"},
+			DNS: DNSParams{
+				Public:   DNSProviderParams{Provider: "external-dns", Proxy: &falseValue},
+				Internal: DNSProviderParams{Provider: "google-cloud-dns"},
+			},
+		}
+
+		// act
+		templateData := generateTemplateData(params, -1, "", "")
+
+		assert.Equal(t, map[string]string{"external-dns.alpha.kubernetes.io/hostname": "myapp.example.com,www.example.com"}, templateData.PublicDNSAnnotations)
+		assert.Equal(t, map[string]string{"estafette.io/google-cloud-dns": "true", "estafette.io/google-cloud-dns-hostnames": "myapp.internal.example.com"}, templateData.InternalDNSAnnotations)
+	})
+
+	t.Run("SetsNoDNSAnnotationsIfProviderIsNone", func(t *testing.T) {
+
+		params := Params{
+			Hosts: []string{"myapp.example.com"},
+			DNS:   DNSParams{Public: DNSProviderParams{Provider: "none"}},
+		}
+
+		// act
+		templateData := generateTemplateData(params, -1, "", "")
+
+		assert.Equal(t, "none", templateData.PublicDNSProvider)
+		assert.Equal(t, 0, len(templateData.PublicDNSAnnotations))
+	})
+
+	t.Run("RoutesThroughMeshInsteadOfIngressIfMeshTypeIsIstio", func(t *testing.T) {
+
+		retries := 3
+		params := Params{
+			App:           "myapp",
+			Kind:          "deployment",
+			Action:        "deploy-canary",
+			Visibility:    "private",
+			Protocol:      "grpc",
+			InternalHosts: []string{"myapp.internal.example.com"},
+			Mesh:          MeshParams{Type: "istio", GatewaySelector: map[string]string{"istio": "ingressgateway"}, GatewayNamespace: "istio-system", CanaryWeight: 20},
+			Request:       RequestParams{Timeout: "30s", Retries: &retries},
+			NetworkPolicy: NetworkPolicyParams{Enabled: true, IngressControllerNamespaces: []string{"ingress-nginx"}},
+		}
+
+		// act
+		templateData := generateTemplateData(params, -1, "", "")
+
+		assert.True(t, templateData.UseIstio)
+		assert.False(t, templateData.UseNginxIngress)
+		assert.False(t, templateData.UseDNSAnnotationsOnIngress)
+		assert.Equal(t, "ClusterIP", templateData.ServiceType)
+		assert.Equal(t, []string{"myapp"}, templateData.Mesh.Gateways)
+		assert.True(t, templateData.Mesh.RenderGateway)
+		assert.True(t, templateData.Mesh.UseSubsets)
+		assert.Equal(t, 0, templateData.Mesh.CanaryWeight)
+		assert.Equal(t, "grpc", templateData.Mesh.ServicePortName)
+		assert.Equal(t, "30s", templateData.Mesh.Timeout)
+		assert.Equal(t, 3, *templateData.Mesh.Retries)
+		assert.Equal(t, []string{"istio-system"}, templateData.NetworkPolicy.IngressNamespaces)
+	})
+
+	t.Run("BindsToMeshGatewayWithoutSubsetsForDeploySimple", func(t *testing.T) {
+
+		params := Params{
+			App:    "myapp",
+			Kind:   "deployment",
+			Action: "deploy-simple",
+			Mesh:   MeshParams{Type: "istio", Gateway: "istio-system/public-gateway"},
+		}
+
+		// act
+		templateData := generateTemplateData(params, -1, "", "")
+
+		assert.Equal(t, []string{"istio-system/public-gateway"}, templateData.Mesh.Gateways)
+		assert.False(t, templateData.Mesh.RenderGateway)
+		assert.False(t, templateData.Mesh.UseSubsets)
+		assert.Equal(t, "http", templateData.Mesh.ServicePortName)
+	})
+
+	t.Run("SetsStatefulSetDataWithHeadlessServiceNameIfKindIsStatefulSet", func(t *testing.T) {
+
+		params := Params{
+			App:  "myapp",
+			Kind: "statefulset",
+			StatefulSet: StatefulSetParams{
+				Replicas:            3,
+				PodManagementPolicy: "OrderedReady",
+				VolumeClaimTemplates: []VolumeClaimTemplateParams{
+					{Name: "data", MountPath: "/data", Size: "10Gi", AccessModes: []string{"ReadWriteOnce"}},
+				},
+			},
+		}
+
+		// act
+		templateData := generateTemplateData(params, -1, "", "")
+
+		assert.Equal(t, "myapp-headless", templateData.StatefulSet.ServiceName)
+		assert.Equal(t, 3, templateData.StatefulSet.Replicas)
+		assert.Equal(t, []VolumeClaimTemplateData{
+			{Name: "data", MountPath: "/data", Size: "10Gi", AccessModes: []string{"ReadWriteOnce"}},
+		}, templateData.StatefulSet.VolumeClaimTemplates)
+	})
+
+	t.Run("AddsTolerationForAllTaintsIfKindIsDaemonSetAndTolerateAllTaintsIsTrue", func(t *testing.T) {
+
+		params := Params{
+			App:  "myapp",
+			Kind: "daemonset",
+			DaemonSet: DaemonSetParams{
+				HostNetwork:       true,
+				UpdateStrategy:    "RollingUpdate",
+				MaxUnavailable:    "1",
+				TolerateAllTaints: true,
+			},
+		}
+
+		// act
+		templateData := generateTemplateData(params, -1, "", "")
+
+		assert.True(t, templateData.DaemonSet.HostNetwork)
+		assert.Equal(t, "1", templateData.DaemonSet.MaxUnavailable)
+		assert.Contains(t, templateData.Tolerations, TolerationData{Operator: "Exists"})
+	})
+
+	t.Run("SetsUseInternalIngressToTrueIfInternalHostsAreSet", func(t *testing.T) {
+
+		params := Params{
+			Kind:          "deployment",
+			Visibility:    "private",
+			InternalHosts: []string{"ci.estafette.internal"},
+		}
+
+		// act
+		templateData := generateTemplateData(params, -1, "", "")
+
+		assert.True(t, templateData.UseInternalIngress)
+	})
+
+	t.Run("SetsUseInternalIngressToFalseIfMeshTypeIsIstioAndInternalHostsAreSet", func(t *testing.T) {
+
+		params := Params{
+			Kind:          "deployment",
+			Visibility:    "private",
+			InternalHosts: []string{"ci.estafette.internal"},
+			Mesh:          MeshParams{Type: "istio", GatewayNamespace: "istio-system"},
+		}
+
+		// act
+		templateData := generateTemplateData(params, -1, "", "")
+
+		assert.True(t, templateData.UseIstio)
+		assert.False(t, templateData.UseInternalIngress)
+		assert.Equal(t, []string{"ci.estafette.internal"}, templateData.InternalHosts)
+	})
 }
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	validationSeverityError   = "error"
+	validationSeverityWarning = "warning"
+)
+
+// ValidationError describes a problem with a property, identified by its json path so it can be traced back to the manifest
+type ValidationError struct {
+	Path     string `json:"path"`
+	Severity string `json:"severity"`
+	Code     string `json:"code"`
+	Message  string `json:"message"`
+}
+
+func newValidationError(path, code, format string, args ...interface{}) ValidationError {
+	return ValidationError{
+		Path:     path,
+		Severity: validationSeverityError,
+		Code:     code,
+		Message:  fmt.Sprintf(format, args...),
+	}
+}
+
+func newValidationWarning(path, code, format string, args ...interface{}) ValidationError {
+	return ValidationError{
+		Path:     path,
+		Severity: validationSeverityWarning,
+		Code:     code,
+		Message:  fmt.Sprintf(format, args...),
+	}
+}
+
+func (e ValidationError) Error() string {
+	return e.Message
+}
+
+// ValidationReport is written to disk so the Estafette UI or a pull request bot can annotate the manifest
+type ValidationReport struct {
+	Valid  bool              `json:"valid"`
+	Errors []ValidationError `json:"errors"`
+}
+
+func hasValidationErrors(validationErrors []ValidationError) bool {
+	for _, e := range validationErrors {
+		if e.Severity == validationSeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// printValidationErrors renders errors and warnings as a table
+func printValidationErrors(w io.Writer, validationErrors []ValidationError) {
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "SEVERITY\tCODE\tPATH\tMESSAGE")
+	for _, e := range validationErrors {
+		path := e.Path
+		if path == "" {
+			path = "-"
+		}
+		fmt.Fprintf(tw, "%v\t%v\t%v\t%v\n", strings.ToUpper(e.Severity), e.Code, path, e.Message)
+	}
+
+	tw.Flush()
+}
+
+// writeValidationReport stores errors and warnings as json in file
+func writeValidationReport(file string, validationErrors []ValidationError) error {
+
+	report := ValidationReport{
+		Valid:  !hasValidationErrors(validationErrors),
+		Errors: validationErrors,
+	}
+	if report.Errors == nil {
+		report.Errors = []ValidationError{}
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(file, data, 0644)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintValidationErrors(t *testing.T) {
+
+	t.Run("PrintsErrorsAndWarningsAsTable", func(t *testing.T) {
+
+		validationErrors := []ValidationError{
+			newValidationError("sidecars[1].sqlproxyport", "required", "The port on which the Cloud SQL Proxy listens is required"),
+			newValidationWarning("sidecar", "deprecated", "The sidecar field is deprecated"),
+		}
+		var output bytes.Buffer
+
+		// act
+		printValidationErrors(&output, validationErrors)
+
+		lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+		assert.Equal(t, 3, len(lines))
+		assert.Equal(t, "SEVERITY  CODE        PATH                      MESSAGE", lines[0])
+		assert.Equal(t, "ERROR     required    sidecars[1].sqlproxyport  The port on which the Cloud SQL Proxy listens is required", lines[1])
+		assert.Equal(t, "WARNING   deprecated  sidecar                   The sidecar field is deprecated", lines[2])
+	})
+}
+
+func TestWriteValidationReport(t *testing.T) {
+
+	t.Run("WritesErrorsAsJSON", func(t *testing.T) {
+
+		dir, err := ioutil.TempDir("", "validation-report")
+		assert.Nil(t, err)
+		defer os.RemoveAll(dir)
+		file := filepath.Join(dir, "validation.json")
+
+		validationErrors := []ValidationError{
+			newValidationError("autoscale.min", "invalid-value", "Autoscaling min replicas must be larger than zero"),
+		}
+
+		// act
+		err = writeValidationReport(file, validationErrors)
+
+		assert.Nil(t, err)
+		data, err := ioutil.ReadFile(file)
+		assert.Nil(t, err)
+		var report ValidationReport
+		err = json.Unmarshal(data, &report)
+		assert.Nil(t, err)
+		assert.False(t, report.Valid)
+		assert.Equal(t, validationErrors, report.Errors)
+	})
+
+	t.Run("WritesValidReportIfThereAreOnlyWarnings", func(t *testing.T) {
+
+		dir, err := ioutil.TempDir("", "validation-report")
+		assert.Nil(t, err)
+		defer os.RemoveAll(dir)
+		file := filepath.Join(dir, "validation.json")
+
+		// act
+		err = writeValidationReport(file, []ValidationError{newValidationWarning("sidecar", "deprecated", "The sidecar field is deprecated")})
+
+		assert.Nil(t, err)
+		data, err := ioutil.ReadFile(file)
+		assert.Nil(t, err)
+		var report ValidationReport
+		err = json.Unmarshal(data, &report)
+		assert.Nil(t, err)
+		assert.True(t, report.Valid)
+		assert.Equal(t, 1, len(report.Errors))
+	})
+}